@@ -0,0 +1,140 @@
+// Command gateway-ctl is the operator CLI for the gateway. Its "login"
+// subcommand drives an OIDC device flow login against an institutional
+// SSO provider, so a human operator can obtain a bearer token for the
+// gateway's admin endpoints without ever handling an HMAC key. Its
+// "check-leases" subcommand runs that token against the gateway's
+// lease/receiver consistency check.
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/oidc"
+)
+
+func main() {
+	if len(os.Args) < 2 {
+		fmt.Fprintln(os.Stderr, "usage: gateway-ctl login [flags] | check-leases [flags]")
+		os.Exit(2)
+	}
+
+	switch os.Args[1] {
+	case "login":
+		runLogin(os.Args[2:])
+	case "check-leases":
+		runCheckLeases(os.Args[2:])
+	default:
+		fmt.Fprintf(os.Stderr, "unknown subcommand %q\n", os.Args[1])
+		os.Exit(2)
+	}
+}
+
+func runLogin(args []string) {
+	fs := flag.NewFlagSet("login", flag.ExitOnError)
+	clientID := fs.String("client-id", "", "OIDC client ID registered for gateway-ctl")
+	deviceAuthEndpoint := fs.String("device-auth-endpoint", "", "OIDC provider device authorization endpoint")
+	tokenEndpoint := fs.String("token-endpoint", "", "OIDC provider token endpoint")
+	scope := fs.String("scope", "openid email", "OIDC scopes to request")
+	tokenFile := fs.String("token-file", defaultTokenFile(), "path to save the obtained token")
+	fs.Parse(args)
+
+	if *clientID == "" || *deviceAuthEndpoint == "" || *tokenEndpoint == "" {
+		log.Fatal("gateway-ctl login: --client-id, --device-auth-endpoint, and --token-endpoint are required")
+	}
+
+	client := oidc.NewClient(*clientID, *deviceAuthEndpoint, *tokenEndpoint)
+	dc, err := client.StartDeviceFlow(*scope)
+	if err != nil {
+		log.Fatalf("gateway-ctl login: %v", err)
+	}
+
+	if dc.VerificationURIComplete != "" {
+		fmt.Printf("Open %s to finish logging in.\n", dc.VerificationURIComplete)
+	} else {
+		fmt.Printf("Open %s and enter code %s to finish logging in.\n", dc.VerificationURI, dc.UserCode)
+	}
+
+	interval := time.Duration(dc.Interval) * time.Second
+	if interval <= 0 {
+		interval = 5 * time.Second
+	}
+	tok, err := client.PollForToken(dc.DeviceCode, interval, time.Duration(dc.ExpiresIn)*time.Second)
+	if err != nil {
+		log.Fatalf("gateway-ctl login: %v", err)
+	}
+
+	buf, err := json.MarshalIndent(tok, "", "  ")
+	if err != nil {
+		log.Fatalf("gateway-ctl login: could not encode token: %v", err)
+	}
+	if err := os.WriteFile(*tokenFile, buf, 0600); err != nil {
+		log.Fatalf("gateway-ctl login: could not save token to %s: %v", *tokenFile, err)
+	}
+	fmt.Printf("logged in, token saved to %s\n", *tokenFile)
+}
+
+// runCheckLeases calls the gateway's lease/receiver consistency check
+// endpoint with a token obtained from "login", printing its JSON report.
+// With --repair, the gateway additionally discards any orphaned upstream
+// sessions the check finds.
+func runCheckLeases(args []string) {
+	fs := flag.NewFlagSet("check-leases", flag.ExitOnError)
+	gatewayURL := fs.String("gateway-url", "", "base URL of the gateway to check, e.g. https://gateway.example.org")
+	tokenFile := fs.String("token-file", defaultTokenFile(), "path to a token saved by the login subcommand")
+	repair := fs.Bool("repair", false, "also repair any orphaned upstream sessions the check finds")
+	fs.Parse(args)
+
+	if *gatewayURL == "" {
+		log.Fatal("gateway-ctl check-leases: --gateway-url is required")
+	}
+
+	buf, err := os.ReadFile(*tokenFile)
+	if err != nil {
+		log.Fatalf("gateway-ctl check-leases: could not read token from %s: %v", *tokenFile, err)
+	}
+	var tok oidc.Token
+	if err := json.Unmarshal(buf, &tok); err != nil {
+		log.Fatalf("gateway-ctl check-leases: could not parse token from %s: %v", *tokenFile, err)
+	}
+
+	method := http.MethodGet
+	if *repair {
+		method = http.MethodPost
+	}
+	req, err := http.NewRequest(method, strings.TrimRight(*gatewayURL, "/")+"/api/v1/leases/consistency-check", nil)
+	if err != nil {
+		log.Fatalf("gateway-ctl check-leases: %v", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+tok.IDToken)
+
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatalf("gateway-ctl check-leases: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatalf("gateway-ctl check-leases: could not read response: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		log.Fatalf("gateway-ctl check-leases: gateway returned %s: %s", resp.Status, body)
+	}
+	fmt.Println(string(body))
+}
+
+func defaultTokenFile() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".gateway-ctl-token.json"
+	}
+	return home + "/.cvmfs/gateway-ctl-token.json"
+}
@@ -0,0 +1,430 @@
+// Command gateway runs the CVMFS repository gateway HTTP service.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/alertrules"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/config"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/featureflag"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/frontend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/ingest"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/metrics"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/objectcache"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/objectstore"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/precheck"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/recorder"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/scratch"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/selftest"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/shadow"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/sysd"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/throttle"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/tokenkey"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/whitelist"
+)
+
+// signingKeyOverlap is how long a rotated-out lease token signing key
+// keeps validating tokens signed under it.
+const signingKeyOverlap = 24 * time.Hour
+
+func main() {
+	if len(os.Args) > 1 && os.Args[1] == "selftest" {
+		if err := selftest.Run(os.Stdout); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "alert-rules" {
+		if err := alertrules.WriteTo(os.Stdout, alertrules.Rules()); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	configPath := flag.String("config", "/etc/cvmfs/gateway/user.conf", "path to the gateway configuration file")
+	checkOnly := flag.Bool("check", false, "run the startup self-check and exit without serving traffic")
+	flag.Parse()
+
+	cfg, err := config.ReadConfig(*configPath)
+	if err != nil {
+		log.Printf("could not read config, using defaults: %v", err)
+		cfg = config.Default()
+	}
+
+	acc, err := access.ReadConfigWithFragments(cfg.AccessConfig, cfg.AccessFragmentsDir)
+	if err != nil {
+		log.Fatalf("could not read access config: %v", err)
+	}
+
+	if *checkOnly {
+		report := precheck.Run(cfg, acc)
+		report.WriteTo(os.Stdout)
+		if report.Fatal() {
+			os.Exit(1)
+		}
+		return
+	}
+
+	storageHealthCheck := func() error {
+		_, err := os.Stat(cfg.StoragePath)
+		return err
+	}
+
+	var objCache *objectcache.Cache
+	if cfg.ObjectCacheCapacity > 0 {
+		objCache = objectcache.New(cfg.ObjectCacheCapacity)
+	}
+
+	pool := backend.NewPool(backend.NewMemLeaseDB(), newReceiver(cfg, objCache), storageHealthCheck)
+	pool.ObjectCache = objCache
+	if err := pool.CheckReceiverVersion(); err != nil {
+		log.Fatalf("receiver compatibility check failed: %v", err)
+	}
+	if cfg.FIPSMode {
+		pool.FIPSMode = true
+		log.Printf("FIPS mode enabled: payload digest negotiation restricted to FIPS-approved algorithms")
+	}
+	if cfg.ScratchDir != "" {
+		pool.Scratch = scratch.NewFileStore(cfg.ScratchDir)
+		if cfg.EncryptScratchAtRest {
+			scratchKeys, err := scratch.OpenKeyStore(cfg.ScratchKeyPath)
+			if err != nil {
+				log.Fatalf("could not open scratch encryption key store: %v", err)
+			}
+			pool.Scratch = scratch.NewEncryptingStore(pool.Scratch, scratch.RepoKeyLookup(scratchKeys))
+		}
+		if cfg.MinScratchFreeBytes > 0 {
+			pool.EnableDiskWatchdog(backend.DiskSpaceCheck(cfg.ScratchDir, cfg.MinScratchFreeBytes))
+			stopDiskWatchdog := make(chan struct{})
+			go pool.StartDiskWatchdog(stopDiskWatchdog)
+			defer close(stopDiskWatchdog)
+		}
+	}
+	if cfg.CommitLockStuckSeconds > 0 {
+		pool.EnableLockWatchdog(time.Duration(cfg.CommitLockStuckSeconds) * time.Second)
+		stopLockWatchdog := make(chan struct{})
+		go pool.StartLockWatchdog(stopLockWatchdog)
+		defer close(stopLockWatchdog)
+	}
+	if cfg.LeaseHistoryRetentionSeconds > 0 {
+		pool.EnableLeaseHistory(time.Duration(cfg.LeaseHistoryRetentionSeconds) * time.Second)
+	}
+	if cfg.LeaseStatePath != "" {
+		if err := pool.LoadPersistedState(cfg.LeaseStatePath); err != nil {
+			log.Fatalf("could not load persisted lease state: %v", err)
+		}
+		pool.EnableStatePersistence(cfg.LeaseStatePath)
+		stopStatePersist := make(chan struct{})
+		go pool.StartStatePersistence(stopStatePersist)
+		defer close(stopStatePersist)
+	}
+	if cfg.LeadershipTTLSeconds > 0 {
+		pool.LeadershipTTL = time.Duration(cfg.LeadershipTTLSeconds) * time.Second
+	}
+	if cfg.InstanceID != "" {
+		pool.InstanceID = cfg.InstanceID
+	}
+
+	if cfg.WhitelistSigningServiceURL != "" {
+		pool.WhitelistSigner = whitelist.NewHTTPSigner(cfg.WhitelistSigningServiceURL)
+	} else if signer, ok := pool.Receiver.(receiver.WhitelistSigner); ok {
+		pool.WhitelistSigner = signer
+	}
+	pool.ResignWhitelistAfterCommit = cfg.ResignWhitelistAfterCommit
+	if cfg.WhitelistResignIntervalSeconds > 0 && pool.WhitelistSigner != nil {
+		schedule := backend.NewWhitelistSchedule(pool, acc.Repos, time.Duration(cfg.WhitelistResignIntervalSeconds)*time.Second)
+		if cfg.WhitelistExpiryAlertSeconds > 0 {
+			schedule.AlertBefore = time.Duration(cfg.WhitelistExpiryAlertSeconds) * time.Second
+			schedule.OnExpiring = func(info receiver.WhitelistInfo) {
+				log.Printf("whitelist signature for %s expires at %s, within the configured alert window", info.Repository, info.Expiry.Format(time.RFC3339))
+			}
+		}
+		stopWhitelistSchedule := make(chan struct{})
+		go schedule.Start(stopWhitelistSchedule)
+		defer close(stopWhitelistSchedule)
+	}
+
+	promSink := newMetricsSink(cfg, pool)
+	if pr, ok := pool.Receiver.(*receiver.ProcessReceiver); ok {
+		pr.Metrics = pool.Metrics
+	}
+	stopHealth := make(chan struct{})
+	go pool.StartHealthMonitor(stopHealth)
+	defer close(stopHealth)
+
+	stopJanitor := make(chan struct{})
+	go pool.StartJanitor(stopJanitor)
+	defer close(stopJanitor)
+
+	if cfg.AccessFragmentsDir != "" {
+		stopFragments := make(chan struct{})
+		go access.Watch(acc, cfg.AccessFragmentsDir, stopFragments)
+		defer close(stopFragments)
+	}
+
+	signingKey, err := tokenkey.Open(cfg.SigningKeyPath, signingKeyOverlap)
+	if err != nil {
+		log.Fatalf("could not open lease token signing key: %v", err)
+	}
+	pool.ReceiptSigner = signingKey.Sign
+
+	api := frontend.NewAPI(pool, acc)
+	api.SigningKey = signingKey
+	api.EnablePprof = cfg.EnablePprof
+	api.MaxInlineObjectBytes = cfg.MaxInlineObjectBytes
+	api.CanaryFeatures = cfg.CanaryFeatures
+	features := featureflag.NewSetFromDefaults(cfg.FeatureFlags)
+	api.Features = features
+	pool.Features = features
+	api.SpoolThresholdBytes = cfg.PayloadSpoolThresholdBytes
+	api.RequireAuthForReads = cfg.RequireAuthForReads
+	api.InstancePeers = cfg.InstancePeers
+	if cfg.GlobalIngestBytesPerSecond > 0 {
+		api.GlobalLimiter = throttle.NewLimiter(cfg.GlobalIngestBytesPerSecond, cfg.GlobalIngestBytesPerSecond)
+	}
+	if cfg.MinUploadBytesPerSecond > 0 {
+		api.MinUploadBytesPerSecond = float64(cfg.MinUploadBytesPerSecond)
+		api.SlowClientEvictionTimeout = time.Duration(cfg.SlowClientEvictionSeconds) * time.Second
+	}
+	if len(cfg.ConcurrencyLimits) > 0 {
+		api.Limiter = frontend.NewConcurrencyLimiter(cfg.ConcurrencyLimits)
+	}
+	api.ClockSkewTolerance = time.Duration(cfg.ClockSkewToleranceSeconds) * time.Second
+
+	if len(cfg.VirtualHosts) > 0 {
+		vhosts := frontend.NewVirtualHosts(acc)
+		for host, path := range cfg.VirtualHosts {
+			hostAcc, err := access.ReadConfig(path)
+			if err != nil {
+				log.Fatalf("could not read access config for virtual host %s: %v", host, err)
+			}
+			vhosts.Add(host, hostAcc)
+		}
+		api.UseVirtualHosts(vhosts)
+	}
+
+	mux := http.NewServeMux()
+	api.Register(mux)
+	if promSink != nil {
+		mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+			promSink.WriteTo(w)
+		})
+	}
+
+	var handler http.Handler = mux
+	if cfg.ShadowURL != "" {
+		handler = shadow.NewMirror(handler, cfg.ShadowURL, cfg.ShadowFraction)
+		log.Printf("mirroring %.0f%% of read-only traffic to shadow gateway %s", cfg.ShadowFraction*100, cfg.ShadowURL)
+	}
+	if cfg.DebugRecorderCapacity > 0 {
+		rec := recorder.New(handler, cfg.DebugRecorderCapacity, recorder.KeyFromBearer, recorder.RepoFromPath)
+		api.Recorder = rec
+		handler = rec
+	}
+
+	listener, err := newListener(cfg)
+	if err != nil {
+		log.Fatalf("could not create listener: %v", err)
+	}
+	log.Printf("gateway listening on %s", listener.Addr())
+
+	if err := sysd.Notify("READY=1"); err != nil {
+		log.Printf("sd_notify READY failed: %v", err)
+	}
+
+	if err := http.Serve(listener, handler); err != nil {
+		sysd.Notify("STOPPING=1")
+		log.Fatal(err)
+	}
+}
+
+// newReceiver builds the Receiver used for commits. When cfg.S3Endpoint is
+// set, it returns the experimental receiver-less ingest.DirectReceiver
+// instead, optionally still delegating the catalog commit to a
+// cvmfs_receiver process if cfg.S3DelegateCommitsToReceiver is set, and
+// recording every object it writes into objCache if one is configured.
+// When cfg.ReceiverAffinity is set, each repository gets its own
+// cvmfs_receiver process instance instead of sharing one across the
+// whole gateway.
+func newReceiver(cfg config.Config, objCache *objectcache.Cache) receiver.Receiver {
+	if cfg.S3Endpoint != "" {
+		store := objectstore.NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKeyID, cfg.S3SecretAccessKey)
+		store.Prefix = cfg.S3ObjectPrefix
+		var finalizer receiver.Receiver
+		if cfg.S3DelegateCommitsToReceiver {
+			finalizer = newProcessReceiver(cfg)
+		}
+		direct := ingest.NewDirectReceiver(store, finalizer)
+		direct.Cache = objCache
+		return direct
+	}
+	if !cfg.ReceiverAffinity {
+		return newProcessReceiver(cfg)
+	}
+	return receiver.NewAffinityPool(func(repository string) receiver.Receiver {
+		return newProcessReceiver(cfg)
+	})
+}
+
+// newProcessReceiver builds a ProcessReceiver configured with the
+// commit/payload dead-man-switch timeouts from cfg. Its Metrics sink is
+// wired in later, once newMetricsSink has picked one, for the common
+// (non-affinity, non-S3) case where pool.Receiver is this exact instance.
+func newProcessReceiver(cfg config.Config) *receiver.ProcessReceiver {
+	pr := receiver.NewProcessReceiver(cfg.ReceiverBinary)
+	pr.CommitTimeout = time.Duration(cfg.ReceiverCommitTimeoutSeconds) * time.Second
+	pr.PayloadTimeout = time.Duration(cfg.ReceiverPayloadTimeoutSeconds) * time.Second
+	return pr
+}
+
+// newMetricsSink wires pool.Metrics to the backend selected by
+// cfg.MetricsBackend and, for the "prometheus" backend, returns the sink
+// so its exposition can be served on /metrics. StatsD and Graphite push
+// their own observations out-of-band, so there's nothing for the gateway
+// itself to serve, and this returns nil.
+func newMetricsSink(cfg config.Config, pool *backend.Pool) *metrics.PrometheusSink {
+	switch cfg.MetricsBackend {
+	case "statsd":
+		sink, err := metrics.NewStatsDSink(cfg.MetricsAddress, "cvmfs_gateway.")
+		if err != nil {
+			log.Printf("could not start statsd metrics sink, instrumentation disabled: %v", err)
+			return nil
+		}
+		pool.Metrics = sink
+		return nil
+	case "graphite":
+		pool.Metrics = metrics.NewGraphiteSink(cfg.MetricsAddress)
+		return nil
+	default:
+		sink := metrics.NewPrometheusSink()
+		pool.Metrics = sink
+		return sink
+	}
+}
+
+// newListener prefers a systemd-activated socket, if one was passed to
+// the process, then falls back to cfg.ListenSocket (a Unix domain
+// socket), then to cfg.ListenAddresses (explicit host:port pairs, for
+// IPv6-only or dual-stack sites), then to a plain TCP listener on
+// cfg.Port bound to every address (the historical implicit
+// dual-stack-if-the-kernel-allows-it behavior of ":<port>").
+func newListener(cfg config.Config) (net.Listener, error) {
+	activated, err := sysd.Listeners()
+	if err != nil {
+		return nil, err
+	}
+	if len(activated) > 0 {
+		return activated[0], nil
+	}
+
+	if cfg.ListenSocket != "" {
+		if err := os.RemoveAll(cfg.ListenSocket); err != nil {
+			return nil, fmt.Errorf("could not remove stale socket: %w", err)
+		}
+		return net.Listen("unix", cfg.ListenSocket)
+	}
+
+	if len(cfg.ListenAddresses) == 0 {
+		return net.Listen("tcp", fmt.Sprintf(":%d", cfg.Port))
+	}
+
+	listeners := make([]net.Listener, 0, len(cfg.ListenAddresses))
+	for _, addr := range cfg.ListenAddresses {
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			for _, opened := range listeners {
+				opened.Close()
+			}
+			return nil, fmt.Errorf("could not listen on %s: %w", addr, err)
+		}
+		listeners = append(listeners, l)
+	}
+	if len(listeners) == 1 {
+		return listeners[0], nil
+	}
+	return newMultiListener(listeners), nil
+}
+
+// multiListener merges Accept calls from several net.Listeners into one,
+// so http.Serve can treat multiple explicit listen addresses (e.g. a
+// separate IPv4 and IPv6 socket for a dual-stack configuration) as a
+// single listener.
+type multiListener struct {
+	listeners []net.Listener
+	conns     chan multiListenerAccept
+	closeOnce sync.Once
+	closed    chan struct{}
+}
+
+type multiListenerAccept struct {
+	conn net.Conn
+	err  error
+}
+
+func newMultiListener(listeners []net.Listener) *multiListener {
+	m := &multiListener{
+		listeners: listeners,
+		conns:     make(chan multiListenerAccept),
+		closed:    make(chan struct{}),
+	}
+	for _, l := range listeners {
+		go m.acceptLoop(l)
+	}
+	return m
+}
+
+func (m *multiListener) acceptLoop(l net.Listener) {
+	for {
+		conn, err := l.Accept()
+		select {
+		case m.conns <- multiListenerAccept{conn, err}:
+		case <-m.closed:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+func (m *multiListener) Accept() (net.Conn, error) {
+	res, ok := <-m.conns
+	if !ok {
+		return nil, fmt.Errorf("multiListener: closed")
+	}
+	return res.conn, res.err
+}
+
+func (m *multiListener) Close() error {
+	m.closeOnce.Do(func() { close(m.closed) })
+	var firstErr error
+	for _, l := range m.listeners {
+		if err := l.Close(); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+	return firstErr
+}
+
+// Addr returns the address of the first configured listener. Callers
+// that need every bound address should consult cfg.ListenAddresses
+// directly.
+func (m *multiListener) Addr() net.Addr {
+	return m.listeners[0].Addr()
+}
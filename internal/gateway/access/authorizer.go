@@ -0,0 +1,67 @@
+package access
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// Authorizer performs secondary authorization for a lease request, after
+// the key has passed HMAC verification and the built-in repo/IP checks.
+// It lets a site plug in a central policy system (an LHC VO framework, an
+// internal entitlement service) without changing the gateway's core auth
+// path. Implementations should return an error describing why the
+// request was denied; a nil error allows it.
+type Authorizer interface {
+	Authorize(keyID, repo, path string) error
+}
+
+// PassthroughAuthorizer allows every request. It is the gateway's default
+// Authorizer when no external policy service is configured.
+type PassthroughAuthorizer struct{}
+
+// Authorize always allows the request.
+func (PassthroughAuthorizer) Authorize(keyID, repo, path string) error { return nil }
+
+// HTTPAuthorizer defers authorization decisions to an external policy
+// service, POSTing the request's metadata and treating any non-2xx
+// response as a denial.
+type HTTPAuthorizer struct {
+	URL    string
+	Client *http.Client
+}
+
+// NewHTTPAuthorizer returns an HTTPAuthorizer that posts to url with a
+// default 5-second timeout.
+func NewHTTPAuthorizer(url string) *HTTPAuthorizer {
+	return &HTTPAuthorizer{URL: url, Client: &http.Client{Timeout: 5 * time.Second}}
+}
+
+type authorizeRequest struct {
+	KeyID string `json:"key_id"`
+	Repo  string `json:"repo"`
+	Path  string `json:"path"`
+}
+
+// Authorize POSTs the request metadata to the configured policy service
+// URL and denies the request unless the service responds with a 2xx
+// status.
+func (a *HTTPAuthorizer) Authorize(keyID, repo, path string) error {
+	body, err := json.Marshal(authorizeRequest{KeyID: keyID, Repo: repo, Path: path})
+	if err != nil {
+		return fmt.Errorf("authorizer: could not encode request: %w", err)
+	}
+
+	resp, err := a.Client.Post(a.URL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("authorizer: policy service unreachable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("authorizer: policy service denied the request (status %d)", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,38 @@
+package access
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPassthroughAuthorizerAllowsEverything(t *testing.T) {
+	var a Authorizer = PassthroughAuthorizer{}
+	if err := a.Authorize("keyA", "repo.example.org", "repo.example.org/x"); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestHTTPAuthorizerDeniesOnNon2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer srv.Close()
+
+	a := NewHTTPAuthorizer(srv.URL)
+	if err := a.Authorize("keyA", "repo.example.org", "repo.example.org/x"); err == nil {
+		t.Fatal("expected authorization to be denied")
+	}
+}
+
+func TestHTTPAuthorizerAllowsOn2xx(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer srv.Close()
+
+	a := NewHTTPAuthorizer(srv.URL)
+	if err := a.Authorize("keyA", "repo.example.org", "repo.example.org/x"); err != nil {
+		t.Fatalf("expected authorization to be allowed, got %v", err)
+	}
+}
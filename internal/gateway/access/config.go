@@ -0,0 +1,622 @@
+// Package access implements the gateway's access control configuration:
+// the set of repository keys and the repositories each key is allowed to
+// publish to.
+package access
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// KeyConfig describes a single repository key as loaded from the access
+// configuration file.
+type KeyConfig struct {
+	ID string `json:"id"`
+
+	// Secret is reserved for a future request-signing scheme; nothing in
+	// this package currently verifies a signature or MAC against it. Key
+	// authentication today is identity-based: a caller who supplies ID
+	// as its key_id/X-Key-ID is trusted to be that key's holder, so this
+	// gateway depends on transport-level trust (a private network, mTLS,
+	// or an authenticating reverse proxy) to keep IDs from being usable
+	// by anyone but their intended owner. It is never exposed via
+	// KeyInfo.
+	Secret string `json:"secret"`
+	Repo   string `json:"repo_subpath"`
+
+	// Admin marks a key as authorized for administrative endpoints, on
+	// top of whatever repositories it may also be allowed to publish to.
+	// It is equivalent to setting Role to RoleAdmin, and kept alongside
+	// it so existing configs naming only "admin": true keep working.
+	Admin bool `json:"admin,omitempty"`
+
+	// Role grants this key a level of access to the admin API: "viewer",
+	// "operator", or "admin". Leave empty for a key that only publishes
+	// and has no admin API access at all. Ignored if Admin is also set,
+	// since Admin already implies the highest role.
+	Role Role `json:"role,omitempty"`
+
+	// MaxBytesPerSecond caps this key's payload upload throughput,
+	// throttling the ingest connection rather than rejecting it. Leave
+	// at 0 for no per-key cap beyond whatever global ingest cap the
+	// gateway is configured with.
+	MaxBytesPerSecond int64 `json:"max_bytes_per_second,omitempty"`
+
+	// DefaultPathTemplate is applied by ExpandLeasePath when this key
+	// requests a lease on a bare repository path with no subpath,
+	// sparing sandboxed publishers from having to know their exact
+	// subtree. It supports the placeholder {key}, replaced with the
+	// key's ID; for example "sandboxes/{key}" routes a bare lease
+	// request into that key's own sandbox directory.
+	DefaultPathTemplate string `json:"default_path_template,omitempty"`
+}
+
+// KeyInfo describes a key's metadata for introspection purposes, with its
+// secret redacted.
+type KeyInfo struct {
+	ID         string    `json:"id"`
+	Admin      bool      `json:"admin,omitempty"`
+	Repos      []string  `json:"repos"`
+	LastUsedAt time.Time `json:"last_used_at,omitempty"`
+
+	// Role is this key's effective admin API role: RoleAdmin if Admin is
+	// set, otherwise the key's configured Role, otherwise empty.
+	Role                Role   `json:"role,omitempty"`
+	DefaultPathTemplate string `json:"default_path_template,omitempty"`
+}
+
+// RepoConfig describes the keys authorized to publish to a repository,
+// and optionally the client IP ranges allowed or denied from doing so.
+type RepoConfig struct {
+	Repo               string   `json:"domain"`
+	Keys               []string `json:"keys"`
+	AllowCIDRs         []string `json:"allow_cidrs,omitempty"`
+	DenyCIDRs          []string `json:"deny_cidrs,omitempty"`
+	MaxTransactionSize int64    `json:"max_transaction_size,omitempty"`
+	MirrorURLs         []string `json:"mirror_urls,omitempty"`
+
+	// ForbiddenPaths lists extra path.Match glob patterns, matched
+	// against the lease path relative to the repository root, that are
+	// rejected for this repository on top of the gateway's built-in
+	// content policy rules.
+	ForbiddenPaths []string `json:"forbidden_paths,omitempty"`
+
+	// TagTemplate names the pattern used to auto-generate a catalog tag
+	// when a commit doesn't specify one. Supports the placeholders
+	// {date}, {seq}, and {key}. Defaults to "{date}-{seq}".
+	TagTemplate string `json:"tag_template,omitempty"`
+
+	// RemoteURL, if set, marks this repository as hosted by another
+	// gateway: lease, payload, and cancel requests are forwarded there
+	// instead of being handled locally. RemoteKeyID names the key this
+	// gateway authenticates to the upstream with.
+	RemoteURL   string `json:"remote_url,omitempty"`
+	RemoteKeyID string `json:"remote_key_id,omitempty"`
+
+	// FreezeWindows lists scheduled periods during which new leases on
+	// this repository are refused, e.g. for data-taking periods or
+	// conference freezes.
+	FreezeWindows []FreezeWindow `json:"freeze_windows,omitempty"`
+
+	// Protected marks this repository as requiring a second authorized
+	// key to approve a commit before it's applied, implementing a
+	// four-eyes policy for production software repositories.
+	Protected bool `json:"protected,omitempty"`
+
+	// Canary opts this repository into the gateway's canary feature set
+	// (see Config.CanaryFeatures), so a new protocol feature can be
+	// rolled out to a small number of consenting repositories and
+	// observed before it's enabled everywhere.
+	Canary bool `json:"canary,omitempty"`
+}
+
+// FreezeWindow is a single scheduled freeze period for a repository: a
+// 5-field cron expression (minute hour day-of-month month day-of-week)
+// naming when the freeze begins, and how long it lasts once triggered.
+type FreezeWindow struct {
+	Schedule        string `json:"schedule"`
+	DurationSeconds int    `json:"duration_seconds"`
+	Reason          string `json:"reason,omitempty"`
+}
+
+// RemoteConfig describes the upstream gateway a repository's requests
+// should be forwarded to, and the key used to authenticate there.
+type RemoteConfig struct {
+	URL   string
+	KeyID string
+}
+
+// Config is the in-memory, thread-safe representation of the access
+// configuration. It maps key IDs to secrets and repositories to the set
+// of key IDs allowed to publish to them.
+type Config struct {
+	mu          sync.RWMutex
+	keys        map[string]KeyConfig
+	repos       map[string]map[string]bool
+	ipACL       map[string]*ipACL
+	maxBytes    map[string]int64
+	mirrors     map[string][]string
+	forbidden   map[string][]string
+	tagTemplate map[string]string
+	remotes     map[string]RemoteConfig
+	freezes     map[string][]FreezeWindow
+	protected   map[string]bool
+	canary      map[string]bool
+
+	// allowedRepos is the reverse of repos: for each key ID, the sorted
+	// list of repositories it is authorized to publish to.
+	allowedRepos map[string][]string
+
+	// lastUsed records the most recent successful authentication
+	// timestamp for each key ID.
+	lastUsed map[string]time.Time
+
+	// fragmentRepos is the set of repositories currently sourced from a
+	// LoadFragments directory rather than the main config file, so a
+	// later reload knows to remove one whose fragment file disappeared.
+	fragmentRepos map[string]bool
+
+	// groupRoles maps an OIDC group name (as carried in an ID token's
+	// "groups" claim) to the admin API role it grants, for operators who
+	// authenticate via institutional SSO rather than an HMAC key.
+	groupRoles map[string]Role
+}
+
+// NewConfig returns an empty access configuration.
+func NewConfig() *Config {
+	return &Config{
+		keys:          make(map[string]KeyConfig),
+		repos:         make(map[string]map[string]bool),
+		ipACL:         make(map[string]*ipACL),
+		maxBytes:      make(map[string]int64),
+		mirrors:       make(map[string][]string),
+		forbidden:     make(map[string][]string),
+		tagTemplate:   make(map[string]string),
+		remotes:       make(map[string]RemoteConfig),
+		freezes:       make(map[string][]FreezeWindow),
+		protected:     make(map[string]bool),
+		canary:        make(map[string]bool),
+		allowedRepos:  make(map[string][]string),
+		lastUsed:      make(map[string]time.Time),
+		fragmentRepos: make(map[string]bool),
+		groupRoles:    make(map[string]Role),
+	}
+}
+
+type fileFormat struct {
+	Repos      []RepoConfig    `json:"repos"`
+	Keys       []KeyConfig     `json:"keys"`
+	GroupRoles map[string]Role `json:"group_roles,omitempty"`
+}
+
+// ReadConfig loads an access configuration from a JSON file.
+func ReadConfig(path string) (*Config, error) {
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read access config: %w", err)
+	}
+
+	var raw fileFormat
+	if err := json.Unmarshal(buf, &raw); err != nil {
+		return nil, fmt.Errorf("could not parse access config: %w", err)
+	}
+
+	cfg := NewConfig()
+	for _, k := range raw.Keys {
+		cfg.keys[k.ID] = k
+	}
+	for _, r := range raw.Repos {
+		if err := cfg.addRepo(r); err != nil {
+			return nil, err
+		}
+	}
+	for group, role := range raw.GroupRoles {
+		cfg.groupRoles[group] = role
+	}
+	return cfg, nil
+}
+
+// addRepo installs or replaces r's configuration. It assumes the caller
+// either holds c.mu or is building a Config not yet shared with other
+// goroutines.
+func (c *Config) addRepo(r RepoConfig) error {
+	acl, err := newIPACL(r.AllowCIDRs, r.DenyCIDRs)
+	if err != nil {
+		return fmt.Errorf("could not parse IP ACL for repository %s: %w", r.Repo, err)
+	}
+
+	c.removeRepo(r.Repo)
+
+	set := make(map[string]bool, len(r.Keys))
+	for _, k := range r.Keys {
+		set[k] = true
+	}
+	c.repos[r.Repo] = set
+	c.ipACL[r.Repo] = acl
+	c.maxBytes[r.Repo] = r.MaxTransactionSize
+	c.mirrors[r.Repo] = r.MirrorURLs
+	c.forbidden[r.Repo] = r.ForbiddenPaths
+	c.tagTemplate[r.Repo] = r.TagTemplate
+	c.freezes[r.Repo] = r.FreezeWindows
+	c.protected[r.Repo] = r.Protected
+	c.canary[r.Repo] = r.Canary
+	if r.RemoteURL != "" {
+		c.remotes[r.Repo] = RemoteConfig{URL: r.RemoteURL, KeyID: r.RemoteKeyID}
+	}
+	for _, k := range r.Keys {
+		c.allowedRepos[k] = append(c.allowedRepos[k], r.Repo)
+	}
+	for _, repos := range c.allowedRepos {
+		sort.Strings(repos)
+	}
+	return nil
+}
+
+// removeRepo drops repo and all of its per-repository configuration,
+// including its entries in every key's allowedRepos list. It assumes
+// the caller either holds c.mu or is building a Config not yet shared
+// with other goroutines.
+func (c *Config) removeRepo(repo string) {
+	delete(c.repos, repo)
+	delete(c.ipACL, repo)
+	delete(c.maxBytes, repo)
+	delete(c.mirrors, repo)
+	delete(c.forbidden, repo)
+	delete(c.tagTemplate, repo)
+	delete(c.remotes, repo)
+	delete(c.freezes, repo)
+	delete(c.protected, repo)
+	delete(c.canary, repo)
+	for key, repos := range c.allowedRepos {
+		c.allowedRepos[key] = removeString(repos, repo)
+	}
+}
+
+// removeString returns list with every occurrence of s removed,
+// reusing list's backing array.
+func removeString(list []string, s string) []string {
+	out := list[:0]
+	for _, v := range list {
+		if v != s {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// ReadConfigWithFragments loads the main access configuration file at
+// path, then applies any per-repository fragments found in
+// fragmentsDir, so operators can manage each repository's configuration
+// independently instead of editing one shared file. fragmentsDir may be
+// empty or not exist, in which case no fragments are applied.
+func ReadConfigWithFragments(path, fragmentsDir string) (*Config, error) {
+	cfg, err := ReadConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	if fragmentsDir == "" {
+		return cfg, nil
+	}
+	if err := cfg.LoadFragments(fragmentsDir); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// LoadFragments merges per-repository configuration fragments found in
+// dir into c, adding repositories whose fragment is new, updating ones
+// whose fragment changed, and removing ones whose fragment file has
+// since disappeared. dir not existing is not an error: it's treated as
+// no fragments.
+//
+// A fragment that fails to parse — for instance because it was caught
+// mid-write — aborts the whole call before any change is applied, so a
+// partial write never corrupts the configuration already in use; the
+// previous state is left untouched and the caller can simply retry
+// later (Watch does this on a poll loop).
+func (c *Config) LoadFragments(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			entries = nil
+		} else {
+			return fmt.Errorf("could not list access config fragments: %w", err)
+		}
+	}
+
+	seen := make(map[string]RepoConfig)
+	for _, e := range entries {
+		if e.IsDir() || !strings.HasSuffix(e.Name(), ".json") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		buf, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read access config fragment %s: %w", path, err)
+		}
+		var r RepoConfig
+		if err := json.Unmarshal(buf, &r); err != nil {
+			return fmt.Errorf("could not parse access config fragment %s: %w", path, err)
+		}
+		if r.Repo == "" {
+			return fmt.Errorf("access config fragment %s has no repository domain", path)
+		}
+		seen[r.Repo] = r
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for repo := range c.fragmentRepos {
+		if _, ok := seen[repo]; !ok {
+			c.removeRepo(repo)
+			delete(c.fragmentRepos, repo)
+		}
+	}
+	for repo, r := range seen {
+		if err := c.addRepo(r); err != nil {
+			return fmt.Errorf("could not apply access config fragment for %s: %w", repo, err)
+		}
+		c.fragmentRepos[repo] = true
+	}
+	return nil
+}
+
+// Secret returns the secret associated with a key ID. It is reserved for
+// a future request-signing scheme -- see the caveat on KeyConfig.Secret --
+// and has no caller in this codebase today.
+func (c *Config) Secret(keyID string) (string, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	k, ok := c.keys[keyID]
+	return k.Secret, ok
+}
+
+// KnownRepo reports whether repo is present in the access configuration.
+// The gateway's janitor uses this to detect leases left over from a
+// repository that has since been removed from the config.
+func (c *Config) KnownRepo(repo string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	_, ok := c.repos[repo]
+	return ok
+}
+
+// Authorized reports whether the given key is allowed to publish to
+// repo. This is an identity check against keyID as asserted by the
+// caller, not a cryptographic one -- see the caveat on KeyConfig.Secret.
+func (c *Config) Authorized(keyID, repo string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	keys, ok := c.repos[repo]
+	if !ok {
+		return false
+	}
+	return keys[keyID]
+}
+
+// IPAllowed reports whether a client at the given address is allowed to
+// reach repo, according to the repository's allow/deny CIDR lists. A
+// repository with no configured lists allows any address.
+func (c *Config) IPAllowed(repo string, addr net.IP) bool {
+	c.mu.RLock()
+	acl, ok := c.ipACL[repo]
+	c.mu.RUnlock()
+	if !ok {
+		return true
+	}
+	return acl.allowed(addr)
+}
+
+// MaxTransactionSize returns the configured maximum number of payload
+// bytes accepted for a single lease on repo. A result of 0 means the
+// repository has no configured limit.
+func (c *Config) MaxTransactionSize(repo string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.maxBytes[repo]
+}
+
+// MirrorURLs returns the mirror endpoints that should be notified after a
+// successful commit to repo.
+func (c *Config) MirrorURLs(repo string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.mirrors[repo]
+}
+
+// ForbiddenPaths returns the repository-specific glob patterns rejected
+// by the content policy engine, on top of the gateway's built-in rules.
+func (c *Config) ForbiddenPaths(repo string) []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.forbidden[repo]
+}
+
+// TagTemplate returns the configured tag naming template for repo, or ""
+// if none is configured.
+func (c *Config) TagTemplate(repo string) string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.tagTemplate[repo]
+}
+
+// FreezeWindowsFor returns repo's configured freeze windows, or nil if
+// none are configured.
+func (c *Config) FreezeWindowsFor(repo string) []FreezeWindow {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.freezes[repo]
+}
+
+// GroupRole returns the most privileged admin API role granted by any of
+// groups, or "" if none of them are configured with one. It's used to
+// authorize OIDC-authenticated operators by the groups claim in their ID
+// token, mirroring how a KeyInfo's Role authorizes an HMAC key.
+func (c *Config) GroupRole(groups []string) Role {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var best Role
+	for _, g := range groups {
+		if role, ok := c.groupRoles[g]; ok && role.AtLeast(best) {
+			best = role
+		}
+	}
+	return best
+}
+
+// ExpandLeasePath resolves a lease request's path against keyID's
+// DefaultPathTemplate when path is bare (just the repository name, with
+// no subpath), so a sandboxed publisher can request a lease on
+// "myrepo" and land in its own configured subtree without needing to
+// spell it out on every request. Any path that already names a subpath
+// is returned unchanged, as is a bare path when the key has no
+// DefaultPathTemplate configured.
+func (c *Config) ExpandLeasePath(keyID, repo, path string) string {
+	if path != repo {
+		return path
+	}
+	c.mu.RLock()
+	tmpl := c.keys[keyID].DefaultPathTemplate
+	c.mu.RUnlock()
+	if tmpl == "" {
+		return path
+	}
+	return repo + "/" + strings.ReplaceAll(tmpl, "{key}", keyID)
+}
+
+// Protected reports whether repo requires a second authorized key to
+// approve a commit before it's applied.
+func (c *Config) Protected(repo string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.protected[repo]
+}
+
+// Canary reports whether repo has opted into the gateway's canary
+// feature set.
+func (c *Config) Canary(repo string) bool {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.canary[repo]
+}
+
+// Repos returns the sorted list of repositories present in the access
+// configuration.
+func (c *Config) Repos() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]string, 0, len(c.repos))
+	for repo := range c.repos {
+		out = append(out, repo)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// Validate reports configuration inconsistencies that don't prevent
+// ReadConfig from loading the file but indicate a mistake: a repository
+// authorizing a key ID that isn't defined anywhere in the "keys" section.
+func (c *Config) Validate() []string {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var issues []string
+	for repo, keys := range c.repos {
+		for keyID := range keys {
+			if _, ok := c.keys[keyID]; !ok {
+				issues = append(issues, fmt.Sprintf("repository %s authorizes unknown key %s", repo, keyID))
+			}
+		}
+	}
+	sort.Strings(issues)
+	return issues
+}
+
+// RemoteUpstream returns the upstream gateway configured for repo, if
+// any. A repository with no RemoteURL configured is hosted locally.
+func (c *Config) RemoteUpstream(repo string) (RemoteConfig, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	r, ok := c.remotes[repo]
+	return r, ok
+}
+
+func (c *Config) keyInfo(k KeyConfig) KeyInfo {
+	role := k.Role
+	if k.Admin {
+		role = RoleAdmin
+	}
+	return KeyInfo{
+		ID:                  k.ID,
+		Admin:               k.Admin,
+		Repos:               c.allowedRepos[k.ID],
+		LastUsedAt:          c.lastUsed[k.ID],
+		Role:                role,
+		DefaultPathTemplate: k.DefaultPathTemplate,
+	}
+}
+
+// RecordUse timestamps a successful authentication for keyID. It is a
+// no-op for unknown key IDs.
+func (c *Config) RecordUse(keyID string, at time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.keys[keyID]; !ok {
+		return
+	}
+	c.lastUsed[keyID] = at
+}
+
+// StaleKeys returns metadata for keys that have never authenticated, or
+// whose last successful authentication was before cutoff.
+func (c *Config) StaleKeys(cutoff time.Time) []KeyInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	var out []KeyInfo
+	for _, k := range c.keys {
+		if last, ok := c.lastUsed[k.ID]; ok && last.After(cutoff) {
+			continue
+		}
+		out = append(out, c.keyInfo(k))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// Keys returns metadata, with secrets redacted, for every configured key.
+func (c *Config) Keys() []KeyInfo {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	out := make([]KeyInfo, 0, len(c.keys))
+	for _, k := range c.keys {
+		out = append(out, c.keyInfo(k))
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+// KeyBandwidth returns the configured upload throughput cap, in bytes
+// per second, for keyID. 0 means the key has no per-key cap of its own.
+func (c *Config) KeyBandwidth(keyID string) int64 {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	return c.keys[keyID].MaxBytesPerSecond
+}
+
+// Key returns metadata, with the secret redacted, for a single key ID.
+func (c *Config) Key(id string) (KeyInfo, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	k, ok := c.keys[id]
+	if !ok {
+		return KeyInfo{}, false
+	}
+	return c.keyInfo(k), true
+}
@@ -0,0 +1,209 @@
+package access
+
+import (
+	"testing"
+	"time"
+)
+
+func exampleConfig() *Config {
+	cfg := NewConfig()
+	cfg.keys["keyA"] = KeyConfig{ID: "keyA", Secret: "s3cr3t", Repo: "repo.example.org"}
+	cfg.keys["admin"] = KeyConfig{ID: "admin", Secret: "s3cr3t2", Admin: true}
+	cfg.repos["repo.example.org"] = map[string]bool{"keyA": true}
+	cfg.allowedRepos["keyA"] = []string{"repo.example.org"}
+	return cfg
+}
+
+func TestKeysListsAllowedRepos(t *testing.T) {
+	cfg := exampleConfig()
+	for _, k := range cfg.Keys() {
+		if k.ID == "keyA" && (len(k.Repos) != 1 || k.Repos[0] != "repo.example.org") {
+			t.Fatalf("expected keyA to list repo.example.org, got %v", k.Repos)
+		}
+	}
+}
+
+func TestKeyReturnsAdminFlag(t *testing.T) {
+	cfg := exampleConfig()
+	k, ok := cfg.Key("admin")
+	if !ok {
+		t.Fatal("expected admin key to be found")
+	}
+	if !k.Admin {
+		t.Fatal("expected admin flag to be set")
+	}
+}
+
+func TestKeyNotFound(t *testing.T) {
+	cfg := exampleConfig()
+	if _, ok := cfg.Key("does-not-exist"); ok {
+		t.Fatal("expected unknown key to be not found")
+	}
+}
+
+func TestKnownRepo(t *testing.T) {
+	cfg := exampleConfig()
+	if !cfg.KnownRepo("repo.example.org") {
+		t.Fatal("expected repo.example.org to be known")
+	}
+	if cfg.KnownRepo("gone.example.org") {
+		t.Fatal("expected an unconfigured repository to be unknown")
+	}
+}
+
+func TestCanary(t *testing.T) {
+	cfg := exampleConfig()
+	if cfg.Canary("repo.example.org") {
+		t.Fatal("expected repo.example.org not to be a canary by default")
+	}
+
+	if err := cfg.addRepo(RepoConfig{Repo: "canary.example.org", Keys: []string{"keyA"}, Canary: true}); err != nil {
+		t.Fatalf("addRepo: %v", err)
+	}
+	if !cfg.Canary("canary.example.org") {
+		t.Fatal("expected canary.example.org to be reported as a canary")
+	}
+}
+
+func TestRemoteUpstream(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.remotes["remote.example.org"] = RemoteConfig{URL: "https://upstream.example.org", KeyID: "edge-key"}
+
+	remote, ok := cfg.RemoteUpstream("remote.example.org")
+	if !ok || remote.URL != "https://upstream.example.org" || remote.KeyID != "edge-key" {
+		t.Fatalf("expected configured remote upstream, got %+v (ok=%v)", remote, ok)
+	}
+
+	if _, ok := cfg.RemoteUpstream("repo.example.org"); ok {
+		t.Fatal("expected a locally-hosted repository to have no remote upstream")
+	}
+}
+
+func TestFreezeWindowsFor(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.freezes["repo.example.org"] = []FreezeWindow{
+		{Schedule: "0 0 1 1 *", DurationSeconds: 3600, Reason: "new year freeze"},
+	}
+
+	windows := cfg.FreezeWindowsFor("repo.example.org")
+	if len(windows) != 1 || windows[0].Reason != "new year freeze" {
+		t.Fatalf("unexpected freeze windows: %+v", windows)
+	}
+
+	if windows := cfg.FreezeWindowsFor("gone.example.org"); windows != nil {
+		t.Fatalf("expected no freeze windows for an unconfigured repository, got %+v", windows)
+	}
+}
+
+func TestReposReturnsSortedRepoNames(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.repos["another.example.org"] = map[string]bool{}
+
+	repos := cfg.Repos()
+	if len(repos) != 2 || repos[0] != "another.example.org" || repos[1] != "repo.example.org" {
+		t.Fatalf("expected sorted repo names, got %v", repos)
+	}
+}
+
+func TestValidateFlagsUnknownKeyReferences(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.repos["broken.example.org"] = map[string]bool{"ghost-key": true}
+
+	issues := cfg.Validate()
+	if len(issues) != 1 {
+		t.Fatalf("expected exactly one issue, got %v", issues)
+	}
+}
+
+func TestValidateReportsNoIssuesForConsistentConfig(t *testing.T) {
+	cfg := exampleConfig()
+	if issues := cfg.Validate(); len(issues) != 0 {
+		t.Fatalf("expected no issues, got %v", issues)
+	}
+}
+
+func TestStaleKeysExcludesRecentlyUsed(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.RecordUse("keyA", time.Now())
+
+	stale := cfg.StaleKeys(time.Now().Add(-24 * time.Hour))
+	for _, k := range stale {
+		if k.ID == "keyA" {
+			t.Fatal("expected recently-used keyA to be excluded from the stale report")
+		}
+	}
+
+	var sawAdmin bool
+	for _, k := range stale {
+		if k.ID == "admin" {
+			sawAdmin = true
+		}
+	}
+	if !sawAdmin {
+		t.Fatal("expected never-used admin key to be reported as stale")
+	}
+}
+
+func TestExpandLeasePathAppliesTemplateOnlyToBarePath(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.keys["keyA"] = KeyConfig{ID: "keyA", Secret: "s3cr3t", DefaultPathTemplate: "sandboxes/{key}"}
+
+	got := cfg.ExpandLeasePath("keyA", "repo.example.org", "repo.example.org")
+	if want := "repo.example.org/sandboxes/keyA"; got != want {
+		t.Fatalf("expected bare path to expand to %q, got %q", want, got)
+	}
+
+	got = cfg.ExpandLeasePath("keyA", "repo.example.org", "repo.example.org/explicit/path")
+	if want := "repo.example.org/explicit/path"; got != want {
+		t.Fatalf("expected explicit path to pass through unchanged, got %q", got)
+	}
+}
+
+func TestExpandLeasePathLeavesBarePathUnchangedWithoutTemplate(t *testing.T) {
+	cfg := exampleConfig()
+	got := cfg.ExpandLeasePath("keyA", "repo.example.org", "repo.example.org")
+	if got != "repo.example.org" {
+		t.Fatalf("expected bare path unchanged without a template, got %q", got)
+	}
+}
+
+func TestKeyInfoRoleReflectsAdminPrecedence(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.keys["operatorKey"] = KeyConfig{ID: "operatorKey", Secret: "s3cr3t", Role: RoleOperator}
+	cfg.keys["adminByFlag"] = KeyConfig{ID: "adminByFlag", Secret: "s3cr3t", Role: RoleViewer, Admin: true}
+
+	op, ok := cfg.Key("operatorKey")
+	if !ok || op.Role != RoleOperator {
+		t.Fatalf("expected operatorKey to have RoleOperator, got %q (found=%v)", op.Role, ok)
+	}
+
+	admin, ok := cfg.Key("adminByFlag")
+	if !ok || admin.Role != RoleAdmin {
+		t.Fatalf("expected Admin: true to imply RoleAdmin regardless of Role, got %q (found=%v)", admin.Role, ok)
+	}
+
+	plain, ok := cfg.Key("keyA")
+	if !ok || plain.Role != "" {
+		t.Fatalf("expected a key with no Role or Admin to have an empty effective role, got %q (found=%v)", plain.Role, ok)
+	}
+}
+
+func TestGroupRoleReturnsMostPrivilegedMatch(t *testing.T) {
+	cfg := exampleConfig()
+	cfg.groupRoles["sre"] = RoleOperator
+	cfg.groupRoles["sre-leads"] = RoleAdmin
+	cfg.groupRoles["support"] = RoleViewer
+
+	if got := cfg.GroupRole([]string{"support", "sre"}); got != RoleOperator {
+		t.Fatalf("expected most privileged matching group role RoleOperator, got %q", got)
+	}
+	if got := cfg.GroupRole([]string{"support", "sre", "sre-leads"}); got != RoleAdmin {
+		t.Fatalf("expected most privileged matching group role RoleAdmin, got %q", got)
+	}
+	if got := cfg.GroupRole([]string{"unconfigured-group"}); got != "" {
+		t.Fatalf("expected no role for unconfigured groups, got %q", got)
+	}
+	if got := cfg.GroupRole(nil); got != "" {
+		t.Fatalf("expected no role for no groups, got %q", got)
+	}
+}
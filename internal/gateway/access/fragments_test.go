@@ -0,0 +1,82 @@
+package access
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func writeFragment(t *testing.T, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatalf("could not write fragment: %v", err)
+	}
+}
+
+func TestLoadFragmentsAddsRepositories(t *testing.T) {
+	dir := t.TempDir()
+	writeFragment(t, dir, "frag.example.org.json", `{"domain":"frag.example.org","keys":["keyA"]}`)
+
+	cfg := NewConfig()
+	cfg.keys["keyA"] = KeyConfig{ID: "keyA"}
+	if err := cfg.LoadFragments(dir); err != nil {
+		t.Fatalf("LoadFragments: %v", err)
+	}
+
+	if !cfg.KnownRepo("frag.example.org") {
+		t.Fatal("expected the fragment's repository to be loaded")
+	}
+	if !cfg.Authorized("keyA", "frag.example.org") {
+		t.Fatal("expected keyA to be authorized for the fragment's repository")
+	}
+}
+
+func TestLoadFragmentsRemovesDeletedFragments(t *testing.T) {
+	dir := t.TempDir()
+	writeFragment(t, dir, "frag.example.org.json", `{"domain":"frag.example.org","keys":["keyA"]}`)
+
+	cfg := NewConfig()
+	if err := cfg.LoadFragments(dir); err != nil {
+		t.Fatalf("LoadFragments: %v", err)
+	}
+	if !cfg.KnownRepo("frag.example.org") {
+		t.Fatal("expected the fragment's repository to be loaded")
+	}
+
+	if err := os.Remove(filepath.Join(dir, "frag.example.org.json")); err != nil {
+		t.Fatalf("could not remove fragment: %v", err)
+	}
+	if err := cfg.LoadFragments(dir); err != nil {
+		t.Fatalf("LoadFragments: %v", err)
+	}
+	if cfg.KnownRepo("frag.example.org") {
+		t.Fatal("expected the removed fragment's repository to be dropped")
+	}
+}
+
+func TestLoadFragmentsLeavesConfigUntouchedOnParseError(t *testing.T) {
+	dir := t.TempDir()
+	writeFragment(t, dir, "good.example.org.json", `{"domain":"good.example.org","keys":["keyA"]}`)
+
+	cfg := NewConfig()
+	cfg.keys["keyA"] = KeyConfig{ID: "keyA"}
+	if err := cfg.LoadFragments(dir); err != nil {
+		t.Fatalf("LoadFragments: %v", err)
+	}
+
+	writeFragment(t, dir, "partial.example.org.json", `{"domain":"partial.example`)
+	if err := cfg.LoadFragments(dir); err == nil {
+		t.Fatal("expected a malformed fragment to fail the reload")
+	}
+
+	if !cfg.KnownRepo("good.example.org") {
+		t.Fatal("expected the previously loaded repository to survive a failed reload")
+	}
+}
+
+func TestLoadFragmentsIgnoresMissingDirectory(t *testing.T) {
+	cfg := NewConfig()
+	if err := cfg.LoadFragments(filepath.Join(t.TempDir(), "does-not-exist")); err != nil {
+		t.Fatalf("expected a missing fragments directory to be treated as no fragments, got %v", err)
+	}
+}
@@ -0,0 +1,55 @@
+package access
+
+import "net"
+
+// ipACL holds the parsed allow/deny CIDR ranges for a single repository.
+// Deny takes precedence over allow: an address matching both is denied.
+// If no allow ranges are configured, any address not explicitly denied
+// is allowed.
+type ipACL struct {
+	allow []*net.IPNet
+	deny  []*net.IPNet
+}
+
+func newIPACL(allowCIDRs, denyCIDRs []string) (*ipACL, error) {
+	allow, err := parseCIDRs(allowCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	deny, err := parseCIDRs(denyCIDRs)
+	if err != nil {
+		return nil, err
+	}
+	return &ipACL{allow: allow, deny: deny}, nil
+}
+
+func parseCIDRs(cidrs []string) ([]*net.IPNet, error) {
+	nets := make([]*net.IPNet, 0, len(cidrs))
+	for _, c := range cidrs {
+		_, n, err := net.ParseCIDR(c)
+		if err != nil {
+			return nil, err
+		}
+		nets = append(nets, n)
+	}
+	return nets, nil
+}
+
+func containsAny(nets []*net.IPNet, addr net.IP) bool {
+	for _, n := range nets {
+		if n.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+func (a *ipACL) allowed(addr net.IP) bool {
+	if containsAny(a.deny, addr) {
+		return false
+	}
+	if len(a.allow) == 0 {
+		return true
+	}
+	return containsAny(a.allow, addr)
+}
@@ -0,0 +1,40 @@
+package access
+
+import (
+	"net"
+	"testing"
+)
+
+func TestIPACLAllowDenyPrecedence(t *testing.T) {
+	acl, err := newIPACL([]string{"10.0.0.0/8"}, []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatalf("newIPACL: %v", err)
+	}
+
+	cases := []struct {
+		addr string
+		want bool
+	}{
+		{"10.2.3.4", true},     // within allow, outside deny
+		{"10.1.2.3", false},    // denied overrides allow
+		{"192.168.0.1", false}, // outside allow range
+	}
+	for _, c := range cases {
+		if got := acl.allowed(net.ParseIP(c.addr)); got != c.want {
+			t.Errorf("allowed(%s) = %v, want %v", c.addr, got, c.want)
+		}
+	}
+}
+
+func TestIPACLNoAllowListAllowsByDefault(t *testing.T) {
+	acl, err := newIPACL(nil, []string{"10.1.0.0/16"})
+	if err != nil {
+		t.Fatalf("newIPACL: %v", err)
+	}
+	if !acl.allowed(net.ParseIP("8.8.8.8")) {
+		t.Error("expected address outside deny list to be allowed")
+	}
+	if acl.allowed(net.ParseIP("10.1.2.3")) {
+		t.Error("expected denied address to be rejected")
+	}
+}
@@ -0,0 +1,47 @@
+package access
+
+// Role grants a key or OIDC group a level of access to the admin API,
+// on top of (and independent from) the repository publishing
+// authorization Config.Authorized governs. Roles are ordered:
+// RoleAdmin can do everything RoleOperator can, which can do everything
+// RoleViewer can.
+type Role string
+
+const (
+	// RoleViewer may read admin-only introspection endpoints (key
+	// metadata, janitor reports) but cannot trigger any action.
+	RoleViewer Role = "viewer"
+
+	// RoleOperator may additionally trigger day-to-day operational
+	// actions: approving held commits, running a janitor sweep,
+	// resigning a repository's whitelist, and taking lease database
+	// backups and restores.
+	RoleOperator Role = "operator"
+
+	// RoleAdmin may additionally perform security- and
+	// availability-sensitive actions: rotating the lease token signing
+	// key, entering or leaving drain mode, and reading the runtime
+	// profiler.
+	RoleAdmin Role = "admin"
+)
+
+// rank orders roles from least to most privileged. An unrecognized or
+// empty Role ranks below RoleViewer, so a key or group with no role
+// configured is authorized for nothing.
+func (r Role) rank() int {
+	switch r {
+	case RoleViewer:
+		return 1
+	case RoleOperator:
+		return 2
+	case RoleAdmin:
+		return 3
+	default:
+		return 0
+	}
+}
+
+// AtLeast reports whether r is at least as privileged as min.
+func (r Role) AtLeast(min Role) bool {
+	return r.rank() >= min.rank()
+}
@@ -0,0 +1,24 @@
+package access
+
+import "testing"
+
+func TestRoleAtLeast(t *testing.T) {
+	cases := []struct {
+		role Role
+		min  Role
+		want bool
+	}{
+		{RoleAdmin, RoleViewer, true},
+		{RoleAdmin, RoleOperator, true},
+		{RoleAdmin, RoleAdmin, true},
+		{RoleOperator, RoleAdmin, false},
+		{RoleOperator, RoleOperator, true},
+		{RoleViewer, RoleOperator, false},
+		{"", RoleViewer, false},
+	}
+	for _, c := range cases {
+		if got := c.role.AtLeast(c.min); got != c.want {
+			t.Errorf("Role(%q).AtLeast(%q) = %v, want %v", c.role, c.min, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package access
+
+import (
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/ratelog"
+)
+
+// fragmentPollInterval is how often Watch re-scans the fragments
+// directory for additions, removals, and edits. There's no filesystem
+// change notification available without pulling in a third-party
+// dependency, so this polls on a fixed interval instead of using
+// inotify.
+const fragmentPollInterval = 5 * time.Second
+
+// reloadLogInterval bounds how often Watch logs a reload failure for the
+// same fragments directory, so a fragment that stays broken doesn't log
+// once per poll tick forever.
+const reloadLogInterval = 5 * time.Minute
+
+var reloadLogLimiter = ratelog.NewLimiter(reloadLogInterval)
+
+// Watch polls fragmentsDir on fragmentPollInterval and applies any
+// changes to cfg in place until stopCh is closed. It should be started
+// once, in its own goroutine, after cfg has been handed to the parts of
+// the gateway that will read it, since LoadFragments mutates cfg rather
+// than replacing it.
+func Watch(cfg *Config, fragmentsDir string, stopCh <-chan struct{}) {
+	ticker := time.NewTicker(fragmentPollInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := cfg.LoadFragments(fragmentsDir); err != nil {
+				reloadLogLimiter.Printf(fragmentsDir, "access: skipping config fragment reload: %v", err)
+			}
+		case <-stopCh:
+			return
+		}
+	}
+}
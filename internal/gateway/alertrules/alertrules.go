@@ -0,0 +1,84 @@
+// Package alertrules generates suggested Prometheus alerting rules for
+// the metrics the gateway exports on /metrics, so an operator standing
+// up a new site doesn't have to reverse-engineer thresholds from the
+// source. It backs the gateway's "alert-rules" subcommand.
+package alertrules
+
+import (
+	"fmt"
+	"io"
+)
+
+// Rule is one suggested Prometheus alerting rule.
+type Rule struct {
+	// Alert is the rule's name, following Prometheus's CamelCase
+	// convention for alert names.
+	Alert string
+
+	// Expr is the PromQL expression that fires the alert.
+	Expr string
+
+	// For is how long Expr must hold continuously before the alert
+	// fires, avoiding noise from single-scrape blips.
+	For string
+
+	// Severity is carried as a "severity" label on the fired alert.
+	Severity string
+
+	// Summary is a short, human-readable description of what firing
+	// means, suitable for a paging annotation.
+	Summary string
+}
+
+// Rules returns the suggested alert rules for every metric the gateway
+// exports, matching the metric names emitted by backend.Pool via its
+// Metrics sink (see backend/pool.go and backend/gc.go).
+func Rules() []Rule {
+	return []Rule{
+		{
+			Alert:    "CVMFSGatewayHighCommitFailureRate",
+			Expr:     `rate(cvmfs_gateway_commits_failed_total[5m]) / (rate(cvmfs_gateway_commits_failed_total[5m]) + rate(cvmfs_gateway_commits_total[5m])) > 0.1`,
+			For:      "10m",
+			Severity: "warning",
+			Summary:  "more than 10% of commits are failing across the last 5 minutes",
+		},
+		{
+			Alert:    "CVMFSGatewayNoCommits",
+			Expr:     `rate(cvmfs_gateway_commits_total[1h]) == 0`,
+			For:      "1h",
+			Severity: "info",
+			Summary:  "no commits have been processed in the last hour",
+		},
+		{
+			Alert:    "CVMFSGatewaySlowTasks",
+			Expr:     `rate(cvmfs_gateway_task_seconds_sum[5m]) / rate(cvmfs_gateway_task_seconds_count[5m]) > 30`,
+			For:      "10m",
+			Severity: "warning",
+			Summary:  "the mean duration of commit, payload, and gc tasks over the last 5 minutes exceeds 30 seconds",
+		},
+	}
+}
+
+// WriteTo renders rules in the Prometheus rule group YAML format
+// expected by a rule_files entry.
+func WriteTo(w io.Writer, rules []Rule) error {
+	if _, err := fmt.Fprintln(w, "groups:"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "  - name: cvmfs-gateway"); err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintln(w, "    rules:"); err != nil {
+		return err
+	}
+	for _, rule := range rules {
+		fmt.Fprintf(w, "      - alert: %s\n", rule.Alert)
+		fmt.Fprintf(w, "        expr: %s\n", rule.Expr)
+		fmt.Fprintf(w, "        for: %s\n", rule.For)
+		fmt.Fprintf(w, "        labels:\n")
+		fmt.Fprintf(w, "          severity: %s\n", rule.Severity)
+		fmt.Fprintf(w, "        annotations:\n")
+		fmt.Fprintf(w, "          summary: %q\n", rule.Summary)
+	}
+	return nil
+}
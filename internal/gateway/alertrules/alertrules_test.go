@@ -0,0 +1,29 @@
+package alertrules
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRulesReferenceExportedMetricNames(t *testing.T) {
+	for _, rule := range Rules() {
+		if !strings.Contains(rule.Expr, "cvmfs_gateway_") {
+			t.Fatalf("rule %s does not reference a cvmfs_gateway_ metric: %s", rule.Alert, rule.Expr)
+		}
+	}
+}
+
+func TestWriteToRendersEveryRule(t *testing.T) {
+	var buf bytes.Buffer
+	rules := Rules()
+	if err := WriteTo(&buf, rules); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	out := buf.String()
+	for _, rule := range rules {
+		if !strings.Contains(out, "alert: "+rule.Alert) {
+			t.Fatalf("expected output to contain rule %s, got:\n%s", rule.Alert, out)
+		}
+	}
+}
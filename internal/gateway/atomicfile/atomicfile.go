@@ -0,0 +1,46 @@
+// Package atomicfile writes small on-disk state files -- signing keys,
+// encryption keys, snapshots -- without ever leaving a truncated or
+// partially-written file behind for a concurrent reader or a crash to
+// find, since for every current caller a corrupt file is worse than an
+// ordinary write failure: it turns a restart into a hard startup error
+// instead of silently regenerating or reloading state.
+package atomicfile
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+// Write replaces the file at path with data, atomically: it writes to a
+// temporary file in the same directory (so the final rename is on the
+// same filesystem) and renames it over path, so a crash or power loss
+// mid-write leaves either the old contents or the new ones, never a
+// truncated file in between.
+func Write(path string, data []byte, perm os.FileMode) error {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("atomicfile: could not create temp file: %w", err)
+	}
+	tmpPath := tmp.Name()
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmpPath)
+		return fmt.Errorf("atomicfile: could not write temp file: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("atomicfile: could not close temp file: %w", err)
+	}
+	if err := os.Chmod(tmpPath, perm); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("atomicfile: could not set temp file permissions: %w", err)
+	}
+	if err := os.Rename(tmpPath, path); err != nil {
+		os.Remove(tmpPath)
+		return fmt.Errorf("atomicfile: could not rename temp file into place: %w", err)
+	}
+	return nil
+}
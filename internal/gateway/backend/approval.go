@@ -0,0 +1,91 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// ErrApprovalRequired is returned by CommitLease when path's repository
+// requires a second approver's sign-off before a commit is applied. The
+// payload isn't lost: it's held in Pool.Approvals until ApproveCommit is
+// called for path.
+type ErrApprovalRequired struct {
+	Path string
+}
+
+func (e ErrApprovalRequired) Error() string {
+	return fmt.Sprintf("commit to %s requires a second approver before it is applied", e.Path)
+}
+
+// ErrApprovalNotFound is returned by ApproveCommit when there is no
+// commit held pending for path.
+type ErrApprovalNotFound struct {
+	Path string
+}
+
+func (e ErrApprovalNotFound) Error() string {
+	return fmt.Sprintf("no pending commit awaiting approval for path: %s", e.Path)
+}
+
+// ErrSelfApproval is returned by ApproveCommit when the approving key is
+// the same key that submitted the pending commit, which a four-eyes
+// policy exists to prevent.
+type ErrSelfApproval struct {
+	Path string
+}
+
+func (e ErrSelfApproval) Error() string {
+	return fmt.Sprintf("the key that submitted the pending commit for %s cannot also approve it", e.Path)
+}
+
+// PendingCommit is a commit CommitLease intercepted for a repository
+// under Pool.ProtectedRepos, held until a second key approves it via
+// Pool.ApproveCommit.
+type PendingCommit struct {
+	Path        string
+	Repository  string
+	Token       string
+	Payload     receiver.Payload
+	SubmittedBy string
+	SubmittedAt time.Time
+}
+
+// ApprovalStore tracks commits held pending a second approver's sign-off,
+// keyed by path. Embedded into Pool.
+type ApprovalStore struct {
+	mu      sync.Mutex
+	pending map[string]PendingCommit
+}
+
+// NewApprovalStore returns an empty ApprovalStore.
+func NewApprovalStore() *ApprovalStore {
+	return &ApprovalStore{pending: make(map[string]PendingCommit)}
+}
+
+func (s *ApprovalStore) hold(p PendingCommit) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.pending[p.Path] = p
+}
+
+func (s *ApprovalStore) get(path string) (PendingCommit, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	p, ok := s.pending[path]
+	return p, ok
+}
+
+func (s *ApprovalStore) clear(path string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.pending, path)
+}
+
+// Pending returns the commit held for path, if any, for use by an admin
+// endpoint that wants to show what it would be approving.
+func (s *ApprovalStore) Pending(path string) (PendingCommit, bool) {
+	return s.get(path)
+}
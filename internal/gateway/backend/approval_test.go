@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestCommitLeaseHoldsPendingApprovalForProtectedRepo(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.ProtectedRepos = func(repo string) bool { return repo == "myrepo" }
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	_, err = pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")})
+	if _, ok := err.(ErrApprovalRequired); !ok {
+		t.Fatalf("expected ErrApprovalRequired, got %v", err)
+	}
+
+	if _, ok := pool.Approvals.Pending(lease.Path); !ok {
+		t.Fatal("expected a pending commit to be held for the leased path")
+	}
+}
+
+func TestApproveCommitAppliesPendingCommit(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.ProtectedRepos = func(repo string) bool { return repo == "myrepo" }
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")}); err == nil {
+		t.Fatal("expected the initial commit to be held for approval")
+	}
+
+	if _, err := pool.ApproveCommit(lease.Path, "keyB"); err != nil {
+		t.Fatalf("ApproveCommit: %v", err)
+	}
+
+	if _, ok := pool.Approvals.Pending(lease.Path); ok {
+		t.Fatal("expected the pending commit to be cleared after approval")
+	}
+	if _, err := pool.Leases.GetLease(lease.Path); err == nil {
+		t.Fatal("expected the lease to be gone after a successful commit")
+	}
+}
+
+func TestApproveCommitRejectsSelfApproval(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.ProtectedRepos = func(repo string) bool { return repo == "myrepo" }
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")})
+
+	_, err = pool.ApproveCommit(lease.Path, "keyA")
+	if _, ok := err.(ErrSelfApproval); !ok {
+		t.Fatalf("expected ErrSelfApproval, got %v", err)
+	}
+}
+
+func TestApproveCommitRejectsUnknownPath(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	_, err := pool.ApproveCommit("myrepo/nonexistent", "keyB")
+	if _, ok := err.(ErrApprovalNotFound); !ok {
+		t.Fatalf("expected ErrApprovalNotFound, got %v", err)
+	}
+}
+
+func TestCancelLeaseClearsPendingApproval(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.ProtectedRepos = func(repo string) bool { return repo == "myrepo" }
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")})
+
+	if _, err := pool.CancelLease(lease.Path); err != nil {
+		t.Fatalf("CancelLease: %v", err)
+	}
+	if _, ok := pool.Approvals.Pending(lease.Path); ok {
+		t.Fatal("expected the pending commit to be cleared after cancellation")
+	}
+}
@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+)
+
+// Snapshot captures the full state of the lease DB: every active lease
+// and every path currently recorded as having a commit in flight. It is
+// what Backup returns and Restore installs, so a gateway migrating to a
+// new host doesn't orphan publisher transactions in flight on the old
+// one. Access-config state already lives in its own on-disk JSON file
+// and round-trips with it, so a Snapshot only needs to cover the lease
+// DB, which otherwise exists in memory alone.
+type Snapshot struct {
+	Leases      map[string]Lease     `json:"leases"`
+	LockedPaths map[string]time.Time `json:"locked_paths"`
+}
+
+// Backup captures the pool's current lease DB state as a Snapshot.
+func (p *Pool) Backup() (Snapshot, error) {
+	leases, err := p.Leases.GetLeases()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("backend: could not read leases for backup: %w", err)
+	}
+	locked, err := p.Leases.LockedPaths()
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("backend: could not read locked paths for backup: %w", err)
+	}
+	return Snapshot{Leases: leases, LockedPaths: locked}, nil
+}
+
+// Restore installs a Snapshot produced by Backup into the pool's lease
+// DB, so leases and in-flight commit locks active on another gateway
+// instance carry over instead of being orphaned. It does not clear any
+// leases already present; a lease in the snapshot for a path that
+// already has one replaces it.
+func (p *Pool) Restore(snap Snapshot) error {
+	for _, l := range snap.Leases {
+		if err := p.Leases.NewLease(l.Token, l); err != nil {
+			return fmt.Errorf("backend: could not restore lease for %s: %w", l.Path, err)
+		}
+	}
+	for path, at := range snap.LockedPaths {
+		if err := p.Leases.LockPath(path, at); err != nil {
+			return fmt.Errorf("backend: could not restore lock for %s: %w", path, err)
+		}
+	}
+	p.RestoreLocks()
+	return nil
+}
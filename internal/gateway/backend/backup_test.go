@@ -0,0 +1,31 @@
+package backend
+
+import "testing"
+
+func TestBackupRestoreRoundTrip(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if _, err := pool.NewLease("key1", "token1", "repo.example.org", "repo.example.org/a", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	snap, err := pool.Backup()
+	if err != nil {
+		t.Fatalf("Backup: %v", err)
+	}
+	if len(snap.Leases) != 1 {
+		t.Fatalf("expected 1 lease in snapshot, got %d", len(snap.Leases))
+	}
+
+	restored := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if err := restored.Restore(snap); err != nil {
+		t.Fatalf("Restore: %v", err)
+	}
+
+	l, err := restored.Leases.GetLease("repo.example.org/a")
+	if err != nil {
+		t.Fatalf("GetLease after restore: %v", err)
+	}
+	if l.KeyID != "key1" {
+		t.Fatalf("unexpected restored lease: %+v", l)
+	}
+}
@@ -0,0 +1,95 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestErrPathBusyRetryAfterDoublesPerAttempt(t *testing.T) {
+	cases := []struct {
+		attempt int
+		want    int64 // milliseconds
+	}{
+		{attempt: 0, want: 250},
+		{attempt: 1, want: 500},
+		{attempt: 2, want: 1000},
+		{attempt: 3, want: 2000},
+	}
+	for _, c := range cases {
+		err := ErrPathBusy{Path: "repo.example.org/a", Attempt: c.attempt}
+		if got := err.RetryAfter().Milliseconds(); got != c.want {
+			t.Errorf("attempt %d: RetryAfter() = %dms, want %dms", c.attempt, got, c.want)
+		}
+	}
+}
+
+func TestErrPathBusyRetryAfterCapsAtMax(t *testing.T) {
+	err := ErrPathBusy{Path: "repo.example.org/a", Attempt: 20}
+	if got := err.RetryAfter(); got != maxBusyBackoff {
+		t.Errorf("RetryAfter() = %s, want cap of %s", got, maxBusyBackoff)
+	}
+}
+
+func TestNewLeaseBusyRejectionAttemptsIncreaseAndReset(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(NewMemLeaseDB(), blockingReceiver{release: release}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	commitDone := make(chan struct{})
+	go func() {
+		pool.CommitLease("tok1", lease.Path, receiver.Payload{})
+		close(commitDone)
+	}()
+	waitUntilLocked(t, pool, lease.Path)
+
+	_, err1 := pool.NewLease("keyB", "tok2", "repo.example.org", "repo.example.org/a", 0)
+	_, err2 := pool.NewLease("keyC", "tok3", "repo.example.org", "repo.example.org/a", 0)
+	busy1, ok := err1.(ErrPathBusy)
+	if !ok {
+		t.Fatalf("expected ErrPathBusy, got %v (%T)", err1, err1)
+	}
+	busy2, ok := err2.(ErrPathBusy)
+	if !ok {
+		t.Fatalf("expected ErrPathBusy, got %v (%T)", err2, err2)
+	}
+	if busy2.Attempt <= busy1.Attempt {
+		t.Fatalf("expected consecutive rejections to increase the attempt count, got %d then %d", busy1.Attempt, busy2.Attempt)
+	}
+
+	close(release)
+	<-commitDone
+
+	lease2, err := pool.NewLease("keyD", "tok4", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease after release: %v", err)
+	}
+
+	release2 := make(chan struct{})
+	pool.Receiver = blockingReceiver{release: release2}
+	commit2Done := make(chan struct{})
+	go func() {
+		pool.CommitLease("tok4", lease2.Path, receiver.Payload{})
+		close(commit2Done)
+	}()
+	waitUntilLocked(t, pool, lease2.Path)
+
+	_, err = pool.NewLease("keyE", "tok5", "repo.example.org", "repo.example.org/a", 0)
+	busy, ok := err.(ErrPathBusy)
+	if !ok {
+		close(release2)
+		<-commit2Done
+		t.Fatalf("expected ErrPathBusy, got %v (%T)", err, err)
+	}
+	if busy.Attempt != 1 {
+		close(release2)
+		<-commit2Done
+		t.Fatalf("expected the busy-attempt counter to reset after the path was released, got attempt %d", busy.Attempt)
+	}
+
+	close(release2)
+	<-commit2Done
+}
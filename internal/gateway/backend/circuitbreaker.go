@@ -0,0 +1,139 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// circuitState is the lifecycle state of a single repository's circuit
+// breaker.
+type circuitState int
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+)
+
+// breakerFailureThreshold is how many commit failures within
+// breakerWindow trip a repository's circuit open.
+const breakerFailureThreshold = 5
+
+// breakerWindow is the sliding window over which failures are counted.
+const breakerWindow = 1 * time.Minute
+
+// breakerCooldown is how long a tripped circuit stays open before a
+// single probe commit is allowed through to test recovery.
+const breakerCooldown = 30 * time.Second
+
+type breakerEntry struct {
+	state     circuitState
+	failures  []time.Time
+	openSince time.Time
+}
+
+// CircuitBreaker tracks commit failures per repository and stops sending
+// further commits to a repository whose upstream storage is repeatedly
+// failing, so one broken repository can't exhaust worker capacity for
+// the rest of the gateway.
+type CircuitBreaker struct {
+	mu      sync.Mutex
+	entries map[string]*breakerEntry
+}
+
+// NewCircuitBreaker returns a breaker with no tripped repositories.
+func NewCircuitBreaker() *CircuitBreaker {
+	return &CircuitBreaker{entries: make(map[string]*breakerEntry)}
+}
+
+// ErrCircuitOpen is returned by Allow when a repository's circuit is
+// currently open.
+type ErrCircuitOpen struct {
+	Repository string
+}
+
+func (e ErrCircuitOpen) Error() string {
+	return fmt.Sprintf("circuit open for repository %s: too many recent commit failures", e.Repository)
+}
+
+// Allow reports whether a commit to repo may proceed, transitioning an
+// open circuit to half-open once its cooldown has elapsed.
+func (b *CircuitBreaker) Allow(repo string) error {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.entries[repo]
+	if !ok {
+		return nil
+	}
+
+	switch e.state {
+	case circuitOpen:
+		if time.Since(e.openSince) >= breakerCooldown {
+			e.state = circuitHalfOpen
+			return nil
+		}
+		return ErrCircuitOpen{Repository: repo}
+	default:
+		return nil
+	}
+}
+
+// RecordSuccess closes repo's circuit, if it was open or half-open.
+func (b *CircuitBreaker) RecordSuccess(repo string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	delete(b.entries, repo)
+}
+
+// RecordFailure counts a commit failure against repo, tripping its
+// circuit open once the failure threshold is reached within the window.
+func (b *CircuitBreaker) RecordFailure(repo string) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	e, ok := b.entries[repo]
+	if !ok {
+		e = &breakerEntry{}
+		b.entries[repo] = e
+	}
+
+	now := time.Now()
+	e.failures = append(e.failures, now)
+	e.failures = pruneOlderThan(e.failures, now.Add(-breakerWindow))
+
+	if e.state == circuitHalfOpen || len(e.failures) >= breakerFailureThreshold {
+		e.state = circuitOpen
+		e.openSince = now
+	}
+}
+
+// RecentFailures returns the total number of commit failures recorded
+// against any repository within the current breakerWindow, and the
+// number of repositories whose circuit is currently open. Used to derive
+// a gateway-wide error-rate signal for health scoring.
+func (b *CircuitBreaker) RecentFailures() (failures, open int) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := time.Now()
+	for _, e := range b.entries {
+		e.failures = pruneOlderThan(e.failures, now.Add(-breakerWindow))
+		failures += len(e.failures)
+		if e.state == circuitOpen {
+			open++
+		}
+	}
+	return failures, open
+}
+
+func pruneOlderThan(times []time.Time, cutoff time.Time) []time.Time {
+	kept := times[:0]
+	for _, t := range times {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	return kept
+}
@@ -0,0 +1,36 @@
+package backend
+
+import "testing"
+
+func TestCircuitBreakerTripsAfterThreshold(t *testing.T) {
+	b := NewCircuitBreaker()
+	repo := "repo.example.org"
+
+	for i := 0; i < breakerFailureThreshold-1; i++ {
+		b.RecordFailure(repo)
+		if err := b.Allow(repo); err != nil {
+			t.Fatalf("unexpected trip before threshold: %v", err)
+		}
+	}
+
+	b.RecordFailure(repo)
+	if err := b.Allow(repo); err == nil {
+		t.Fatal("expected circuit to be open after crossing the failure threshold")
+	}
+}
+
+func TestCircuitBreakerRecordSuccessCloses(t *testing.T) {
+	b := NewCircuitBreaker()
+	repo := "repo.example.org"
+	for i := 0; i < breakerFailureThreshold; i++ {
+		b.RecordFailure(repo)
+	}
+	if err := b.Allow(repo); err == nil {
+		t.Fatal("expected circuit to be open")
+	}
+
+	b.RecordSuccess(repo)
+	if err := b.Allow(repo); err != nil {
+		t.Fatalf("expected circuit to be closed after RecordSuccess, got %v", err)
+	}
+}
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"strings"
+	"time"
+)
+
+// LeaseConflict describes an existing lease or in-flight commit lock
+// that would block a new lease on a requested path, so a publisher
+// rejected with a PathBusy error can see why without asking an
+// operator.
+type LeaseConflict struct {
+	Path       string    `json:"path"`
+	KeyID      string    `json:"key_id,omitempty"`
+	Expiration time.Time `json:"expiration,omitempty"`
+	Locked     bool      `json:"locked"`
+	LockedAt   time.Time `json:"locked_at,omitempty"`
+
+	// Overlap describes how Path relates to the requested path: "exact"
+	// if it's the same path, "ancestor" if it's a parent subtree, or
+	// "descendant" if it's nested underneath it.
+	Overlap string `json:"overlap"`
+}
+
+// LeaseConflicts reports every active lease and in-flight commit lock
+// that overlaps path, whether by being the same path, an ancestor
+// subtree, or a descendant of it.
+func (p *Pool) LeaseConflicts(path string) ([]LeaseConflict, error) {
+	leases, err := p.Leases.GetLeases()
+	if err != nil {
+		return nil, err
+	}
+
+	p.mu.Lock()
+	lockedAt := make(map[string]time.Time, len(p.lockedAt))
+	for k, v := range p.lockedAt {
+		lockedAt[k] = v
+	}
+	p.mu.Unlock()
+
+	var conflicts []LeaseConflict
+	for other, l := range leases {
+		overlap := pathOverlap(other, path)
+		if overlap == "" {
+			continue
+		}
+		at, locked := lockedAt[other]
+		conflicts = append(conflicts, LeaseConflict{
+			Path:       other,
+			KeyID:      l.KeyID,
+			Expiration: l.Expiration,
+			Locked:     locked,
+			LockedAt:   at,
+			Overlap:    overlap,
+		})
+	}
+	return conflicts, nil
+}
+
+// pathOverlap reports how other relates to path: "exact" if equal,
+// "ancestor" if other is a parent subtree of path, "descendant" if other
+// is nested underneath path, or "" if they don't overlap at all.
+func pathOverlap(other, path string) string {
+	switch {
+	case other == path:
+		return "exact"
+	case strings.HasPrefix(path, other+"/"):
+		return "ancestor"
+	case strings.HasPrefix(other, path+"/"):
+		return "descendant"
+	default:
+		return ""
+	}
+}
@@ -0,0 +1,43 @@
+package backend
+
+import "testing"
+
+func TestPathOverlap(t *testing.T) {
+	cases := []struct {
+		other, path, want string
+	}{
+		{"repo/a", "repo/a", "exact"},
+		{"repo/a", "repo/a/b", "ancestor"},
+		{"repo/a/b", "repo/a", "descendant"},
+		{"repo/a", "repo/b", ""},
+		{"repo/ab", "repo/a", ""},
+	}
+	for _, c := range cases {
+		if got := pathOverlap(c.other, c.path); got != c.want {
+			t.Errorf("pathOverlap(%q, %q) = %q, want %q", c.other, c.path, got, c.want)
+		}
+	}
+}
+
+func TestLeaseConflictsReportsOverlappingLeases(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if _, err := pool.NewLease("key1", "token1", "repo.example.org", "repo.example.org/a", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	conflicts, err := pool.LeaseConflicts("repo.example.org/a")
+	if err != nil {
+		t.Fatalf("LeaseConflicts: %v", err)
+	}
+	if len(conflicts) != 1 || conflicts[0].Overlap != "exact" || conflicts[0].KeyID != "key1" {
+		t.Fatalf("unexpected conflicts: %+v", conflicts)
+	}
+
+	conflicts, err = pool.LeaseConflicts("repo.example.org/z")
+	if err != nil {
+		t.Fatalf("LeaseConflicts: %v", err)
+	}
+	if len(conflicts) != 0 {
+		t.Fatalf("expected no conflicts for an unrelated path, got %+v", conflicts)
+	}
+}
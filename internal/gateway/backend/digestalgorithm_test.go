@@ -0,0 +1,69 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeCapableReceiver struct {
+	fakeReceiver
+	capabilities []string
+}
+
+func (r fakeCapableReceiver) Version() (receiver.VersionInfo, error) {
+	return receiver.VersionInfo{Version: "2.10.1", Capabilities: r.capabilities}, nil
+}
+
+func TestSupportsDigestAlgorithmAllowsEmptyByDefault(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if !pool.SupportsDigestAlgorithm("") {
+		t.Fatal("expected an empty algorithm to always be supported")
+	}
+	if pool.SupportsDigestAlgorithm("sha256") {
+		t.Fatal("expected an explicit algorithm to be rejected before capabilities are known")
+	}
+}
+
+func TestSupportsDigestAlgorithmMatchesAdvertisedCapability(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeCapableReceiver{capabilities: []string{"diff", "sha256", "blake3"}}, func() error { return nil })
+	if err := pool.CheckReceiverVersion(); err != nil {
+		t.Fatalf("CheckReceiverVersion: %v", err)
+	}
+	if !pool.SupportsDigestAlgorithm("sha256") {
+		t.Fatal("expected sha256 to be supported once advertised")
+	}
+	if !pool.SupportsDigestAlgorithm("blake3") {
+		t.Fatal("expected blake3 to be supported once advertised")
+	}
+	if pool.SupportsDigestAlgorithm("md5") {
+		t.Fatal("expected an unadvertised algorithm to be rejected")
+	}
+}
+
+func TestSupportsDigestAlgorithmFIPSModeRejectsNonApprovedAlgorithm(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeCapableReceiver{capabilities: []string{"sha256", "blake3"}}, func() error { return nil })
+	if err := pool.CheckReceiverVersion(); err != nil {
+		t.Fatalf("CheckReceiverVersion: %v", err)
+	}
+	pool.FIPSMode = true
+
+	if !pool.SupportsDigestAlgorithm("sha256") {
+		t.Fatal("expected sha256 to remain supported under FIPS mode")
+	}
+	if pool.SupportsDigestAlgorithm("blake3") {
+		t.Fatal("expected blake3 to be rejected under FIPS mode even though the receiver advertises it")
+	}
+}
+
+func TestSupportsDigestAlgorithmFIPSModeRejectsImplicitDefault(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeCapableReceiver{capabilities: []string{"sha256", "blake3"}}, func() error { return nil })
+	if err := pool.CheckReceiverVersion(); err != nil {
+		t.Fatalf("CheckReceiverVersion: %v", err)
+	}
+	pool.FIPSMode = true
+
+	if pool.SupportsDigestAlgorithm("") {
+		t.Fatal("expected the implicit legacy default (empty alg) to be rejected under FIPS mode")
+	}
+}
@@ -0,0 +1,29 @@
+package backend
+
+import (
+	"fmt"
+	"syscall"
+	"time"
+)
+
+// DiskWatchInterval is how often the disk space watchdog re-checks the
+// scratch filesystem's free space.
+const DiskWatchInterval = 30 * time.Second
+
+// DiskSpaceCheck returns a HealthCheck that fails once the filesystem
+// backing path has less than minFree bytes available, so a Pool can
+// refuse or flag new leases before the scratch area actually fills up
+// mid-commit.
+func DiskSpaceCheck(path string, minFree int64) HealthCheck {
+	return func() error {
+		var stat syscall.Statfs_t
+		if err := syscall.Statfs(path, &stat); err != nil {
+			return fmt.Errorf("could not stat scratch filesystem %s: %w", path, err)
+		}
+		available := int64(stat.Bavail) * int64(stat.Bsize)
+		if available < minFree {
+			return fmt.Errorf("scratch filesystem %s has %d bytes free, below the %d byte minimum", path, available, minFree)
+		}
+		return nil
+	}
+}
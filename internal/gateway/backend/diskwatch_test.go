@@ -0,0 +1,35 @@
+package backend
+
+import "testing"
+
+func TestDiskSpaceCheckPassesWithGenerousThreshold(t *testing.T) {
+	check := DiskSpaceCheck(t.TempDir(), 1)
+	if err := check(); err != nil {
+		t.Fatalf("DiskSpaceCheck: %v", err)
+	}
+}
+
+func TestDiskSpaceCheckFailsWithImpossibleThreshold(t *testing.T) {
+	check := DiskSpaceCheck(t.TempDir(), 1<<62)
+	if err := check(); err == nil {
+		t.Fatal("expected DiskSpaceCheck to fail when the threshold exceeds any real filesystem's capacity")
+	}
+}
+
+func TestDiskSpaceCheckFailsForMissingPath(t *testing.T) {
+	check := DiskSpaceCheck("/does/not/exist", 1)
+	if err := check(); err == nil {
+		t.Fatal("expected DiskSpaceCheck to fail for a nonexistent path")
+	}
+}
+
+func TestEnableDiskWatchdogStartsHealthy(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.EnableDiskWatchdog(func() error { return nil })
+	if pool.DiskHealth == nil {
+		t.Fatal("expected EnableDiskWatchdog to set DiskHealth")
+	}
+	if !pool.DiskHealth.Status().Healthy {
+		t.Fatal("expected the watchdog to start healthy before its first run")
+	}
+}
@@ -0,0 +1,37 @@
+package backend
+
+// ErrDraining is returned by NewLease and NewLeaseWait once the pool has
+// been put into drain mode, so an operator performing a blue-green
+// upgrade can be sure no new lease slips in between exporting a
+// Snapshot and shutting the old instance down. Leases already held are
+// unaffected; they can still be committed or cancelled while draining.
+type ErrDraining struct{}
+
+func (ErrDraining) Error() string {
+	return "gateway is draining: no new leases are being accepted"
+}
+
+// Drain stops the pool from accepting new leases, without disturbing
+// leases already held. It is the first step of a blue-green upgrade
+// handoff: once drained, an operator takes a Backup, hands it to the
+// incoming instance's Restore, and only then retires this one.
+func (p *Pool) Drain() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = true
+}
+
+// Undrain resumes accepting new leases after a Drain, for an instance
+// that was drained in preparation for a handoff that didn't go through.
+func (p *Pool) Undrain() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.draining = false
+}
+
+// Draining reports whether the pool is currently refusing new leases.
+func (p *Pool) Draining() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.draining
+}
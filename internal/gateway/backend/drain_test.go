@@ -0,0 +1,30 @@
+package backend
+
+import "testing"
+
+func TestDrainRejectsNewLeases(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.Drain()
+
+	if !pool.Draining() {
+		t.Fatal("expected pool to report draining after Drain")
+	}
+	if _, err := pool.NewLease("key1", "token1", "repo.example.org", "repo.example.org/a", 0); err == nil {
+		t.Fatal("expected NewLease to fail while draining")
+	} else if _, ok := err.(ErrDraining); !ok {
+		t.Fatalf("expected ErrDraining, got %T: %v", err, err)
+	}
+}
+
+func TestUndrainResumesAcceptingLeases(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.Drain()
+	pool.Undrain()
+
+	if pool.Draining() {
+		t.Fatal("expected pool to no longer report draining after Undrain")
+	}
+	if _, err := pool.NewLease("key1", "token1", "repo.example.org", "repo.example.org/a", 0); err != nil {
+		t.Fatalf("expected NewLease to succeed after Undrain, got %v", err)
+	}
+}
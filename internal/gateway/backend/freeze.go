@@ -0,0 +1,87 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRepositoryFrozen is returned by NewLease and NewLeaseWait when repo
+// is currently inside one of its configured freeze windows.
+type ErrRepositoryFrozen struct {
+	Repository string
+	Reason     string
+}
+
+func (e ErrRepositoryFrozen) Error() string {
+	return fmt.Sprintf("repository %s is frozen: %s", e.Repository, e.Reason)
+}
+
+// FreezeWindow is a single scheduled freeze period for a repository: a
+// 5-field cron expression (minute hour day-of-month month day-of-week)
+// naming the minute the freeze begins, and how long it lasts once
+// triggered. Each field accepts "*" or a comma-separated list of exact
+// values; ranges and step syntax aren't supported.
+type FreezeWindow struct {
+	Schedule string
+	Duration time.Duration
+	Reason   string
+}
+
+func cronFieldMatches(field string, value int) bool {
+	if field == "*" {
+		return true
+	}
+	for _, part := range strings.Split(field, ",") {
+		if n, err := strconv.Atoi(strings.TrimSpace(part)); err == nil && n == value {
+			return true
+		}
+	}
+	return false
+}
+
+func (w FreezeWindow) matchesAt(t time.Time) (bool, error) {
+	fields := strings.Fields(w.Schedule)
+	if len(fields) != 5 {
+		return false, fmt.Errorf("backend: freeze schedule %q must have 5 fields (minute hour day-of-month month day-of-week)", w.Schedule)
+	}
+	return cronFieldMatches(fields[0], t.Minute()) &&
+		cronFieldMatches(fields[1], t.Hour()) &&
+		cronFieldMatches(fields[2], t.Day()) &&
+		cronFieldMatches(fields[3], int(t.Month())) &&
+		cronFieldMatches(fields[4], int(t.Weekday())), nil
+}
+
+// active reports whether now falls within a freeze triggered by w: some
+// minute in [now-Duration, now] matched its cron schedule.
+func (w FreezeWindow) active(now time.Time) (bool, error) {
+	for at := now; !at.Before(now.Add(-w.Duration)); at = at.Add(-time.Minute) {
+		matched, err := w.matchesAt(at)
+		if err != nil {
+			return false, err
+		}
+		if matched {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// freezeReason reports whether now falls within any of windows, and if
+// so, why. A malformed schedule is treated as not matching, rather than
+// failing the lease request outright.
+func freezeReason(windows []FreezeWindow, now time.Time) (string, bool) {
+	for _, w := range windows {
+		active, err := w.active(now)
+		if err != nil || !active {
+			continue
+		}
+		reason := w.Reason
+		if reason == "" {
+			reason = "scheduled freeze window"
+		}
+		return reason, true
+	}
+	return "", false
+}
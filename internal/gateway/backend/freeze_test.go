@@ -0,0 +1,61 @@
+package backend
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestFreezeReasonMatchesActiveWindow(t *testing.T) {
+	now := time.Date(2026, time.August, 8, 14, 30, 0, 0, time.UTC)
+	windows := []FreezeWindow{
+		{Schedule: "0 14 8 8 *", Duration: time.Hour, Reason: "conference freeze"},
+	}
+	reason, frozen := freezeReason(windows, now)
+	if !frozen {
+		t.Fatal("expected the window to be active at its trigger time")
+	}
+	if reason != "conference freeze" {
+		t.Fatalf("got reason %q, want %q", reason, "conference freeze")
+	}
+}
+
+func TestFreezeReasonIgnoresInactiveWindow(t *testing.T) {
+	now := time.Date(2026, time.August, 8, 14, 30, 0, 0, time.UTC)
+	windows := []FreezeWindow{
+		{Schedule: "0 14 1 1 *", Duration: time.Hour},
+	}
+	if _, frozen := freezeReason(windows, now); frozen {
+		t.Fatal("expected a January 1st schedule not to match an August trigger time")
+	}
+}
+
+func TestFreezeReasonDefaultsReasonWhenUnset(t *testing.T) {
+	now := time.Date(2026, time.August, 8, 14, 0, 0, 0, time.UTC)
+	windows := []FreezeWindow{
+		{Schedule: "0 14 8 8 *", Duration: time.Minute},
+	}
+	reason, frozen := freezeReason(windows, now)
+	if !frozen || reason == "" {
+		t.Fatalf("expected a default reason for an unset FreezeWindow.Reason, got %q, frozen=%v", reason, frozen)
+	}
+}
+
+func TestNewLeaseWaitRejectsLeaseDuringFreezeWindow(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	frozenAt := time.Now()
+	pool.FreezeWindows = func(repo string) []FreezeWindow {
+		return []FreezeWindow{
+			{Schedule: fmtCronForMinute(frozenAt), Duration: time.Minute, Reason: "freeze window"},
+		}
+	}
+
+	_, err := pool.NewLease("key1", "token1", "myrepo", "myrepo/a", 0)
+	if _, ok := err.(ErrRepositoryFrozen); !ok {
+		t.Fatalf("expected ErrRepositoryFrozen, got %v", err)
+	}
+}
+
+func fmtCronForMinute(t time.Time) string {
+	return fmt.Sprintf("%d %d %d %d %d", t.Minute(), t.Hour(), t.Day(), int(t.Month()), int(t.Weekday()))
+}
@@ -0,0 +1,105 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/jobqueue"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// JobTypeGC is the jobqueue job type used for asynchronous garbage
+// collection runs.
+const JobTypeGC = "gc"
+
+// GCReportStore holds the most recent garbage collection report for each
+// repository, so a dry-run report (or the outcome of an asynchronous
+// real run) can be retrieved after the fact.
+type GCReportStore struct {
+	mu      sync.Mutex
+	reports map[string]receiver.GCReport
+}
+
+// NewGCReportStore returns an empty GCReportStore.
+func NewGCReportStore() *GCReportStore {
+	return &GCReportStore{reports: make(map[string]receiver.GCReport)}
+}
+
+func (s *GCReportStore) save(repo string, report receiver.GCReport) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.reports[repo] = report
+}
+
+// Get returns the most recent garbage collection report for repo, if any.
+func (s *GCReportStore) Get(repo string) (receiver.GCReport, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.reports[repo]
+	return r, ok
+}
+
+type gcJobArgs struct {
+	Repo string
+	Opts receiver.GCOptions
+}
+
+// JobDescription implements jobqueue.Describer.
+func (a gcJobArgs) JobDescription() string { return a.Repo }
+
+func (p *Pool) runGCJob(job jobqueue.Job) error {
+	args := job.Data.(gcJobArgs)
+	_, err := p.RunGC(args.Repo, args.Opts)
+	return err
+}
+
+// gcMaintenanceTTL bounds how long a single GC run holds repo's
+// maintenance lock, so a receiver process that hangs doesn't lock a
+// repository out of maintenance forever.
+const gcMaintenanceTTL = 30 * time.Minute
+
+// maintenanceHolderGC identifies the gateway itself as the holder of a
+// repository's maintenance lock while RunGC is in progress, distinct
+// from an external cvmfs_server operation's own holder identity.
+const maintenanceHolderGC = "gateway-gc"
+
+// RunGC runs garbage collection against repo, saving the report of a
+// successful run, dry or real, for later retrieval via GCReports; a
+// failed run saves nothing. It requires the
+// configured Receiver to implement receiver.GCer. It acquires repo's
+// maintenance lock for the duration of the run, refusing to start (and
+// leaving any concurrent commit or external cvmfs_server operation
+// undisturbed) if another holder already has it; see
+// MaintenanceLocker.
+func (p *Pool) RunGC(repo string, opts receiver.GCOptions) (receiver.GCReport, error) {
+	gcer, ok := p.Receiver.(receiver.GCer)
+	if !ok {
+		return receiver.GCReport{}, fmt.Errorf("backend: receiver does not support garbage collection")
+	}
+
+	granted, err := p.AcquireMaintenanceLock(repo, maintenanceHolderGC, gcMaintenanceTTL)
+	if err != nil {
+		return receiver.GCReport{}, err
+	}
+	if !granted {
+		holder, _ := p.MaintenanceLockHolder(repo)
+		return receiver.GCReport{}, ErrMaintenanceLocked{Repository: repo, Holder: holder}
+	}
+	defer p.ReleaseMaintenanceLock(repo, maintenanceHolderGC)
+
+	start := time.Now()
+	report, err := gcer.GC(repo, opts)
+	p.observeDuration("gc", repo, start)
+	if err != nil {
+		return receiver.GCReport{}, err
+	}
+	p.GCReports.save(repo, report)
+	return report, nil
+}
+
+// RunGCAsync enqueues a garbage collection run on the background job
+// queue and returns immediately.
+func (p *Pool) RunGCAsync(repo string, opts receiver.GCOptions) (jobqueue.Job, error) {
+	return p.Jobs.Enqueue(JobTypeGC, gcJobArgs{Repo: repo, Opts: opts})
+}
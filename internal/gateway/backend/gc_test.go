@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeGCReceiver struct {
+	fakeReceiver
+	report receiver.GCReport
+}
+
+func (f fakeGCReceiver) GC(repository string, opts receiver.GCOptions) (receiver.GCReport, error) {
+	report := f.report
+	report.DryRun = opts.DryRun
+	return report, nil
+}
+
+func TestRunGCSavesReportForLaterRetrieval(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeGCReceiver{report: receiver.GCReport{DeletedObjects: []string{"/a"}}}, func() error { return nil })
+
+	report, err := pool.RunGC("repo.example.org", receiver.GCOptions{DryRun: true})
+	if err != nil {
+		t.Fatalf("RunGC: %v", err)
+	}
+	if !report.DryRun {
+		t.Fatal("expected DryRun to be propagated to the report")
+	}
+
+	saved, ok := pool.GCReports.Get("repo.example.org")
+	if !ok {
+		t.Fatal("expected report to be saved")
+	}
+	if len(saved.DeletedObjects) != 1 {
+		t.Fatalf("expected saved report to carry deleted objects, got %v", saved.DeletedObjects)
+	}
+}
+
+func TestRunGCFailsWithoutGCCapableReceiver(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if _, err := pool.RunGC("repo.example.org", receiver.GCOptions{}); err == nil {
+		t.Fatal("expected an error when the receiver doesn't support GC")
+	}
+}
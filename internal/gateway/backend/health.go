@@ -0,0 +1,78 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// HealthCheck reports whether the upstream storage backend is reachable
+// and writable.
+type HealthCheck func() error
+
+// HealthMonitor periodically runs a HealthCheck against upstream storage
+// and caches the latest result so request handlers can report health
+// without blocking on a live check.
+type HealthMonitor struct {
+	check    HealthCheck
+	interval time.Duration
+
+	mu        sync.RWMutex
+	healthy   bool
+	lastErr   string
+	lastRun   time.Time
+	lastCheck time.Duration
+}
+
+// NewHealthMonitor returns a monitor that runs check every interval,
+// starting in a healthy state until the first check completes.
+func NewHealthMonitor(check HealthCheck, interval time.Duration) *HealthMonitor {
+	return &HealthMonitor{check: check, interval: interval, healthy: true}
+}
+
+// Start runs the periodic health check loop until stopCh is closed.
+func (m *HealthMonitor) Start(stopCh <-chan struct{}) {
+	m.runOnce()
+	ticker := time.NewTicker(m.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			m.runOnce()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (m *HealthMonitor) runOnce() {
+	start := time.Now()
+	err := m.check()
+	latency := time.Since(start)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.lastRun = time.Now()
+	m.lastCheck = latency
+	if err != nil {
+		m.healthy = false
+		m.lastErr = err.Error()
+		return
+	}
+	m.healthy = true
+	m.lastErr = ""
+}
+
+// Status is a snapshot of the upstream storage's health.
+type Status struct {
+	Healthy   bool          `json:"healthy"`
+	Error     string        `json:"error,omitempty"`
+	CheckedAt time.Time     `json:"checked_at"`
+	Latency   time.Duration `json:"latency_ns,omitempty"`
+}
+
+// Status returns the most recently observed health status.
+func (m *HealthMonitor) Status() Status {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return Status{Healthy: m.healthy, Error: m.lastErr, CheckedAt: m.lastRun, Latency: m.lastCheck}
+}
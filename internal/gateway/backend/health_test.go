@@ -0,0 +1,26 @@
+package backend
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthMonitorStartsHealthy(t *testing.T) {
+	m := NewHealthMonitor(func() error { return nil }, time.Hour)
+	if !m.Status().Healthy {
+		t.Fatal("expected monitor to start healthy before its first run")
+	}
+}
+
+func TestHealthMonitorRecordsFailure(t *testing.T) {
+	m := NewHealthMonitor(func() error { return errors.New("unreachable") }, time.Hour)
+	m.runOnce()
+	status := m.Status()
+	if status.Healthy {
+		t.Fatal("expected monitor to be unhealthy after a failing check")
+	}
+	if status.Error != "unreachable" {
+		t.Fatalf("unexpected error message: %q", status.Error)
+	}
+}
@@ -0,0 +1,139 @@
+package backend
+
+import "time"
+
+// healthFactorWeight is how many of the 100 total points each
+// contributing factor accounts for. All four are weighted equally: none
+// of queue depth, error rate, storage latency, or lease database latency
+// is a reliable enough single signal on its own to be trusted more than
+// the others.
+const healthFactorWeight = 25
+
+// HealthFactor is one dimension considered by HealthScore, scored
+// 0-100 on its own, with a human-readable Detail explaining the reading.
+type HealthFactor struct {
+	Name   string `json:"name"`
+	Score  int    `json:"score"`
+	Detail string `json:"detail"`
+}
+
+// HealthScore is a composite 0-100 summary of gateway health, broken
+// down into the factors that were weighed to produce it, so an operator
+// can see at a glance which dimension is dragging the score down instead
+// of just that something, somewhere, is degraded.
+type HealthScore struct {
+	Score   int            `json:"score"`
+	Factors []HealthFactor `json:"factors"`
+}
+
+// queueDepthThresholds bound the jobqueue backlog score: at or below the
+// first threshold the queue is keeping up, at or above the second it's
+// falling badly behind.
+const (
+	queueDepthHealthy  = 4
+	queueDepthDegraded = 32
+)
+
+func (p *Pool) queueDepthFactor() HealthFactor {
+	depth := p.Jobs.QueueDepth()
+	f := HealthFactor{Name: "queue_depth", Detail: "background job queue depth"}
+	switch {
+	case depth <= queueDepthHealthy:
+		f.Score = 100
+	case depth >= queueDepthDegraded:
+		f.Score = 0
+	default:
+		f.Score = 100 - (depth-queueDepthHealthy)*100/(queueDepthDegraded-queueDepthHealthy)
+	}
+	return f
+}
+
+// errorRateThresholds bound the circuit breaker's recent-failure score,
+// counted across all repositories: at or below the first threshold
+// failures are within normal noise, at or above the second there are as
+// many as it takes to trip a single repository's circuit.
+const (
+	errorRateHealthy  = 1
+	errorRateDegraded = breakerFailureThreshold
+)
+
+func (p *Pool) errorRateFactor() HealthFactor {
+	failures, open := p.Breaker.RecentFailures()
+	f := HealthFactor{Name: "error_rate", Detail: "recent commit failures across all repositories"}
+	switch {
+	case open > 0:
+		f.Score = 0
+	case failures <= errorRateHealthy:
+		f.Score = 100
+	case failures >= errorRateDegraded:
+		f.Score = 10
+	default:
+		f.Score = 100 - (failures-errorRateHealthy)*90/(errorRateDegraded-errorRateHealthy)
+	}
+	return f
+}
+
+// latencyThresholds bound both the storage and lease database latency
+// scores: at or below the first threshold latency is unremarkable, at or
+// above the second it's slow enough to be worth paging on.
+const (
+	latencyHealthy  = 50 * time.Millisecond
+	latencyDegraded = 1 * time.Second
+)
+
+func scoreLatency(d time.Duration) int {
+	switch {
+	case d <= latencyHealthy:
+		return 100
+	case d >= latencyDegraded:
+		return 0
+	default:
+		return 100 - int((d-latencyHealthy)*100/(latencyDegraded-latencyHealthy))
+	}
+}
+
+func (p *Pool) storageLatencyFactor() HealthFactor {
+	status := p.Health.Status()
+	return HealthFactor{
+		Name:   "storage_latency",
+		Score:  scoreLatency(status.Latency),
+		Detail: "upstream storage health check latency",
+	}
+}
+
+// leaseDBLatencyFactor times a live, lightweight LeaseDB call rather
+// than relying on a cached measurement, since unlike the upstream
+// storage check there is no periodic monitor already sampling it.
+func (p *Pool) leaseDBLatencyFactor() HealthFactor {
+	start := time.Now()
+	_, err := p.Leases.GetLeases()
+	latency := time.Since(start)
+
+	f := HealthFactor{Name: "lease_db_latency", Detail: "live lease database round-trip latency"}
+	if err != nil {
+		f.Score = 0
+		f.Detail = "lease database call failed: " + err.Error()
+		return f
+	}
+	f.Score = scoreLatency(latency)
+	return f
+}
+
+// HealthScore computes a composite health score from the current queue
+// depth, recent commit error rate, upstream storage latency, and lease
+// database latency. Unlike Health.Status, which reports a cached result
+// from the periodic background check, this makes a live LeaseDB call
+// each time it's called, so it shouldn't be polled on a tight loop.
+func (p *Pool) HealthScore() HealthScore {
+	factors := []HealthFactor{
+		p.queueDepthFactor(),
+		p.errorRateFactor(),
+		p.storageLatencyFactor(),
+		p.leaseDBLatencyFactor(),
+	}
+	total := 0
+	for _, f := range factors {
+		total += f.Score
+	}
+	return HealthScore{Score: total / len(factors), Factors: factors}
+}
@@ -0,0 +1,40 @@
+package backend
+
+import "testing"
+
+func TestHealthScoreAllFactorsHealthyByDefault(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	score := pool.HealthScore()
+	if len(score.Factors) != 4 {
+		t.Fatalf("expected 4 factors, got %d", len(score.Factors))
+	}
+	if score.Score != 100 {
+		t.Fatalf("expected a perfect score on a freshly constructed pool, got %d (%+v)", score.Score, score.Factors)
+	}
+}
+
+func TestHealthScoreDropsWhenCircuitOpen(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	for i := 0; i < breakerFailureThreshold; i++ {
+		pool.Breaker.RecordFailure("repo.example.org")
+	}
+
+	score := pool.HealthScore()
+	if score.Score >= 100 {
+		t.Fatalf("expected a degraded score once a repository's circuit is open, got %d", score.Score)
+	}
+
+	var found bool
+	for _, f := range score.Factors {
+		if f.Name == "error_rate" {
+			found = true
+			if f.Score != 0 {
+				t.Fatalf("expected error_rate factor to bottom out at 0 with an open circuit, got %d", f.Score)
+			}
+		}
+	}
+	if !found {
+		t.Fatal("expected an error_rate factor in the score breakdown")
+	}
+}
@@ -0,0 +1,83 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// LeaseHistoryEntry records the outcome of a lease that has since been
+// removed from the active LeaseDB, so operators can audit publish
+// activity and compute stats over a path after the fact.
+type LeaseHistoryEntry struct {
+	Path       string `json:"path"`
+	Repository string `json:"repository"`
+	KeyID      string `json:"key_id,omitempty"`
+
+	// Outcome is "committed" or "cancelled".
+	Outcome string `json:"outcome"`
+
+	RecordedAt time.Time `json:"recorded_at"`
+}
+
+// HistoryStore retains LeaseHistoryEntry records for a configurable
+// retention period after the lease they describe is committed or
+// cancelled, instead of the LeaseDB simply forgetting them. Compact
+// drops entries older than the retention period; it's called from the
+// pool's regular janitor sweep rather than run on its own schedule.
+type HistoryStore struct {
+	mu        sync.Mutex
+	retention time.Duration
+	entries   []LeaseHistoryEntry
+}
+
+// NewHistoryStore returns an empty HistoryStore that retains entries for
+// retention before Compact removes them.
+func NewHistoryStore(retention time.Duration) *HistoryStore {
+	return &HistoryStore{retention: retention}
+}
+
+// record appends e to the history log.
+func (s *HistoryStore) record(e LeaseHistoryEntry) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.entries = append(s.entries, e)
+}
+
+// Query returns every retained history entry for path, oldest first.
+func (s *HistoryStore) Query(path string) []LeaseHistoryEntry {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []LeaseHistoryEntry
+	for _, e := range s.entries {
+		if e.Path == path {
+			out = append(out, e)
+		}
+	}
+	return out
+}
+
+// Compact drops every entry older than the retention period as of now,
+// returning the number of entries removed.
+func (s *HistoryStore) Compact(now time.Time) int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	kept := s.entries[:0]
+	removed := 0
+	for _, e := range s.entries {
+		if now.Sub(e.RecordedAt) > s.retention {
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	s.entries = kept
+	return removed
+}
+
+// EnableLeaseHistory turns on retention of completed/cancelled lease
+// records for retention, queryable via History and compacted on the
+// pool's regular janitor sweep. It defaults to off; History is nil until
+// this is called.
+func (p *Pool) EnableLeaseHistory(retention time.Duration) {
+	p.History = NewHistoryStore(retention)
+}
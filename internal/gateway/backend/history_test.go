@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestHistoryRecordsCommitAndCancel(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.EnableLeaseHistory(time.Hour)
+
+	if _, err := pool.NewLease("key1", "token1", "repo.example.org", "repo.example.org/a", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.CommitLease("token1", "repo.example.org/a", receiver.Payload{}); err != nil {
+		t.Fatalf("CommitLease: %v", err)
+	}
+
+	if _, err := pool.NewLease("key1", "token2", "repo.example.org", "repo.example.org/b", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.CancelLease("repo.example.org/b"); err != nil {
+		t.Fatalf("CancelLease: %v", err)
+	}
+
+	committed := pool.History.Query("repo.example.org/a")
+	if len(committed) != 1 || committed[0].Outcome != "committed" || committed[0].KeyID != "key1" {
+		t.Fatalf("unexpected committed history: %+v", committed)
+	}
+
+	cancelled := pool.History.Query("repo.example.org/b")
+	if len(cancelled) != 1 || cancelled[0].Outcome != "cancelled" {
+		t.Fatalf("unexpected cancelled history: %+v", cancelled)
+	}
+}
+
+func TestHistoryCompactDropsExpiredEntries(t *testing.T) {
+	store := NewHistoryStore(time.Minute)
+	store.record(LeaseHistoryEntry{Path: "repo/a", Outcome: "committed", RecordedAt: time.Now().Add(-time.Hour)})
+	store.record(LeaseHistoryEntry{Path: "repo/b", Outcome: "committed", RecordedAt: time.Now()})
+
+	removed := store.Compact(time.Now())
+	if removed != 1 {
+		t.Fatalf("expected 1 entry removed, got %d", removed)
+	}
+	if len(store.Query("repo/a")) != 0 {
+		t.Fatal("expected expired entry to be gone")
+	}
+	if len(store.Query("repo/b")) != 1 {
+		t.Fatal("expected fresh entry to survive compaction")
+	}
+}
@@ -0,0 +1,165 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// janitorInterval is how often the orphaned lease and stale lease janitor
+// sweeps the lease database.
+const janitorInterval = 5 * time.Minute
+
+// JanitorReport summarizes the outcome of a single janitor sweep.
+type JanitorReport struct {
+	// OrphanedLeases lists the paths of leases that referenced a
+	// repository no longer present in the access configuration, and were
+	// cancelled as a result.
+	OrphanedLeases []string `json:"orphaned_leases,omitempty"`
+
+	// StaleLeases lists the paths of leases that passed their expiration
+	// without ever being committed or cancelled by the client.
+	StaleLeases []string `json:"stale_leases,omitempty"`
+
+	// HistoryCompacted counts lease history entries dropped for having
+	// aged past their retention period. It stays 0 unless
+	// EnableLeaseHistory was called.
+	HistoryCompacted int `json:"history_compacted,omitempty"`
+
+	RanAt time.Time `json:"ran_at"`
+}
+
+// Janitor periodically sweeps the pool for leases that have become
+// inconsistent and cleans them up, caching the most recent report so it
+// can be inspected without blocking on a sweep.
+type Janitor struct {
+	sweep    func() JanitorReport
+	interval time.Duration
+
+	mu     sync.RWMutex
+	report JanitorReport
+}
+
+// NewJanitor returns a janitor that runs sweep every interval.
+func NewJanitor(sweep func() JanitorReport, interval time.Duration) *Janitor {
+	return &Janitor{sweep: sweep, interval: interval}
+}
+
+// Start runs the periodic sweep loop until stopCh is closed. It should be
+// started once, in its own goroutine.
+func (j *Janitor) Start(stopCh <-chan struct{}) {
+	j.runOnce()
+	ticker := time.NewTicker(j.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			j.runOnce()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+func (j *Janitor) runOnce() {
+	report := j.sweep()
+	j.mu.Lock()
+	j.report = report
+	j.mu.Unlock()
+}
+
+// LastReport returns the most recent sweep's report.
+func (j *Janitor) LastReport() JanitorReport {
+	j.mu.RLock()
+	defer j.mu.RUnlock()
+	return j.report
+}
+
+// RunJanitorSweep runs a sweep immediately, outside of the janitor's
+// regular schedule, and updates its last report.
+func (p *Pool) RunJanitorSweep() JanitorReport {
+	report := p.sweep()
+	p.Janitor.mu.Lock()
+	p.Janitor.report = report
+	p.Janitor.mu.Unlock()
+	return report
+}
+
+// janitorLeaderKey namespaces the janitor's leadership campaign from any
+// other singleton duty electing under the same LeaseDB.
+const janitorLeaderKey = "janitor"
+
+// sweepIfLeader runs a sweep only when this instance currently holds
+// janitor leadership, so that of several gateway instances sharing the
+// same LeaseDB, only one of them cancels orphaned and stale leases on
+// each tick. An instance that isn't leader reports the last sweep it
+// itself ran (which may be stale, or empty if it has never held
+// leadership) rather than a fresh one.
+func (p *Pool) sweepIfLeader() JanitorReport {
+	if !p.IsLeader(janitorLeaderKey) {
+		return p.Janitor.LastReport()
+	}
+	return p.sweep()
+}
+
+// sweep implements one janitor pass: it cancels leases referencing a
+// repository the access configuration no longer knows about ("orphaned"),
+// and leases that expired without ever being committed or cancelled
+// ("stale"). A stale lease with staged upload data waits out
+// LeaseGracePeriod before it's actually cancelled, in case the publisher
+// reconnects; once cancelled, dispatchLeaseCleanup discards the staged
+// upload and fires LeaseExpiredNotify. Sessions returned by
+// handleNewSession are just leases under a different name in this
+// gateway, so no separate upload-directory sweep is needed: cleaning up
+// stale leases covers both.
+func (p *Pool) sweep() JanitorReport {
+	report := JanitorReport{RanAt: time.Now()}
+
+	leases, err := p.Leases.GetLeases()
+	if err != nil {
+		return report
+	}
+
+	now := time.Now()
+	for path, l := range leases {
+		switch {
+		case p.RepoKnown != nil && !p.RepoKnown(l.Repository):
+			if err := p.Leases.CancelLease(path); err == nil {
+				report.OrphanedLeases = append(report.OrphanedLeases, path)
+				if p.History != nil {
+					p.History.record(LeaseHistoryEntry{
+						Path:       path,
+						Repository: l.Repository,
+						KeyID:      l.KeyID,
+						Outcome:    "cancelled",
+						RecordedAt: now,
+					})
+				}
+			}
+		case l.Expired(now):
+			if l.BytesSubmitted > 0 && p.LeaseGracePeriod > 0 && now.Before(l.Expiration.Add(p.LeaseGracePeriod)) {
+				// Staged upload, grace period not yet elapsed: leave the
+				// lease in place in case the publisher reconnects.
+				continue
+			}
+			if err := p.Leases.CancelLease(path); err == nil {
+				report.StaleLeases = append(report.StaleLeases, path)
+				if l.BytesSubmitted > 0 {
+					p.dispatchLeaseCleanup(l)
+				}
+				if p.History != nil {
+					p.History.record(LeaseHistoryEntry{
+						Path:       path,
+						Repository: l.Repository,
+						KeyID:      l.KeyID,
+						Outcome:    "cancelled",
+						RecordedAt: now,
+					})
+				}
+			}
+		}
+	}
+	if p.History != nil {
+		report.HistoryCompacted = p.History.Compact(now)
+	}
+	return report
+}
@@ -0,0 +1,96 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSweepCancelsOrphanedLeases(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.RepoKnown = func(repo string) bool { return repo == "known.example.org" }
+
+	if _, err := pool.NewLease("keyA", "tok1", "gone.example.org", "gone.example.org/a", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.NewLease("keyA", "tok2", "known.example.org", "known.example.org/a", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	report := pool.RunJanitorSweep()
+	if len(report.OrphanedLeases) != 1 || report.OrphanedLeases[0] != "gone.example.org/a" {
+		t.Fatalf("expected the orphaned lease to be reported, got %v", report.OrphanedLeases)
+	}
+	if _, err := pool.Leases.GetLease("gone.example.org/a"); err == nil {
+		t.Fatal("expected the orphaned lease to be cancelled")
+	}
+	if _, err := pool.Leases.GetLease("known.example.org/a"); err != nil {
+		t.Fatal("expected the lease for a known repository to survive the sweep")
+	}
+}
+
+func TestSweepCancelsStaleLeases(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.Leases.NewLease("tok1", Lease{
+		Token:      "tok1",
+		Repository: "repo.example.org",
+		Path:       "repo.example.org/a",
+		Expiration: time.Now().Add(-time.Minute),
+	})
+
+	report := pool.RunJanitorSweep()
+	if len(report.StaleLeases) != 1 || report.StaleLeases[0] != "repo.example.org/a" {
+		t.Fatalf("expected the expired lease to be reported as stale, got %v", report.StaleLeases)
+	}
+	if _, err := pool.Leases.GetLease("repo.example.org/a"); err == nil {
+		t.Fatal("expected the stale lease to be cancelled")
+	}
+}
+
+func TestSweepDefersStaleLeaseWithStagedDataUntilGracePeriodElapses(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.LeaseGracePeriod = time.Hour
+	pool.Leases.NewLease("tok1", Lease{
+		Token:          "tok1",
+		Repository:     "repo.example.org",
+		Path:           "repo.example.org/a",
+		Expiration:     time.Now().Add(-time.Minute),
+		BytesSubmitted: 42,
+	})
+
+	report := pool.RunJanitorSweep()
+	if len(report.StaleLeases) != 0 {
+		t.Fatalf("expected the lease to survive the sweep during its grace period, got %v", report.StaleLeases)
+	}
+	if _, err := pool.Leases.GetLease("repo.example.org/a"); err != nil {
+		t.Fatal("expected the lease to still exist during its grace period")
+	}
+}
+
+func TestSweepCleansUpStaleLeaseAfterGracePeriodElapses(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeCleanerReceiver{}, func() error { return nil })
+	pool.LeaseGracePeriod = time.Minute
+	var notified LeaseExpiryEvent
+	pool.LeaseExpiredNotify = func(e LeaseExpiryEvent) { notified = e }
+	pool.Leases.NewLease("tok1", Lease{
+		Token:          "tok1",
+		Repository:     "repo.example.org",
+		Path:           "repo.example.org/a",
+		KeyID:          "keyA",
+		Expiration:     time.Now().Add(-time.Hour),
+		BytesSubmitted: 42,
+	})
+
+	report := pool.RunJanitorSweep()
+	if len(report.StaleLeases) != 1 || report.StaleLeases[0] != "repo.example.org/a" {
+		t.Fatalf("expected the lease to be cancelled once its grace period elapsed, got %v", report.StaleLeases)
+	}
+	if notified.Path != "repo.example.org/a" || notified.KeyID != "keyA" {
+		t.Fatalf("expected LeaseExpiredNotify to fire for the discarded lease, got %+v", notified)
+	}
+}
+
+type fakeCleanerReceiver struct {
+	fakeReceiver
+}
+
+func (fakeCleanerReceiver) Cleanup(repository, path string) error { return nil }
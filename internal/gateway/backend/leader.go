@@ -0,0 +1,51 @@
+package backend
+
+import "time"
+
+// LeaderElector campaigns for exclusive leadership of one of the
+// gateway's singleton background duties (the janitor sweep, the
+// whitelist re-signing schedule) among multiple gateway instances
+// sharing the same LeaseDB, so that duty runs exactly once across a
+// cluster instead of once per instance. A LeaseDB that doesn't implement
+// it, or isn't actually shared across instances to begin with, simply
+// leaves every Pool.IsLeader call answering true, preserving
+// single-instance behavior.
+type LeaderElector interface {
+	// Campaign attempts to acquire or renew leadership of key for
+	// instanceID, valid until ttl elapses without a further renewal. It
+	// returns whether instanceID holds leadership as of this call.
+	Campaign(key, instanceID string, ttl time.Duration) (bool, error)
+}
+
+type leaderTerm struct {
+	instanceID string
+	expiresAt  time.Time
+}
+
+// newInstanceID returns a random identity for this gateway process to
+// campaign for leadership under, distinguishing it from any other
+// instance sharing the same LeaseDB.
+func newInstanceID() string {
+	return randomHex(8)
+}
+
+// IsLeader reports whether this instance currently holds leadership for
+// the named singleton duty. When LeadershipTTL is 0 (the default) or the
+// configured LeaseDB doesn't implement LeaderElector, every instance
+// answers true, so a single-instance deployment (or one backed by a
+// LeaseDB with no election support) behaves exactly as before this
+// existed.
+func (p *Pool) IsLeader(key string) bool {
+	if p.LeadershipTTL == 0 {
+		return true
+	}
+	elector, ok := p.Leases.(LeaderElector)
+	if !ok {
+		return true
+	}
+	leading, err := elector.Campaign(key, p.InstanceID, p.LeadershipTTL)
+	if err != nil {
+		return true
+	}
+	return leading
+}
@@ -0,0 +1,42 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestIsLeaderDefaultsToTrueWithoutElection(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if !pool.IsLeader("janitor") {
+		t.Fatal("expected IsLeader to default to true when LeadershipTTL is 0")
+	}
+}
+
+func TestIsLeaderElectsExactlyOneInstance(t *testing.T) {
+	db := NewMemLeaseDB()
+	a := NewPool(db, fakeReceiver{}, func() error { return nil })
+	a.LeadershipTTL = time.Minute
+	b := NewPool(db, fakeReceiver{}, func() error { return nil })
+	b.LeadershipTTL = time.Minute
+
+	if !a.IsLeader("janitor") {
+		t.Fatal("expected the first instance to win the campaign")
+	}
+	if b.IsLeader("janitor") {
+		t.Fatal("expected the second instance to lose the campaign while the first holds it")
+	}
+	if !a.IsLeader("janitor") {
+		t.Fatal("expected the leader to renew its own term")
+	}
+}
+
+func TestMemLeaseDBCampaignHandsOverAfterExpiry(t *testing.T) {
+	db := NewMemLeaseDB()
+	if ok, err := db.Campaign("janitor", "a", time.Millisecond); err != nil || !ok {
+		t.Fatalf("expected instance a to win the campaign, ok=%v err=%v", ok, err)
+	}
+	time.Sleep(5 * time.Millisecond)
+	if ok, err := db.Campaign("janitor", "b", time.Minute); err != nil || !ok {
+		t.Fatalf("expected instance b to win after a's term expired, ok=%v err=%v", ok, err)
+	}
+}
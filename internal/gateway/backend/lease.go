@@ -0,0 +1,57 @@
+// Package backend implements the gateway's core publishing logic: lease
+// management and commit coordination on top of a repository's storage.
+package backend
+
+import (
+	"time"
+)
+
+// Lease represents an exclusive publishing lease held on a repository
+// subpath by a key holder.
+type Lease struct {
+	Token          string    `json:"token"`
+	Repository     string    `json:"repository"`
+	Path           string    `json:"path"`
+	KeyID          string    `json:"key_id"`
+	Expiration     time.Time `json:"expiration"`
+	BytesSubmitted int64     `json:"bytes_submitted"`
+	MaxBytes       int64     `json:"max_bytes,omitempty"`
+
+	// AcquiredAt records when the lease was granted, so a LeaseSummary
+	// issued when it ends can report the transaction's total duration.
+	AcquiredAt time.Time `json:"acquired_at"`
+}
+
+// Expired reports whether the lease has passed its expiration time.
+func (l Lease) Expired(now time.Time) bool {
+	return now.After(l.Expiration)
+}
+
+// LeaseSummary reports the observed shape of a lease's transaction once
+// it ends, whether by commit or cancellation, so publisher tooling can
+// log a meaningful publish report instead of just a bare status.
+type LeaseSummary struct {
+	Path       string `json:"path"`
+	Repository string `json:"repository"`
+
+	// PayloadsReceived counts the payload submissions applied to the
+	// lease. It's 1 for a committed lease, since a lease's payload is
+	// applied in a single CommitLease call, and for a cancelled lease
+	// it's 1 if any bytes were submitted and 0 otherwise; it exists so a
+	// future multi-payload
+	// submission protocol can report an accurate count without another
+	// response field.
+	PayloadsReceived int `json:"payloads_received"`
+
+	BytesSubmitted int64 `json:"bytes_submitted"`
+
+	// Duration is the time between the lease being acquired and it
+	// ending, covering everything the publisher spent holding it, not
+	// just the final commit.
+	Duration time.Duration `json:"duration_ns"`
+
+	// ReceiverDuration is the time the receiver itself spent applying
+	// the commit. Zero for a cancelled lease, which never reaches the
+	// receiver.
+	ReceiverDuration time.Duration `json:"receiver_duration_ns,omitempty"`
+}
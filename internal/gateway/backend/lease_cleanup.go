@@ -0,0 +1,57 @@
+package backend
+
+import (
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/jobqueue"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// JobTypeLeaseCleanup is the jobqueue job type used to discard a
+// receiver's staged upload for a lease that expired without being
+// committed or cancelled.
+const JobTypeLeaseCleanup = "lease-cleanup"
+
+// LeaseExpiryEvent describes a stale lease discarded by the janitor after
+// its grace period elapsed, passed to LeaseExpiredNotify.
+type LeaseExpiryEvent struct {
+	Repository string
+	Path       string
+	KeyID      string
+	ExpiredAt  time.Time
+}
+
+type leaseCleanupJobArgs struct {
+	Repo string
+	Path string
+}
+
+// JobDescription implements jobqueue.Describer.
+func (a leaseCleanupJobArgs) JobDescription() string { return a.Path }
+
+func (p *Pool) runLeaseCleanupJob(job jobqueue.Job) error {
+	args := job.Data.(leaseCleanupJobArgs)
+	cleaner, ok := p.Receiver.(receiver.Cleaner)
+	if !ok {
+		return nil
+	}
+	return cleaner.Cleanup(args.Repo, args.Path)
+}
+
+// dispatchLeaseCleanup enqueues a cleanup job for a stale lease that had
+// staged upload data and notifies LeaseExpiredNotify, if set, that the
+// publisher's transaction was discarded. It's a no-op for the job
+// dispatch if the configured Receiver doesn't implement receiver.Cleaner.
+func (p *Pool) dispatchLeaseCleanup(l Lease) {
+	if _, ok := p.Receiver.(receiver.Cleaner); ok {
+		p.Jobs.Enqueue(JobTypeLeaseCleanup, leaseCleanupJobArgs{Repo: l.Repository, Path: l.Path})
+	}
+	if p.LeaseExpiredNotify != nil {
+		p.LeaseExpiredNotify(LeaseExpiryEvent{
+			Repository: l.Repository,
+			Path:       l.Path,
+			KeyID:      l.KeyID,
+			ExpiredAt:  l.Expiration,
+		})
+	}
+}
@@ -0,0 +1,109 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// repositoryFromPath extracts the leading repository name segment from a
+// lease path (e.g. "repo/some/subpath" -> "repo").
+func repositoryFromPath(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	return parts[0]
+}
+
+// LeaseConsistencyReport summarizes a cross-check of the lease database
+// against the receiver's own view of which paths have an active
+// upstream session.
+type LeaseConsistencyReport struct {
+	// Supported is false if the configured Receiver doesn't implement
+	// receiver.SessionReporter, in which case there is nothing to
+	// cross-check against and the two slices below are always empty.
+	Supported bool `json:"supported"`
+
+	// TokensWithoutSessions lists paths with a lease that has already
+	// received upload bytes, but that the receiver reports no active
+	// session for -- for example, a receiver process that crashed
+	// mid-upload without the gateway noticing.
+	TokensWithoutSessions []string `json:"tokens_without_sessions,omitempty"`
+
+	// SessionsWithoutTokens lists paths the receiver reports an active
+	// session for that have no corresponding lease at all -- for
+	// example, upstream state left behind after an admin
+	// force-cancelled a lease out from under an in-flight upload.
+	SessionsWithoutTokens []string `json:"sessions_without_tokens,omitempty"`
+
+	CheckedAt time.Time `json:"checked_at"`
+}
+
+// CheckLeaseConsistency cross-checks the lease database against the
+// receiver's reported active sessions, if it supports reporting them.
+func (p *Pool) CheckLeaseConsistency() (LeaseConsistencyReport, error) {
+	report := LeaseConsistencyReport{CheckedAt: time.Now()}
+
+	lister, ok := p.Receiver.(receiver.SessionReporter)
+	if !ok {
+		return report, nil
+	}
+	report.Supported = true
+
+	leases, err := p.Leases.GetLeases()
+	if err != nil {
+		return report, err
+	}
+	sessions, err := lister.ActiveSessions()
+	if err != nil {
+		return report, err
+	}
+
+	sessionSet := make(map[string]bool, len(sessions))
+	for _, s := range sessions {
+		sessionSet[s] = true
+	}
+	leaseSet := make(map[string]bool, len(leases))
+	for path, l := range leases {
+		leaseSet[path] = true
+		if l.BytesSubmitted > 0 && !sessionSet[path] {
+			report.TokensWithoutSessions = append(report.TokensWithoutSessions, path)
+		}
+	}
+	for _, s := range sessions {
+		if !leaseSet[s] {
+			report.SessionsWithoutTokens = append(report.SessionsWithoutTokens, s)
+		}
+	}
+	sort.Strings(report.TokensWithoutSessions)
+	sort.Strings(report.SessionsWithoutTokens)
+
+	return report, nil
+}
+
+// RepairLeaseConsistency discards every orphaned upstream session listed
+// in report.SessionsWithoutTokens via the receiver's Cleaner interface,
+// returning how many it successfully cleaned up. TokensWithoutSessions
+// isn't repaired here: there's no upstream session left to act on, only
+// a lease the publisher will have to retry or an operator will have to
+// cancel by hand.
+func (p *Pool) RepairLeaseConsistency(report LeaseConsistencyReport) (int, error) {
+	cleaner, ok := p.Receiver.(receiver.Cleaner)
+	if !ok {
+		return 0, fmt.Errorf("backend: receiver does not support cleaning up orphaned sessions")
+	}
+
+	var repaired int
+	var firstErr error
+	for _, path := range report.SessionsWithoutTokens {
+		if err := cleaner.Cleanup(repositoryFromPath(path), path); err != nil {
+			if firstErr == nil {
+				firstErr = err
+			}
+			continue
+		}
+		repaired++
+	}
+	return repaired, firstErr
+}
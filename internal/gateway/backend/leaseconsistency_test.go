@@ -0,0 +1,108 @@
+package backend
+
+import "testing"
+
+type fakeSessionReceiver struct {
+	fakeReceiver
+	sessions []string
+}
+
+func (r fakeSessionReceiver) ActiveSessions() ([]string, error) {
+	return r.sessions, nil
+}
+
+type fakeSessionCleanerReceiver struct {
+	fakeSessionReceiver
+	cleaned []string
+}
+
+func (r *fakeSessionCleanerReceiver) Cleanup(repository, path string) error {
+	r.cleaned = append(r.cleaned, path)
+	return nil
+}
+
+func TestCheckLeaseConsistencyReportsUnsupportedWithoutSessionReporter(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	report, err := pool.CheckLeaseConsistency()
+	if err != nil {
+		t.Fatalf("CheckLeaseConsistency: %v", err)
+	}
+	if report.Supported {
+		t.Fatal("expected Supported=false for a receiver that doesn't implement SessionReporter")
+	}
+	if len(report.TokensWithoutSessions) != 0 || len(report.SessionsWithoutTokens) != 0 {
+		t.Fatalf("expected no discrepancies when unsupported, got %+v", report)
+	}
+}
+
+func TestCheckLeaseConsistencyFindsTokensWithoutSessions(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeSessionReceiver{}, func() error { return nil })
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.Leases.AddBytes(lease.Path, 10); err != nil {
+		t.Fatalf("AddBytes: %v", err)
+	}
+
+	report, err := pool.CheckLeaseConsistency()
+	if err != nil {
+		t.Fatalf("CheckLeaseConsistency: %v", err)
+	}
+	if !report.Supported {
+		t.Fatal("expected Supported=true")
+	}
+	if len(report.TokensWithoutSessions) != 1 || report.TokensWithoutSessions[0] != lease.Path {
+		t.Fatalf("expected %q flagged as a token without a session, got %+v", lease.Path, report.TokensWithoutSessions)
+	}
+	if len(report.SessionsWithoutTokens) != 0 {
+		t.Fatalf("expected no orphaned sessions, got %+v", report.SessionsWithoutTokens)
+	}
+}
+
+func TestCheckLeaseConsistencyFindsSessionsWithoutTokens(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeSessionReceiver{sessions: []string{"myrepo/orphan"}}, func() error { return nil })
+
+	report, err := pool.CheckLeaseConsistency()
+	if err != nil {
+		t.Fatalf("CheckLeaseConsistency: %v", err)
+	}
+	if len(report.SessionsWithoutTokens) != 1 || report.SessionsWithoutTokens[0] != "myrepo/orphan" {
+		t.Fatalf("expected myrepo/orphan flagged as an orphaned session, got %+v", report.SessionsWithoutTokens)
+	}
+}
+
+func TestRepairLeaseConsistencyCleansUpOrphanedSessions(t *testing.T) {
+	receiver := &fakeSessionCleanerReceiver{fakeSessionReceiver: fakeSessionReceiver{sessions: []string{"myrepo/orphan"}}}
+	pool := NewPool(NewMemLeaseDB(), receiver, func() error { return nil })
+
+	report, err := pool.CheckLeaseConsistency()
+	if err != nil {
+		t.Fatalf("CheckLeaseConsistency: %v", err)
+	}
+
+	repaired, err := pool.RepairLeaseConsistency(report)
+	if err != nil {
+		t.Fatalf("RepairLeaseConsistency: %v", err)
+	}
+	if repaired != 1 {
+		t.Fatalf("expected 1 repaired session, got %d", repaired)
+	}
+	if len(receiver.cleaned) != 1 || receiver.cleaned[0] != "myrepo/orphan" {
+		t.Fatalf("expected Cleanup called for myrepo/orphan, got %+v", receiver.cleaned)
+	}
+}
+
+func TestRepairLeaseConsistencyFailsWithoutCleaner(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeSessionReceiver{sessions: []string{"myrepo/orphan"}}, func() error { return nil })
+
+	report, err := pool.CheckLeaseConsistency()
+	if err != nil {
+		t.Fatalf("CheckLeaseConsistency: %v", err)
+	}
+	if _, err := pool.RepairLeaseConsistency(report); err == nil {
+		t.Fatal("expected an error repairing without a receiver.Cleaner")
+	}
+}
@@ -0,0 +1,235 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+// LeaseDB is the storage interface for active leases. Implementations
+// must be safe for concurrent use.
+type LeaseDB interface {
+	NewLease(token string, l Lease) error
+	GetLease(path string) (Lease, error)
+	GetLeases() (map[string]Lease, error)
+	CommitLease(path string) error
+	CancelLease(path string) error
+	AddBytes(path string, n int64) (int64, error)
+
+	// LockPath and UnlockPath record which paths currently have a
+	// commit in flight, alongside when the lock was taken, so that a
+	// LeaseDB backed by durable storage can survive a gateway restart
+	// without forgetting that a receiver process may still be running
+	// against a path. MemLeaseDB implements these but, being in-memory
+	// itself, doesn't actually survive a restart; a persistent LeaseDB
+	// is what makes RestoreLocks meaningful.
+	LockPath(path string, at time.Time) error
+	UnlockPath(path string) error
+	LockedPaths() (map[string]time.Time, error)
+}
+
+// ErrLeaseNotFound is returned when no lease exists for a given path.
+type ErrLeaseNotFound struct {
+	Path string
+}
+
+func (e ErrLeaseNotFound) Error() string {
+	return fmt.Sprintf("no lease for path: %s", e.Path)
+}
+
+// MemLeaseDB is an in-memory LeaseDB implementation keyed by repository
+// subpath. It has no on-disk file to journal, so it has none of the
+// SQLite-specific "database is locked" failure mode a WAL-journaled,
+// busy-timeout-tuned lease database would need to guard against; this
+// tree has no SQLite-backed LeaseDB to apply that tuning to. What it can
+// offer at this layer is the same single-writer/multiple-reader access
+// pattern WAL mode provides, using an RWMutex so concurrent lease reads
+// (GetLease, GetLeases, LockedPaths) no longer serialize behind each
+// other under heavy lease churn.
+type MemLeaseDB struct {
+	mu     sync.RWMutex
+	leases map[string]Lease
+	locked map[string]time.Time
+
+	leaderMu    sync.Mutex
+	leaderTerms map[string]leaderTerm
+
+	maintenanceMu    sync.Mutex
+	maintenanceLocks map[string]maintenanceLock
+}
+
+// NewMemLeaseDB returns an empty in-memory lease database.
+func NewMemLeaseDB() *MemLeaseDB {
+	return &MemLeaseDB{
+		leases:           make(map[string]Lease),
+		locked:           make(map[string]time.Time),
+		leaderTerms:      make(map[string]leaderTerm),
+		maintenanceLocks: make(map[string]maintenanceLock),
+	}
+}
+
+// Campaign implements LeaderElector, granting leadership of key on a
+// first-come basis and only letting another instance take over once the
+// holder's term has lapsed without a renewal. It only actually
+// coordinates multiple gateway instances when they share one MemLeaseDB
+// by pointer, which happens in tests; a real multi-instance deployment
+// needs a LeaseDB backed by storage the instances actually share.
+func (db *MemLeaseDB) Campaign(key, instanceID string, ttl time.Duration) (bool, error) {
+	db.leaderMu.Lock()
+	defer db.leaderMu.Unlock()
+
+	now := time.Now()
+	term, held := db.leaderTerms[key]
+	if !held || now.After(term.expiresAt) || term.instanceID == instanceID {
+		db.leaderTerms[key] = leaderTerm{instanceID: instanceID, expiresAt: now.Add(ttl)}
+		return true, nil
+	}
+	return false, nil
+}
+
+// AcquireMaintenanceLock implements MaintenanceLocker, granting holder
+// exclusive maintenance access to repo on a first-come basis and only
+// letting another holder take over once the current one's lock has
+// expired without a renewal. It only actually coordinates a gateway with
+// an external cvmfs_server operation when they share one MemLeaseDB by
+// pointer, which happens in tests; a real deployment needs a LeaseDB
+// backed by storage both sides actually share.
+func (db *MemLeaseDB) AcquireMaintenanceLock(repo, holder string, ttl time.Duration) (bool, error) {
+	db.maintenanceMu.Lock()
+	defer db.maintenanceMu.Unlock()
+
+	now := time.Now()
+	lock, held := db.maintenanceLocks[repo]
+	if !held || now.After(lock.expiresAt) || lock.holder == holder {
+		db.maintenanceLocks[repo] = maintenanceLock{holder: holder, expiresAt: now.Add(ttl)}
+		return true, nil
+	}
+	return false, nil
+}
+
+// ReleaseMaintenanceLock implements MaintenanceLocker.
+func (db *MemLeaseDB) ReleaseMaintenanceLock(repo, holder string) error {
+	db.maintenanceMu.Lock()
+	defer db.maintenanceMu.Unlock()
+
+	if lock, held := db.maintenanceLocks[repo]; held && lock.holder == holder {
+		delete(db.maintenanceLocks, repo)
+	}
+	return nil
+}
+
+// MaintenanceLockHolder implements MaintenanceLocker.
+func (db *MemLeaseDB) MaintenanceLockHolder(repo string) (string, bool) {
+	db.maintenanceMu.Lock()
+	defer db.maintenanceMu.Unlock()
+
+	lock, held := db.maintenanceLocks[repo]
+	if !held || time.Now().After(lock.expiresAt) {
+		return "", false
+	}
+	return lock.holder, true
+}
+
+// NewLease records a new lease for the given path.
+func (db *MemLeaseDB) NewLease(token string, l Lease) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.leases[l.Path] = l
+	return nil
+}
+
+// GetLease returns the lease held on the given path, if any.
+func (db *MemLeaseDB) GetLease(path string) (Lease, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	l, ok := db.leases[path]
+	if !ok {
+		return Lease{}, ErrLeaseNotFound{Path: path}
+	}
+	return l, nil
+}
+
+// GetLeases returns a snapshot of all active leases, keyed by path.
+func (db *MemLeaseDB) GetLeases() (map[string]Lease, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	out := make(map[string]Lease, len(db.leases))
+	for k, v := range db.leases {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// CommitLease removes the lease for path after a successful commit.
+func (db *MemLeaseDB) CommitLease(path string) error {
+	return db.CancelLease(path)
+}
+
+// CancelLease drops the lease for path without committing it.
+func (db *MemLeaseDB) CancelLease(path string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	if _, ok := db.leases[path]; !ok {
+		return ErrLeaseNotFound{Path: path}
+	}
+	delete(db.leases, path)
+	return nil
+}
+
+// AddBytes accumulates n bytes against the lease's running submission
+// total and returns the new total.
+func (db *MemLeaseDB) AddBytes(path string, n int64) (int64, error) {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	l, ok := db.leases[path]
+	if !ok {
+		return 0, ErrLeaseNotFound{Path: path}
+	}
+	l.BytesSubmitted += n
+	db.leases[path] = l
+	return l.BytesSubmitted, nil
+}
+
+// LockPath records that path has a commit in flight as of at.
+func (db *MemLeaseDB) LockPath(path string, at time.Time) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	db.locked[path] = at
+	return nil
+}
+
+// UnlockPath clears the in-flight commit record for path, if any.
+func (db *MemLeaseDB) UnlockPath(path string) error {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	delete(db.locked, path)
+	return nil
+}
+
+// LockedPaths returns a snapshot of every path currently recorded as
+// having a commit in flight, keyed by when the lock was taken.
+func (db *MemLeaseDB) LockedPaths() (map[string]time.Time, error) {
+	db.mu.RLock()
+	defer db.mu.RUnlock()
+	out := make(map[string]time.Time, len(db.locked))
+	for k, v := range db.locked {
+		out[k] = v
+	}
+	return out, nil
+}
+
+// pruneExpired removes leases whose expiration is in the past. The pool's
+// periodic janitor doesn't call it: its sweep cancels expired leases one
+// by one through CancelLease instead.
+func (db *MemLeaseDB) pruneExpired(now time.Time) []Lease {
+	db.mu.Lock()
+	defer db.mu.Unlock()
+	var pruned []Lease
+	for path, l := range db.leases {
+		if l.Expired(now) {
+			pruned = append(pruned, l)
+			delete(db.leases, path)
+		}
+	}
+	return pruned
+}
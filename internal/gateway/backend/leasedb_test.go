@@ -0,0 +1,52 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestMemLeaseDBLockPathRoundTrip(t *testing.T) {
+	db := NewMemLeaseDB()
+	at := time.Now()
+	if err := db.LockPath("repo/path", at); err != nil {
+		t.Fatalf("LockPath: %v", err)
+	}
+
+	locked, err := db.LockedPaths()
+	if err != nil {
+		t.Fatalf("LockedPaths: %v", err)
+	}
+	if got, ok := locked["repo/path"]; !ok || !got.Equal(at) {
+		t.Fatalf("expected repo/path locked at %v, got %v (present: %v)", at, got, ok)
+	}
+
+	if err := db.UnlockPath("repo/path"); err != nil {
+		t.Fatalf("UnlockPath: %v", err)
+	}
+	locked, _ = db.LockedPaths()
+	if _, ok := locked["repo/path"]; ok {
+		t.Fatal("expected repo/path to no longer be locked")
+	}
+}
+
+func TestRestoreLocksLoadsPersistedLocks(t *testing.T) {
+	db := NewMemLeaseDB()
+	at := time.Now().Add(-time.Minute)
+	if err := db.LockPath("repo/path", at); err != nil {
+		t.Fatalf("LockPath: %v", err)
+	}
+
+	pool := NewPool(db, fakeReceiver{}, func() error { return nil })
+
+	pool.mu.Lock()
+	locked := pool.locked["repo/path"]
+	lockedAt := pool.lockedAt["repo/path"]
+	pool.mu.Unlock()
+
+	if !locked {
+		t.Fatal("expected NewPool to restore the persisted lock via RestoreLocks")
+	}
+	if !lockedAt.Equal(at) {
+		t.Fatalf("expected restored lock time %v, got %v", at, lockedAt)
+	}
+}
@@ -0,0 +1,50 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestCommitGroupRespectsDependencyOrder(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	dataPath := "repo.example.org/data"
+	metaPath := "repo.example.org/meta"
+	group, err := pool.NewLeaseGroup("keyA", "repo.example.org", []string{dataPath, metaPath},
+		map[string][]string{metaPath: {dataPath}}, 0)
+	if err != nil {
+		t.Fatalf("NewLeaseGroup: %v", err)
+	}
+
+	err = pool.CommitGroup(group.ID, map[string]receiver.Payload{
+		dataPath: {Data: []byte("data")},
+		metaPath: {Data: []byte("meta")},
+	})
+	if err != nil {
+		t.Fatalf("CommitGroup: %v", err)
+	}
+
+	if _, err := pool.Leases.GetLease(dataPath); err == nil {
+		t.Fatal("expected data lease to be committed and dropped")
+	}
+	if _, err := pool.Leases.GetLease(metaPath); err == nil {
+		t.Fatal("expected meta lease to be committed and dropped")
+	}
+}
+
+func TestNewLeaseGroupRejectsCyclicDependency(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	a := "repo.example.org/a"
+	b := "repo.example.org/b"
+	_, err := pool.NewLeaseGroup("keyA", "repo.example.org", []string{a, b},
+		map[string][]string{a: {b}, b: {a}}, 0)
+	if _, ok := err.(ErrCyclicDependency); !ok {
+		t.Fatalf("expected ErrCyclicDependency, got %v", err)
+	}
+
+	if _, err := pool.Leases.GetLease(a); err == nil {
+		t.Fatal("expected leases to be rolled back after cycle detection")
+	}
+}
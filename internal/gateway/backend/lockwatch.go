@@ -0,0 +1,59 @@
+package backend
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// LockWatchInterval is how often the commit lock watchdog re-checks for
+// paths held locked longer than their configured threshold.
+const LockWatchInterval = 30 * time.Second
+
+// StuckLocks returns the commit-locked paths that have been held for at
+// least threshold, sorted by how long they've been held (longest first).
+// A path locked this long almost always means its receiver process has
+// wedged rather than that a commit is merely slow, since ordinary commits
+// release the lock in well under a minute.
+func (p *Pool) StuckLocks(threshold time.Duration) []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	var stuck []string
+	for path, at := range p.lockedAt {
+		if now.Sub(at) >= threshold {
+			stuck = append(stuck, path)
+		}
+	}
+	sort.Slice(stuck, func(i, j int) bool { return p.lockedAt[stuck[i]].Before(p.lockedAt[stuck[j]]) })
+	return stuck
+}
+
+// LockDeadlockCheck returns a HealthCheck that fails once any path has
+// been held locked by an in-flight commit for at least threshold,
+// flagging a receiver process that has stopped making progress.
+func LockDeadlockCheck(p *Pool, threshold time.Duration) HealthCheck {
+	return func() error {
+		stuck := p.StuckLocks(threshold)
+		if len(stuck) == 0 {
+			return nil
+		}
+		return fmt.Errorf("commit lock held for at least %s on: %s", threshold, strings.Join(stuck, ", "))
+	}
+}
+
+// EnableLockWatchdog turns on the commit lock deadlock watchdog, flagging
+// the gateway unhealthy once a path has been locked for at least
+// threshold. Call StartLockWatchdog to begin running it.
+func (p *Pool) EnableLockWatchdog(threshold time.Duration) {
+	p.LockHealth = NewHealthMonitor(LockDeadlockCheck(p, threshold), LockWatchInterval)
+}
+
+// StartLockWatchdog runs the periodic commit lock deadlock check until
+// stopCh is closed. It should only be called after EnableLockWatchdog,
+// and started once, in its own goroutine.
+func (p *Pool) StartLockWatchdog(stopCh <-chan struct{}) {
+	p.LockHealth.Start(stopCh)
+}
@@ -0,0 +1,49 @@
+package backend
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStuckLocksEmptyWhenNothingLocked(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if stuck := pool.StuckLocks(0); len(stuck) != 0 {
+		t.Fatalf("expected no stuck locks, got %v", stuck)
+	}
+}
+
+func TestStuckLocksReportsPathHeldPastThreshold(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.mu.Lock()
+	pool.locked["repo/path"] = true
+	pool.lockedAt["repo/path"] = time.Now().Add(-time.Hour)
+	pool.mu.Unlock()
+
+	stuck := pool.StuckLocks(time.Minute)
+	if len(stuck) != 1 || stuck[0] != "repo/path" {
+		t.Fatalf("expected [repo/path] to be reported stuck, got %v", stuck)
+	}
+}
+
+func TestLockDeadlockCheckPassesBelowThreshold(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.mu.Lock()
+	pool.locked["repo/path"] = true
+	pool.lockedAt["repo/path"] = time.Now()
+	pool.mu.Unlock()
+
+	if err := LockDeadlockCheck(pool, time.Hour)(); err != nil {
+		t.Fatalf("expected no error below the stuck threshold, got %v", err)
+	}
+}
+
+func TestEnableLockWatchdogStartsHealthy(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.EnableLockWatchdog(time.Minute)
+	if pool.LockHealth == nil {
+		t.Fatal("expected EnableLockWatchdog to set LockHealth")
+	}
+	if !pool.LockHealth.Status().Healthy {
+		t.Fatal("expected the watchdog to start healthy before its first run")
+	}
+}
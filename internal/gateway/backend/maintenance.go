@@ -0,0 +1,93 @@
+package backend
+
+import (
+	"fmt"
+	"time"
+)
+
+// MaintenanceLocker campaigns for exclusive access to a repository for
+// destructive maintenance work: garbage collection, or an operator
+// running cvmfs_server directly against the stratum-0 outside the
+// gateway entirely. It's the same shape as LeaderElector, but keyed by
+// repository and held by whichever caller currently owns it rather than
+// contested for renewal by every instance, and it's checked (not just
+// campaigned for) by CommitLease so an in-flight maintenance operation
+// can block new commits, not just other maintenance operations. A
+// LeaseDB that doesn't implement it (or isn't actually shared across
+// instances) leaves AcquireMaintenanceLock always granting the lock,
+// preserving single-instance behavior.
+type MaintenanceLocker interface {
+	// AcquireMaintenanceLock grants holder exclusive access to repo for
+	// ttl unless another holder already has it and hasn't let it expire.
+	// A holder re-acquiring its own still-valid lock renews it.
+	AcquireMaintenanceLock(repo, holder string, ttl time.Duration) (bool, error)
+
+	// ReleaseMaintenanceLock releases repo's lock if holder is its current
+	// holder. Releasing a lock that isn't held, or is held by someone
+	// else, is a no-op.
+	ReleaseMaintenanceLock(repo, holder string) error
+
+	// MaintenanceLockHolder returns repo's current lock holder, if any
+	// and not yet expired.
+	MaintenanceLockHolder(repo string) (string, bool)
+}
+
+type maintenanceLock struct {
+	holder    string
+	expiresAt time.Time
+}
+
+// ErrMaintenanceLocked is returned when a repository's maintenance lock
+// is held by someone other than the caller.
+type ErrMaintenanceLocked struct {
+	Repository string
+	Holder     string
+}
+
+func (e ErrMaintenanceLocked) Error() string {
+	return fmt.Sprintf("repository %s is locked for maintenance by %q", e.Repository, e.Holder)
+}
+
+// AcquireMaintenanceLock attempts to grant holder exclusive maintenance
+// access to repo for ttl, so gateway-driven GC and an external
+// cvmfs_server operation can't run against the same repository at once.
+// If the configured LeaseDB doesn't implement MaintenanceLocker, it
+// always succeeds, matching IsLeader's single-instance fallback.
+func (p *Pool) AcquireMaintenanceLock(repo, holder string, ttl time.Duration) (bool, error) {
+	locker, ok := p.Leases.(MaintenanceLocker)
+	if !ok {
+		return true, nil
+	}
+	return locker.AcquireMaintenanceLock(repo, holder, ttl)
+}
+
+// ReleaseMaintenanceLock releases repo's maintenance lock if holder is
+// its current owner.
+func (p *Pool) ReleaseMaintenanceLock(repo, holder string) error {
+	locker, ok := p.Leases.(MaintenanceLocker)
+	if !ok {
+		return nil
+	}
+	return locker.ReleaseMaintenanceLock(repo, holder)
+}
+
+// MaintenanceLockHolder returns repo's current maintenance lock holder,
+// if any. It returns false if the configured LeaseDB doesn't implement
+// MaintenanceLocker at all, same as an unheld lock.
+func (p *Pool) MaintenanceLockHolder(repo string) (string, bool) {
+	locker, ok := p.Leases.(MaintenanceLocker)
+	if !ok {
+		return "", false
+	}
+	return locker.MaintenanceLockHolder(repo)
+}
+
+// checkMaintenanceLock returns ErrMaintenanceLocked if repo is currently
+// locked for maintenance, so CommitLease can refuse to start a commit
+// that would race a concurrent GC or manual cvmfs_server operation.
+func (p *Pool) checkMaintenanceLock(repo string) error {
+	if holder, held := p.MaintenanceLockHolder(repo); held {
+		return ErrMaintenanceLocked{Repository: repo, Holder: holder}
+	}
+	return nil
+}
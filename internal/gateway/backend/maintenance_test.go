@@ -0,0 +1,82 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestAcquireMaintenanceLockExcludesOtherHolders(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	granted, err := pool.AcquireMaintenanceLock("myrepo", "gateway-gc", time.Minute)
+	if err != nil || !granted {
+		t.Fatalf("expected first acquisition to succeed, got granted=%v err=%v", granted, err)
+	}
+
+	granted, err = pool.AcquireMaintenanceLock("myrepo", "cvmfs_server", time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireMaintenanceLock: %v", err)
+	}
+	if granted {
+		t.Fatal("expected a second holder to be refused while the first still holds the lock")
+	}
+
+	holder, held := pool.MaintenanceLockHolder("myrepo")
+	if !held || holder != "gateway-gc" {
+		t.Fatalf("got holder=%q held=%v, want gateway-gc/true", holder, held)
+	}
+}
+
+func TestReleaseMaintenanceLockRequiresMatchingHolder(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	if _, err := pool.AcquireMaintenanceLock("myrepo", "gateway-gc", time.Minute); err != nil {
+		t.Fatalf("AcquireMaintenanceLock: %v", err)
+	}
+
+	if err := pool.ReleaseMaintenanceLock("myrepo", "cvmfs_server"); err != nil {
+		t.Fatalf("ReleaseMaintenanceLock: %v", err)
+	}
+	if _, held := pool.MaintenanceLockHolder("myrepo"); !held {
+		t.Fatal("expected the lock to survive a release attempt from a non-holder")
+	}
+
+	if err := pool.ReleaseMaintenanceLock("myrepo", "gateway-gc"); err != nil {
+		t.Fatalf("ReleaseMaintenanceLock: %v", err)
+	}
+	if _, held := pool.MaintenanceLockHolder("myrepo"); held {
+		t.Fatal("expected the lock to be released by its own holder")
+	}
+}
+
+func TestCommitLeaseRefusedWhileMaintenanceLockHeld(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.AcquireMaintenanceLock("myrepo", "cvmfs_server", time.Minute); err != nil {
+		t.Fatalf("AcquireMaintenanceLock: %v", err)
+	}
+
+	_, err = pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")})
+	if _, ok := err.(ErrMaintenanceLocked); !ok {
+		t.Fatalf("expected ErrMaintenanceLocked, got %v", err)
+	}
+}
+
+func TestRunGCFailsFastWhenMaintenanceLockHeldByAnotherHolder(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeGCReceiver{}, func() error { return nil })
+
+	if _, err := pool.AcquireMaintenanceLock("myrepo", "cvmfs_server", time.Minute); err != nil {
+		t.Fatalf("AcquireMaintenanceLock: %v", err)
+	}
+
+	_, err := pool.RunGC("myrepo", receiver.GCOptions{})
+	if _, ok := err.(ErrMaintenanceLocked); !ok {
+		t.Fatalf("expected ErrMaintenanceLocked, got %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type spySink struct {
+	observations []string
+}
+
+func (s *spySink) Count(name string, delta int64, tags map[string]string)   {}
+func (s *spySink) Gauge(name string, value float64, tags map[string]string) {}
+func (s *spySink) Observe(name string, value float64, tags map[string]string) {
+	s.observations = append(s.observations, tags["task"]+":"+tags["repository"])
+}
+
+func TestCommitLeaseObservesPayloadAndCommitDurations(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	sink := &spySink{}
+	pool.Metrics = sink
+
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")}); err != nil {
+		t.Fatalf("CommitLease: %v", err)
+	}
+
+	want := map[string]bool{"payload:repo.example.org": false, "commit:repo.example.org": false}
+	for _, o := range sink.observations {
+		if _, ok := want[o]; ok {
+			want[o] = true
+		}
+	}
+	for o, seen := range want {
+		if !seen {
+			t.Fatalf("expected an observation for %q, got %v", o, sink.observations)
+		}
+	}
+}
@@ -0,0 +1,45 @@
+package backend
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/jobqueue"
+)
+
+// JobTypeMirror is the jobqueue job type used to notify a mirror after a
+// successful commit.
+const JobTypeMirror = "mirror"
+
+var mirrorHTTPClient = &http.Client{Timeout: 30 * time.Second}
+
+type mirrorJobArgs struct {
+	Repo string
+	URL  string
+}
+
+// JobDescription implements jobqueue.Describer.
+func (a mirrorJobArgs) JobDescription() string { return a.Repo }
+
+func (p *Pool) runMirrorJob(job jobqueue.Job) error {
+	args := job.Data.(mirrorJobArgs)
+	resp, err := mirrorHTTPClient.Post(args.URL, "application/json", nil)
+	if err != nil {
+		return fmt.Errorf("mirror trigger for %s failed: %w", args.Repo, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("mirror trigger for %s returned status %d", args.Repo, resp.StatusCode)
+	}
+	return nil
+}
+
+// TriggerMirrors enqueues a background notification to each of urls after
+// a successful commit to repo. Failures are retried by the job queue and
+// do not affect the outcome of the commit that triggered them.
+func (p *Pool) TriggerMirrors(repo string, urls []string) {
+	for _, u := range urls {
+		p.Jobs.Enqueue(JobTypeMirror, mirrorJobArgs{Repo: repo, URL: u})
+	}
+}
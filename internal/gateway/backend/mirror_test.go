@@ -0,0 +1,25 @@
+package backend
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func TestTriggerMirrorsNotifiesEachURL(t *testing.T) {
+	hit := make(chan struct{}, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hit <- struct{}{}
+	}))
+	defer srv.Close()
+
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.TriggerMirrors("repo.example.org", []string{srv.URL})
+
+	select {
+	case <-hit:
+	case <-time.After(time.Second):
+		t.Fatal("mirror endpoint was not notified")
+	}
+}
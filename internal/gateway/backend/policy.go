@@ -0,0 +1,72 @@
+package backend
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// reservedNames are path components that are never valid targets for a
+// lease, regardless of repository-specific configuration.
+var reservedNames = []string{".cvmfs", ".cvmfscatalog", ".cvmfs_status.json"}
+
+// ErrPathRejected is returned when a lease path fails content policy
+// checks, before any lease is recorded or receiver work happens.
+type ErrPathRejected struct {
+	Repository string
+	Path       string
+	Reason     string
+}
+
+func (e ErrPathRejected) Error() string {
+	return fmt.Sprintf("path rejected by content policy: %s (%s)", e.Path, e.Reason)
+}
+
+// ContentPolicy rejects lease paths matching forbidden patterns: control
+// characters, reserved catalog file names, bare top-level writes, and any
+// additional glob patterns configured for a specific repository.
+type ContentPolicy struct {
+	// ForbiddenPatterns returns the extra path.Match glob patterns
+	// forbidden for repo, on top of the built-in rules. Nil-safe: a nil
+	// func means no repository has extra patterns configured.
+	ForbiddenPatterns func(repo string) []string
+}
+
+// NewContentPolicy returns a ContentPolicy with no repository-specific
+// patterns configured.
+func NewContentPolicy() *ContentPolicy {
+	return &ContentPolicy{}
+}
+
+// Check rejects p if it matches a built-in or repository-configured
+// forbidden pattern.
+func (c *ContentPolicy) Check(repo, p string) error {
+	for _, r := range p {
+		if r < 0x20 || r == 0x7f {
+			return ErrPathRejected{Repository: repo, Path: p, Reason: "contains a control character"}
+		}
+	}
+
+	trimmed := strings.TrimPrefix(p, repo)
+	trimmed = strings.Trim(trimmed, "/")
+	if trimmed == "" {
+		return ErrPathRejected{Repository: repo, Path: p, Reason: "top-level writes to the repository root are not allowed"}
+	}
+
+	first := strings.SplitN(trimmed, "/", 2)[0]
+	for _, name := range reservedNames {
+		if first == name {
+			return ErrPathRejected{Repository: repo, Path: p, Reason: fmt.Sprintf("%s is a reserved name", name)}
+		}
+	}
+
+	if c.ForbiddenPatterns == nil {
+		return nil
+	}
+	for _, pattern := range c.ForbiddenPatterns(repo) {
+		if matched, _ := path.Match(pattern, trimmed); matched {
+			return ErrPathRejected{Repository: repo, Path: p, Reason: fmt.Sprintf("matches forbidden pattern %q", pattern)}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+package backend
+
+import "testing"
+
+func TestContentPolicyRejectsTopLevelWrite(t *testing.T) {
+	p := NewContentPolicy()
+	if err := p.Check("repo.example.org", "repo.example.org"); err == nil {
+		t.Fatal("expected top-level write to be rejected")
+	}
+}
+
+func TestContentPolicyRejectsReservedName(t *testing.T) {
+	p := NewContentPolicy()
+	if err := p.Check("repo.example.org", "repo.example.org/.cvmfs/foo"); err == nil {
+		t.Fatal("expected reserved name to be rejected")
+	}
+}
+
+func TestContentPolicyRejectsControlCharacter(t *testing.T) {
+	p := NewContentPolicy()
+	if err := p.Check("repo.example.org", "repo.example.org/a\x00b"); err == nil {
+		t.Fatal("expected control character to be rejected")
+	}
+}
+
+func TestContentPolicyAppliesRepositoryForbiddenPatterns(t *testing.T) {
+	p := NewContentPolicy()
+	p.ForbiddenPatterns = func(repo string) []string {
+		return []string{"secrets/*"}
+	}
+	if err := p.Check("repo.example.org", "repo.example.org/secrets/key.pem"); err == nil {
+		t.Fatal("expected repository-configured pattern to be rejected")
+	}
+	if err := p.Check("repo.example.org", "repo.example.org/public/index.html"); err != nil {
+		t.Fatalf("unexpected rejection: %v", err)
+	}
+}
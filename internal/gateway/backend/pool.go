@@ -0,0 +1,764 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/featureflag"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/jobqueue"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/metrics"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/objectcache"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/scratch"
+)
+
+// DefaultLeaseTime is how long a newly acquired lease remains valid
+// before it must be committed, cancelled, or renewed.
+const DefaultLeaseTime = 10 * time.Minute
+
+// commitJobMinWorkers and commitJobMaxWorkers bound the auto-scaling
+// pool of goroutines available to run background commits, GC runs, and
+// cleanups concurrently.
+const (
+	commitJobMinWorkers = 2
+	commitJobMaxWorkers = 16
+)
+
+// healthCheckInterval is how often the upstream storage health monitor
+// re-runs its check.
+const healthCheckInterval = 30 * time.Second
+
+// JobTypeCommit is the jobqueue job type used for asynchronous commits.
+const JobTypeCommit = "commit"
+
+// busyBackoffBase and maxBusyBackoff bound the exponential retry hint
+// carried by ErrPathBusy: it doubles with each consecutive rejection of
+// the same path, from twice busyBackoffBase for the first rejection
+// (Attempt 1) up to maxBusyBackoff.
+const (
+	busyBackoffBase = 250 * time.Millisecond
+	maxBusyBackoff  = 30 * time.Second
+)
+
+// ErrPathBusy is returned when a path is locked by an in-flight commit
+// and, if the caller used NewLeaseWait, waiting for it to free up timed
+// out. Attempt counts how many consecutive times this path has been
+// rejected as busy, so a client can back off exponentially via
+// RetryAfter instead of retrying at a fixed interval and piling onto a
+// path that's already contended.
+type ErrPathBusy struct {
+	Path    string
+	Attempt int
+}
+
+func (e ErrPathBusy) Error() string {
+	return fmt.Sprintf("path busy: %s", e.Path)
+}
+
+// RetryAfter suggests how long a caller should wait before retrying.
+func (e ErrPathBusy) RetryAfter() time.Duration {
+	d := busyBackoffBase << e.Attempt
+	if d <= 0 || d > maxBusyBackoff {
+		return maxBusyBackoff
+	}
+	return d
+}
+
+// Pool holds the gateway's runtime state: active leases, the commit
+// locks that serialize publishing to a given path, and the background
+// job queue used for long-running operations.
+type Pool struct {
+	Leases    LeaseDB
+	Receiver  receiver.Receiver
+	Jobs      *jobqueue.Queue
+	Health    *HealthMonitor
+	Breaker   *CircuitBreaker
+	Policy    *ContentPolicy
+	Tags      *TagGenerator
+	GCReports *GCReportStore
+	Janitor   *Janitor
+
+	// DiskHealth caches the most recent result of the scratch space disk
+	// watchdog, if EnableDiskWatchdog was called. It is nil until then,
+	// meaning no watchdog is configured.
+	DiskHealth *HealthMonitor
+
+	// LockHealth caches the most recent result of the commit lock
+	// deadlock watchdog, if EnableLockWatchdog was called. It is nil
+	// until then, meaning no watchdog is configured.
+	LockHealth *HealthMonitor
+
+	// History retains completed/cancelled lease records for a retention
+	// period after they leave the LeaseDB, if EnableLeaseHistory was
+	// called. It is nil until then, meaning no history is retained.
+	History *HistoryStore
+
+	// StatePersist periodically snapshots the lease DB to disk, if
+	// EnableStatePersistence was called. It is nil until then, meaning a
+	// restart starts with an empty LeaseDB as before this field existed.
+	StatePersist *StatePersistence
+
+	// ReceiverVersion caches the version reported by Receiver, if it
+	// implements receiver.Versioner, as queried by CheckReceiverVersion
+	// at startup. It's exposed on the health endpoint so an operator can
+	// see at a glance which receiver build a gateway is talking to.
+	ReceiverVersion string
+
+	// ReceiverCapabilities caches the capability list reported alongside
+	// ReceiverVersion, e.g. "diff", "gc", "sha256", "blake3". Consulted
+	// by SupportsDigestAlgorithm to reject a payload's requested digest
+	// algorithm before ever invoking a receiver that can't verify it.
+	ReceiverCapabilities []string
+
+	// FIPSMode restricts SupportsDigestAlgorithm to the FIPS-approved
+	// digest allowlist regardless of what the receiver advertises, so a
+	// receiver capability like "blake3" is never negotiated even though
+	// the receiver itself supports it. It defaults to false, matching
+	// this pool's historical behavior of trusting the receiver's
+	// advertised capabilities outright.
+	FIPSMode bool
+
+	// Metrics receives counter, gauge, and timing observations for
+	// commits and lease activity. It defaults to metrics.NopSink, so it's
+	// always safe to call regardless of whether a backend is configured.
+	Metrics metrics.Sink
+
+	// MirrorLookup returns the mirror URLs to notify after a successful
+	// commit to repo. It is nil-safe: a nil lookup disables mirroring.
+	MirrorLookup func(repo string) []string
+
+	// RepoKnown reports whether repo is still present in the access
+	// configuration. It is nil-safe: a nil lookup disables the janitor's
+	// orphaned-lease detection.
+	RepoKnown func(repo string) bool
+
+	// ProtectedRepos reports whether repo requires a second authorized
+	// key to approve a commit before it's applied, implementing a
+	// four-eyes policy. Nil-safe: a nil ProtectedRepos means every
+	// commit is applied immediately, as before this field existed.
+	// Commits CommitLease holds pending are tracked in Approvals.
+	ProtectedRepos func(repo string) bool
+
+	// Approvals tracks commits CommitLease has held pending a second
+	// approver's sign-off for a repository under ProtectedRepos.
+	Approvals *ApprovalStore
+
+	// FreezeWindows returns repo's configured freeze windows, checked by
+	// NewLease and NewLeaseWait before a lease is granted. It is
+	// nil-safe: a nil FreezeWindows means no repository is ever frozen.
+	FreezeWindows func(repo string) []FreezeWindow
+
+	// LeaseGracePeriod delays cleanup of a stale lease that has staged
+	// upload data (BytesSubmitted > 0) by this long past its expiration,
+	// in case the publisher reconnects and commits or cancels it before
+	// the receiver's staged upload is discarded. A lease with no staged
+	// data is cancelled immediately regardless of this setting. Zero
+	// (the default) disables the grace period, matching the janitor's
+	// behavior before this field existed.
+	LeaseGracePeriod time.Duration
+
+	// LeaseExpiredNotify is called once a stale lease with staged upload
+	// data is actually discarded, after LeaseGracePeriod has elapsed, so
+	// the publisher (or an operator dashboard watching on its behalf)
+	// can learn its transaction was abandoned. It's nil-safe: a nil
+	// LeaseExpiredNotify sends no notification.
+	LeaseExpiredNotify func(LeaseExpiryEvent)
+
+	// Scratch is the local staging area for payload data too large to
+	// hold comfortably in memory while a commit is in flight. It is
+	// nil-safe: a nil Scratch means everything stays in memory, as
+	// before this field existed.
+	Scratch scratch.Store
+
+	// Receipts holds a signed CommitReceipt for every successful commit,
+	// retrievable later by ID.
+	Receipts *ReceiptStore
+
+	// WhitelistSigner re-signs a repository's whitelist, either via the
+	// configured receiver (if it implements receiver.WhitelistSigner) or
+	// an external signing service such as whitelist.HTTPSigner. It's
+	// nil-safe: a nil WhitelistSigner disables ResignWhitelist and
+	// TriggerWhitelistResign entirely.
+	WhitelistSigner receiver.WhitelistSigner
+
+	// WhitelistReports holds the most recently issued whitelist
+	// signature for each repository ResignWhitelist has run against.
+	WhitelistReports *WhitelistReportStore
+
+	// ResignWhitelistAfterCommit re-signs a repository's whitelist after
+	// every successful commit to it, in addition to any schedule set up
+	// via WhitelistSchedule. It defaults to false, since most
+	// deployments only need the periodic schedule.
+	ResignWhitelistAfterCommit bool
+
+	// ReceiptSigner signs a commit receipt's canonical JSON encoding,
+	// returning the ID of the key used and the resulting MAC. It's
+	// nil-safe: a nil ReceiptSigner means receipts are still issued and
+	// stored, just unsigned. Set it to a tokenkey.Store's Sign method.
+	ReceiptSigner func(data []byte) (keyID string, mac []byte)
+
+	// InstanceID identifies this Pool among others sharing the same
+	// LeaseDB, campaigning for leadership of singleton background duties
+	// under that identity. It's generated automatically by NewPool.
+	InstanceID string
+
+	// LeadershipTTL enables leader election for the janitor sweep and
+	// the whitelist re-signing schedule, so that exactly one of several
+	// gateway instances sharing the same LeaseDB runs them at a time.
+	// It's the duration a won campaign remains valid without renewal.
+	// Leave at 0 (the default) to disable election entirely: every
+	// instance runs its own background duties unconditionally, as
+	// before this field existed. Has no effect unless the configured
+	// LeaseDB also implements LeaderElector.
+	LeadershipTTL time.Duration
+
+	// ObjectCache records which object digests are already known to
+	// exist in upstream storage, backing the objects/missing endpoint
+	// that lets a publisher skip re-uploading content the gateway
+	// already has. It's nil-safe: a nil ObjectCache disables the
+	// endpoint entirely rather than reporting every digest missing,
+	// since a deployment that never configured a cache almost certainly
+	// isn't expecting a publisher to rely on its answers.
+	ObjectCache *objectcache.Cache
+
+	// Features is the gateway's runtime feature-flag set, shared with
+	// frontend.API so a flag change made through the admin API takes
+	// effect on both sides immediately. It's nil-safe: a nil Features
+	// means FeatureEnabled reports every flag disabled.
+	Features *featureflag.Set
+
+	mu           sync.Mutex
+	locked       map[string]bool
+	lockedAt     map[string]time.Time
+	groups       map[string]LeaseGroup
+	waiters      *waitQueue
+	busyAttempts map[string]int
+	progress     *progressTracker
+	draining     bool
+
+	scheduled scheduledCommits
+}
+
+// NewPool constructs a Pool backed by the given lease database and
+// receiver. The upstream storage health monitor runs healthCheck on a
+// fixed interval in the background; pass a stopCh via StartHealthMonitor
+// to control its lifetime.
+func NewPool(db LeaseDB, r receiver.Receiver, healthCheck HealthCheck) *Pool {
+	p := &Pool{
+		Leases:           db,
+		Receiver:         r,
+		Jobs:             jobqueue.NewQueue(jobqueue.NewMemStore(), commitJobMinWorkers, commitJobMaxWorkers),
+		Health:           NewHealthMonitor(healthCheck, healthCheckInterval),
+		Breaker:          NewCircuitBreaker(),
+		Policy:           NewContentPolicy(),
+		Tags:             NewTagGenerator(),
+		GCReports:        NewGCReportStore(),
+		Approvals:        NewApprovalStore(),
+		Receipts:         NewReceiptStore(),
+		WhitelistReports: NewWhitelistReportStore(),
+		InstanceID:       newInstanceID(),
+		Metrics:          metrics.NopSink{},
+		locked:           make(map[string]bool),
+		lockedAt:         make(map[string]time.Time),
+		groups:           make(map[string]LeaseGroup),
+		waiters:          newWaitQueue(),
+		busyAttempts:     make(map[string]int),
+		progress:         newProgressTracker(),
+	}
+	p.Jobs.RegisterHandler(JobTypeCommit, p.runCommitJob)
+	p.Jobs.RegisterHandler(JobTypeMirror, p.runMirrorJob)
+	p.Jobs.RegisterHandler(JobTypeGC, p.runGCJob)
+	p.Jobs.RegisterHandler(JobTypeWhitelistResign, p.runWhitelistResignJob)
+	p.Jobs.RegisterHandler(JobTypeLeaseCleanup, p.runLeaseCleanupJob)
+	p.Janitor = NewJanitor(p.sweepIfLeader, janitorInterval)
+	p.RestoreLocks()
+	return p
+}
+
+// RestoreLocks reloads the commit locks recorded in the lease database
+// into the pool's in-memory lock map. Called once at startup, this is
+// what makes a persistent LeaseDB useful: if the gateway process was
+// killed mid-commit, the path it was committing to comes back up already
+// marked busy, instead of silently allowing a second commit to race a
+// receiver process that may still be running against it. A path
+// recovered this way stays locked until an operator confirms it's safe
+// and cancels or clears it; RestoreLocks itself never unlocks anything.
+func (p *Pool) RestoreLocks() {
+	locked, err := p.Leases.LockedPaths()
+	if err != nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for path, at := range locked {
+		p.locked[path] = true
+		p.lockedAt[path] = at
+	}
+}
+
+// CheckReceiverVersion queries the receiver's version, if it implements
+// receiver.Versioner, and refuses to proceed if it's older than
+// receiver.MinSupportedVersion. A receiver that doesn't implement
+// Versioner (an old build predating the capability, or a test fake) is
+// allowed through unchecked, since that mismatch already fails loudly at
+// first commit.
+func (p *Pool) CheckReceiverVersion() error {
+	versioner, ok := p.Receiver.(receiver.Versioner)
+	if !ok {
+		return nil
+	}
+	info, err := versioner.Version()
+	if err != nil {
+		return fmt.Errorf("could not query receiver version: %w", err)
+	}
+	p.ReceiverVersion = info.Version
+	p.ReceiverCapabilities = info.Capabilities
+	if !receiver.Compatible(info.Version) {
+		return fmt.Errorf("receiver version %s is older than the minimum supported version %s", info.Version, receiver.MinSupportedVersion)
+	}
+	return nil
+}
+
+// fipsApprovedDigestAlgorithms lists the digest algorithms SupportsDigestAlgorithm
+// allows when FIPSMode is set, regardless of what the receiver advertises.
+// BLAKE3, notably, is deliberately absent: it isn't a FIPS 140-approved
+// algorithm.
+var fipsApprovedDigestAlgorithms = map[string]bool{
+	"sha256": true,
+	"sha384": true,
+	"sha512": true,
+}
+
+// SupportsDigestAlgorithm reports whether the receiver has advertised
+// support for alg (e.g. "sha256", "blake3") in its capability list. An
+// empty alg is normally always supported, since it means the publisher
+// didn't request a specific algorithm and the receiver falls back to its
+// own (historically SHA-1) default. A receiver that has never reported
+// capabilities (Versioner unimplemented, or CheckReceiverVersion not yet
+// run) is assumed to support only the implicit legacy default, so any
+// explicit request is rejected until capabilities are known. If FIPSMode
+// is set, this implicit legacy default is no longer FIPS-approved, so an
+// empty alg is rejected along with any explicit alg outside
+// fipsApprovedDigestAlgorithms, even if the receiver advertises support
+// for it -- a FIPS-mode publisher must always request an approved
+// algorithm explicitly.
+func (p *Pool) SupportsDigestAlgorithm(alg string) bool {
+	if p.FIPSMode {
+		return fipsApprovedDigestAlgorithms[alg]
+	}
+	if alg == "" {
+		return true
+	}
+	for _, c := range p.ReceiverCapabilities {
+		if c == alg {
+			return true
+		}
+	}
+	return false
+}
+
+// FeatureEnabled reports whether the named feature flag is enabled for
+// repository, consulting repo's override if Features has one and falling
+// back to the flag's global value otherwise. It's nil-safe: a Pool with
+// no Features configured reports every flag disabled, so gating a
+// code path on it is safe even in a deployment that never set one up.
+func (p *Pool) FeatureEnabled(repository, name string) bool {
+	if p.Features == nil {
+		return false
+	}
+	return p.Features.Enabled(repository, name)
+}
+
+// StartHealthMonitor runs the upstream storage health check loop until
+// stopCh is closed. It should be started once, in its own goroutine.
+func (p *Pool) StartHealthMonitor(stopCh <-chan struct{}) {
+	p.Health.Start(stopCh)
+}
+
+// StartJanitor runs the periodic orphaned/stale lease sweep until stopCh
+// is closed. It should be started once, in its own goroutine.
+func (p *Pool) StartJanitor(stopCh <-chan struct{}) {
+	p.Janitor.Start(stopCh)
+}
+
+// EnableDiskWatchdog turns on the scratch space disk watchdog, running
+// check on DiskWatchInterval once StartDiskWatchdog is started. It starts
+// in a healthy state until the first check completes, matching
+// NewHealthMonitor.
+func (p *Pool) EnableDiskWatchdog(check HealthCheck) {
+	p.DiskHealth = NewHealthMonitor(check, DiskWatchInterval)
+}
+
+// StartDiskWatchdog runs the periodic scratch space disk check until
+// stopCh is closed. It should only be called after EnableDiskWatchdog,
+// and started once, in its own goroutine.
+func (p *Pool) StartDiskWatchdog(stopCh <-chan struct{}) {
+	p.DiskHealth.Start(stopCh)
+}
+
+type commitJobArgs struct {
+	Token   string
+	Path    string
+	Payload receiver.Payload
+}
+
+// JobDescription implements jobqueue.Describer.
+func (a commitJobArgs) JobDescription() string { return a.Path }
+
+func (p *Pool) runCommitJob(job jobqueue.Job) error {
+	args := job.Data.(commitJobArgs)
+	_, err := p.CommitLease(args.Token, args.Path, args.Payload)
+	return err
+}
+
+// CommitLeaseAsync enqueues the commit on the background job queue and
+// returns immediately with a job that can be polled for completion via
+// Jobs.Get.
+func (p *Pool) CommitLeaseAsync(token, path string, payload receiver.Payload) (jobqueue.Job, error) {
+	return p.Jobs.Enqueue(JobTypeCommit, commitJobArgs{Token: token, Path: path, Payload: payload})
+}
+
+// NewLease acquires a new lease for keyID on repository/path, provided
+// the path isn't already locked by an in-flight commit. maxBytes caps the
+// cumulative payload size accepted under the lease; 0 means unlimited.
+func (p *Pool) NewLease(keyID, token, repository, path string, maxBytes int64) (Lease, error) {
+	return p.NewLeaseWait(keyID, token, repository, path, maxBytes, 0)
+}
+
+// NewLeaseWait behaves like NewLease, but if the path is currently locked
+// by an in-flight commit and waitTimeout is positive, it queues the
+// request in FIFO order and retries as soon as the holder releases the
+// path, instead of failing immediately with a busy error. This spares
+// clients from having to poll and race each other for a lease that's
+// about to free up, which only gets worse the more of them are waiting on
+// the same path. A waitTimeout of 0 preserves NewLease's old
+// fail-immediately behavior.
+func (p *Pool) NewLeaseWait(keyID, token, repository, path string, maxBytes int64, waitTimeout time.Duration) (Lease, error) {
+	p.mu.Lock()
+	draining := p.draining
+	p.mu.Unlock()
+	if draining {
+		return Lease{}, ErrDraining{}
+	}
+
+	if err := p.Policy.Check(repository, path); err != nil {
+		return Lease{}, err
+	}
+
+	if p.FreezeWindows != nil {
+		if reason, frozen := freezeReason(p.FreezeWindows(repository), time.Now()); frozen {
+			return Lease{}, ErrRepositoryFrozen{Repository: repository, Reason: reason}
+		}
+	}
+
+	deadline := time.Now().Add(waitTimeout)
+	for {
+		p.mu.Lock()
+		busy := p.locked[path]
+		p.mu.Unlock()
+		if !busy {
+			break
+		}
+		remaining := time.Until(deadline)
+		if waitTimeout <= 0 || remaining <= 0 || !p.waiters.wait(path, remaining) {
+			return Lease{}, p.recordBusy(path)
+		}
+	}
+
+	l := Lease{
+		Token:      token,
+		Repository: repository,
+		Path:       path,
+		KeyID:      keyID,
+		Expiration: time.Now().Add(DefaultLeaseTime),
+		MaxBytes:   maxBytes,
+		AcquiredAt: time.Now(),
+	}
+	if err := p.Leases.NewLease(token, l); err != nil {
+		return Lease{}, err
+	}
+	return l, nil
+}
+
+// WaitForPathFree blocks until path is not held by an in-flight commit
+// lock or timeout elapses, whichever comes first, without acquiring a
+// lease itself. It reuses the same FIFO wait-queue NewLeaseWait does, so
+// a long-polling status check and a queued lease request for the same
+// path wake in the order they arrived. It reports whether the path was
+// free by the time it returned.
+func (p *Pool) WaitForPathFree(path string, timeout time.Duration) bool {
+	deadline := time.Now().Add(timeout)
+	for {
+		p.mu.Lock()
+		busy := p.locked[path]
+		p.mu.Unlock()
+		if !busy {
+			return true
+		}
+		remaining := time.Until(deadline)
+		if remaining <= 0 || !p.waiters.wait(path, remaining) {
+			return false
+		}
+	}
+}
+
+// recordBusy increments and returns the count of consecutive times path
+// has been rejected as busy, packaged as an ErrPathBusy so the caller can
+// compute a backoff hint.
+func (p *Pool) recordBusy(path string) ErrPathBusy {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.busyAttempts[path]++
+	return ErrPathBusy{Path: path, Attempt: p.busyAttempts[path]}
+}
+
+// ErrTransactionTooLarge is returned when a payload would push a lease's
+// cumulative submitted bytes past its configured maximum transaction
+// size.
+var ErrTransactionTooLarge = fmt.Errorf("payload exceeds the maximum transaction size for this repository")
+
+// ErrTokenPathMismatch is returned when a submitted token does not match
+// the lease held on the target path.
+var ErrTokenPathMismatch = fmt.Errorf("token does not match the lease held on this path")
+
+// CommitLease applies payload to the leased path and, on success, drops
+// the lease. token must match the token issued when the lease on path
+// was acquired. On success it returns a LeaseSummary of the transaction
+// for publisher tooling to log.
+func (p *Pool) CommitLease(token, path string, payload receiver.Payload) (LeaseSummary, error) {
+	payloadStart := time.Now()
+
+	l, err := p.Leases.GetLease(path)
+	if err != nil {
+		return LeaseSummary{}, err
+	}
+	if l.Token != token {
+		return LeaseSummary{}, ErrTokenPathMismatch
+	}
+	if err := p.checkMaintenanceLock(l.Repository); err != nil {
+		return LeaseSummary{}, err
+	}
+	defer p.observeDuration("payload", l.Repository, payloadStart)
+
+	total, err := p.Leases.AddBytes(path, int64(len(payload.Data)))
+	if err != nil {
+		return LeaseSummary{}, err
+	}
+	if l.MaxBytes > 0 && total > l.MaxBytes {
+		return LeaseSummary{}, ErrTransactionTooLarge
+	}
+
+	if err := p.Breaker.Allow(l.Repository); err != nil {
+		return LeaseSummary{}, err
+	}
+
+	if p.ProtectedRepos != nil && p.ProtectedRepos(l.Repository) {
+		p.Approvals.hold(PendingCommit{
+			Path:        path,
+			Repository:  l.Repository,
+			Token:       token,
+			Payload:     payload,
+			SubmittedBy: l.KeyID,
+			SubmittedAt: time.Now(),
+		})
+		return LeaseSummary{}, ErrApprovalRequired{Path: path}
+	}
+
+	receiverDuration, err := p.commitLocked(l, path, payload)
+	if err != nil {
+		return LeaseSummary{}, err
+	}
+	return LeaseSummary{
+		Path:             path,
+		Repository:       l.Repository,
+		PayloadsReceived: 1,
+		BytesSubmitted:   total,
+		Duration:         time.Since(l.AcquiredAt),
+		ReceiverDuration: receiverDuration,
+	}, nil
+}
+
+// ApproveCommit applies a commit that CommitLease held pending because it
+// targeted a repository under ProtectedRepos, requiring approverKeyID to
+// differ from the key that originally submitted the payload so a single
+// key can't satisfy its own four-eyes requirement. On success it returns
+// a LeaseSummary of the transaction for publisher tooling to log.
+func (p *Pool) ApproveCommit(path, approverKeyID string) (LeaseSummary, error) {
+	pending, ok := p.Approvals.get(path)
+	if !ok {
+		return LeaseSummary{}, ErrApprovalNotFound{Path: path}
+	}
+	if approverKeyID == pending.SubmittedBy {
+		return LeaseSummary{}, ErrSelfApproval{Path: path}
+	}
+
+	l, err := p.Leases.GetLease(path)
+	if err != nil {
+		return LeaseSummary{}, err
+	}
+	if l.Token != pending.Token {
+		return LeaseSummary{}, ErrTokenPathMismatch
+	}
+
+	receiverDuration, err := p.commitLocked(l, path, pending.Payload)
+	if err != nil {
+		return LeaseSummary{}, err
+	}
+	p.Approvals.clear(path)
+	return LeaseSummary{
+		Path:             path,
+		Repository:       l.Repository,
+		PayloadsReceived: 1,
+		BytesSubmitted:   l.BytesSubmitted,
+		Duration:         time.Since(l.AcquiredAt),
+		ReceiverDuration: receiverDuration,
+	}, nil
+}
+
+// commitLocked runs the part of a commit shared by CommitLease's
+// immediate path and ApproveCommit's held-then-approved path: tag
+// reservation, the exclusive commit lock, the receiver invocation, and
+// the bookkeeping that follows a successful commit. It returns how long
+// the receiver itself took to apply the commit.
+func (p *Pool) commitLocked(l Lease, path string, payload receiver.Payload) (time.Duration, error) {
+	if payload.Tag == "" {
+		payload.Tag = p.Tags.Generate(l.Repository, l.KeyID)
+	} else if err := p.Tags.Reserve(l.Repository, payload.Tag); err != nil {
+		return 0, err
+	}
+
+	p.mu.Lock()
+	if p.locked[path] {
+		p.mu.Unlock()
+		return 0, fmt.Errorf("path busy: %s", path)
+	}
+	lockedAt := time.Now()
+	p.locked[path] = true
+	p.lockedAt[path] = lockedAt
+	p.mu.Unlock()
+	if err := p.Leases.LockPath(path, lockedAt); err != nil {
+		p.mu.Lock()
+		delete(p.locked, path)
+		delete(p.lockedAt, path)
+		p.mu.Unlock()
+		return 0, err
+	}
+	defer func() {
+		p.mu.Lock()
+		delete(p.locked, path)
+		delete(p.lockedAt, path)
+		delete(p.busyAttempts, path)
+		p.mu.Unlock()
+		p.Leases.UnlockPath(path)
+		p.waiters.release(path)
+	}()
+
+	tags := map[string]string{"repository": l.Repository}
+	commitStart := time.Now()
+	commitErr := p.Receiver.Commit(l.Repository, l.Path, payload)
+	receiverDuration := time.Since(commitStart)
+	p.observeDuration("commit", l.Repository, commitStart)
+	if commitErr != nil {
+		p.Breaker.RecordFailure(l.Repository)
+		p.Metrics.Count("cvmfs_gateway_commits_failed_total", 1, tags)
+		return receiverDuration, commitErr
+	}
+	p.Breaker.RecordSuccess(l.Repository)
+	p.Metrics.Count("cvmfs_gateway_commits_total", 1, tags)
+
+	if err := p.Leases.CommitLease(path); err != nil {
+		return receiverDuration, err
+	}
+	if p.History != nil {
+		p.History.record(LeaseHistoryEntry{
+			Path:       path,
+			Repository: l.Repository,
+			KeyID:      l.KeyID,
+			Outcome:    "committed",
+			RecordedAt: time.Now(),
+		})
+	}
+	if p.MirrorLookup != nil {
+		p.TriggerMirrors(l.Repository, p.MirrorLookup(l.Repository))
+	}
+	if p.ResignWhitelistAfterCommit {
+		p.TriggerWhitelistResign(l.Repository)
+	}
+	p.issueReceipt(l, path, payload)
+	return receiverDuration, nil
+}
+
+// issueReceipt records a CommitReceipt for a just-applied commit,
+// signing it via ReceiptSigner if one is configured. It never fails the
+// commit: a receipt is proof-of-publish, not a precondition for it.
+func (p *Pool) issueReceipt(l Lease, path string, payload receiver.Payload) {
+	var oldHash, newHash string
+	if sr, ok := p.Receiver.(receiver.StatsReporter); ok {
+		if stats, ok := sr.LastStats(path); ok {
+			oldHash, newHash = stats.OldRootHash, stats.NewRootHash
+		}
+	}
+
+	receipt := CommitReceipt{
+		ID:          newReceiptID(),
+		Repository:  l.Repository,
+		Path:        path,
+		Tag:         payload.Tag,
+		OldRootHash: oldHash,
+		NewRootHash: newHash,
+		KeyID:       l.KeyID,
+		CommittedAt: time.Now(),
+	}
+	if p.ReceiptSigner != nil {
+		if body, err := receipt.signingBody(); err == nil {
+			receipt.SignerKeyID, receipt.Signature = p.ReceiptSigner(body)
+		}
+	}
+	p.Receipts.save(receipt)
+}
+
+// observeDuration reports the time elapsed since start as a
+// "cvmfs_gateway_task_seconds" histogram sample, labelled by task
+// ("payload", "commit", "gc") and repository, so operators can identify
+// repositories whose catalogs have grown into bottlenecks for a given
+// stage of the publish pipeline.
+func (p *Pool) observeDuration(task, repository string, start time.Time) {
+	p.Metrics.Observe("cvmfs_gateway_task_seconds", time.Since(start).Seconds(), map[string]string{
+		"task":       task,
+		"repository": repository,
+	})
+}
+
+// CancelLease drops a lease without committing it, returning a
+// LeaseSummary of what it had accumulated for publisher tooling to log.
+func (p *Pool) CancelLease(path string) (LeaseSummary, error) {
+	l, err := p.Leases.GetLease(path)
+	if err != nil {
+		return LeaseSummary{}, err
+	}
+	if err := p.Leases.CancelLease(path); err != nil {
+		return LeaseSummary{}, err
+	}
+	p.Approvals.clear(path)
+	if p.History != nil {
+		p.History.record(LeaseHistoryEntry{
+			Path:       path,
+			Repository: l.Repository,
+			KeyID:      l.KeyID,
+			Outcome:    "cancelled",
+			RecordedAt: time.Now(),
+		})
+	}
+
+	summary := LeaseSummary{
+		Path:           path,
+		Repository:     l.Repository,
+		BytesSubmitted: l.BytesSubmitted,
+		Duration:       time.Since(l.AcquiredAt),
+	}
+	if l.BytesSubmitted > 0 {
+		summary.PayloadsReceived = 1
+	}
+	return summary, nil
+}
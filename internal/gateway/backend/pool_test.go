@@ -0,0 +1,51 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeReceiver struct{}
+
+func (fakeReceiver) Commit(repository, path string, payload receiver.Payload) error {
+	return nil
+}
+
+func TestCommitLeaseRejectsMismatchedToken(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	_, err = pool.CommitLease("wrong-token", lease.Path, receiver.Payload{})
+	if err != ErrTokenPathMismatch {
+		t.Fatalf("expected ErrTokenPathMismatch, got %v", err)
+	}
+}
+
+func TestCommitLeaseTracksBytesSubmitted(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	if _, err := pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")}); err != nil {
+		t.Fatalf("CommitLease: %v", err)
+	}
+}
+
+func TestCommitLeaseRejectsOversizedTransaction(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 4)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	_, err = pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")})
+	if err != ErrTransactionTooLarge {
+		t.Fatalf("expected ErrTransactionTooLarge, got %v", err)
+	}
+}
@@ -0,0 +1,64 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// UploadProgress snapshots an in-flight payload upload's observed
+// throughput, so a lease listing can report a live bytes-per-second rate
+// and, once combined with the lease's MaxBytes, an estimated time to
+// completion, instead of just the cumulative bytes submitted so far.
+type UploadProgress struct {
+	BytesPerSecond float64
+	UpdatedAt      time.Time
+}
+
+// progressTracker records the most recently observed upload rate for
+// each path with a payload upload currently in flight.
+type progressTracker struct {
+	mu    sync.Mutex
+	rates map[string]UploadProgress
+}
+
+func newProgressTracker() *progressTracker {
+	return &progressTracker{rates: make(map[string]UploadProgress)}
+}
+
+func (t *progressTracker) record(path string, bytesPerSecond float64) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.rates[path] = UploadProgress{BytesPerSecond: bytesPerSecond, UpdatedAt: time.Now()}
+}
+
+func (t *progressTracker) clear(path string) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	delete(t.rates, path)
+}
+
+func (t *progressTracker) get(path string) (UploadProgress, bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	p, ok := t.rates[path]
+	return p, ok
+}
+
+// RecordUploadProgress records the current observed upload throughput for
+// path, so it can be surfaced on the lease listing while the upload is
+// still in flight.
+func (p *Pool) RecordUploadProgress(path string, bytesPerSecond float64) {
+	p.progress.record(path, bytesPerSecond)
+}
+
+// ClearUploadProgress drops any recorded upload throughput for path, once
+// its upload has finished.
+func (p *Pool) ClearUploadProgress(path string) {
+	p.progress.clear(path)
+}
+
+// UploadProgress returns the most recently observed upload throughput for
+// path, if a payload upload is currently in flight for it.
+func (p *Pool) UploadProgress(path string) (UploadProgress, bool) {
+	return p.progress.get(path)
+}
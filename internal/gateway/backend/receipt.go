@@ -0,0 +1,85 @@
+package backend
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+)
+
+// CommitReceipt is gateway-signed proof that a commit was applied,
+// retrievable later by ID so a publisher can attach it to their own
+// release records. Signature covers the JSON encoding of every other
+// field, so a receipt can be verified independently of the gateway that
+// issued it, given its signing key.
+type CommitReceipt struct {
+	ID          string    `json:"id"`
+	Repository  string    `json:"repository"`
+	Path        string    `json:"path"`
+	Tag         string    `json:"tag"`
+	OldRootHash string    `json:"old_root_hash,omitempty"`
+	NewRootHash string    `json:"new_root_hash,omitempty"`
+	KeyID       string    `json:"key_id"`
+	CommittedAt time.Time `json:"committed_at"`
+
+	// SignerKeyID and Signature are populated when Pool.ReceiptSigner is
+	// set. They're left zero for a Pool that doesn't sign receipts,
+	// matching the rest of this field's callers.
+	SignerKeyID string `json:"signer_key_id,omitempty"`
+	Signature   []byte `json:"signature,omitempty"`
+}
+
+// signingBody returns the bytes CommitReceipt's signature covers: the
+// JSON encoding of the receipt with SignerKeyID and Signature left
+// unset, so a verifier can reproduce the exact same bytes from a
+// receipt's other fields.
+func (r CommitReceipt) signingBody() ([]byte, error) {
+	r.SignerKeyID = ""
+	r.Signature = nil
+	return json.Marshal(r)
+}
+
+// ReceiptStore keeps every commit receipt issued, retrievable by ID or,
+// for the most recent one, by path.
+type ReceiptStore struct {
+	mu            sync.Mutex
+	receipts      map[string]CommitReceipt
+	latestForPath map[string]string
+}
+
+// NewReceiptStore returns an empty ReceiptStore.
+func NewReceiptStore() *ReceiptStore {
+	return &ReceiptStore{
+		receipts:      make(map[string]CommitReceipt),
+		latestForPath: make(map[string]string),
+	}
+}
+
+func (s *ReceiptStore) save(r CommitReceipt) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.receipts[r.ID] = r
+	s.latestForPath[r.Path] = r.ID
+}
+
+// Get returns the receipt issued for id, if any.
+func (s *ReceiptStore) Get(id string) (CommitReceipt, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	r, ok := s.receipts[id]
+	return r, ok
+}
+
+// Latest returns the most recently issued receipt for path, if any.
+func (s *ReceiptStore) Latest(path string) (CommitReceipt, bool) {
+	s.mu.Lock()
+	id, ok := s.latestForPath[path]
+	s.mu.Unlock()
+	if !ok {
+		return CommitReceipt{}, false
+	}
+	return s.Get(id)
+}
+
+func newReceiptID() string {
+	return randomHex(8)
+}
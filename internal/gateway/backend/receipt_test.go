@@ -0,0 +1,79 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestCommitLeaseIssuesReceipt(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello"), Tag: "rev-1"}); err != nil {
+		t.Fatalf("CommitLease: %v", err)
+	}
+
+	receipt, ok := pool.Receipts.Latest(lease.Path)
+	if !ok {
+		t.Fatal("expected a receipt to be issued for the committed path")
+	}
+	if receipt.Repository != "myrepo" || receipt.Tag != "rev-1" || receipt.KeyID != "keyA" {
+		t.Fatalf("unexpected receipt: %+v", receipt)
+	}
+	if receipt.ID == "" {
+		t.Fatal("expected a non-empty receipt ID")
+	}
+
+	byID, ok := pool.Receipts.Get(receipt.ID)
+	if !ok || byID.ID != receipt.ID {
+		t.Fatalf("expected Get(%q) to return the same receipt", receipt.ID)
+	}
+}
+
+func TestCommitLeaseSignsReceiptWhenSignerConfigured(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.ReceiptSigner = func(data []byte) (string, []byte) {
+		return "signing-key-1", []byte("fake-mac")
+	}
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")}); err != nil {
+		t.Fatalf("CommitLease: %v", err)
+	}
+
+	receipt, ok := pool.Receipts.Latest(lease.Path)
+	if !ok {
+		t.Fatal("expected a receipt to be issued")
+	}
+	if receipt.SignerKeyID != "signing-key-1" || string(receipt.Signature) != "fake-mac" {
+		t.Fatalf("expected the receipt to carry the signer's key ID and MAC, got %+v", receipt)
+	}
+}
+
+func TestApproveCommitIssuesReceipt(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.ProtectedRepos = func(repo string) bool { return true }
+
+	lease, err := pool.NewLease("keyA", "tok1", "myrepo", "myrepo/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	pool.CommitLease("tok1", lease.Path, receiver.Payload{Data: []byte("hello")})
+
+	if _, ok := pool.Receipts.Latest(lease.Path); ok {
+		t.Fatal("expected no receipt before approval")
+	}
+	if _, err := pool.ApproveCommit(lease.Path, "keyB"); err != nil {
+		t.Fatalf("ApproveCommit: %v", err)
+	}
+	if _, ok := pool.Receipts.Latest(lease.Path); !ok {
+		t.Fatal("expected a receipt to be issued once the pending commit was approved")
+	}
+}
@@ -0,0 +1,74 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// ErrNoScheduledCommit is returned when there is no pending scheduled
+// commit for a path.
+type ErrNoScheduledCommit struct {
+	Path string
+}
+
+func (e ErrNoScheduledCommit) Error() string {
+	return fmt.Sprintf("no scheduled commit for path: %s", e.Path)
+}
+
+// scheduledCommits tracks commits queued for a future time, holding their
+// lease until then. It is embedded into Pool.
+type scheduledCommits struct {
+	mu     sync.Mutex
+	timers map[string]*time.Timer
+}
+
+// ScheduleCommit holds path's lease until at, then runs the commit on the
+// background job queue as CommitLeaseAsync would. If at is already in the
+// past, the commit runs immediately. The lease is not otherwise touched,
+// so it can still be cancelled with CancelScheduledCommit or CancelLease
+// before it fires.
+func (p *Pool) ScheduleCommit(token, path string, payload receiver.Payload, at time.Time) error {
+	if _, err := p.Leases.GetLease(path); err != nil {
+		return err
+	}
+
+	delay := time.Until(at)
+	if delay <= 0 {
+		_, err := p.CommitLeaseAsync(token, path, payload)
+		return err
+	}
+
+	p.scheduled.mu.Lock()
+	defer p.scheduled.mu.Unlock()
+	if p.scheduled.timers == nil {
+		p.scheduled.timers = make(map[string]*time.Timer)
+	}
+	if _, exists := p.scheduled.timers[path]; exists {
+		return fmt.Errorf("a commit is already scheduled for path: %s", path)
+	}
+
+	p.scheduled.timers[path] = time.AfterFunc(delay, func() {
+		p.scheduled.mu.Lock()
+		delete(p.scheduled.timers, path)
+		p.scheduled.mu.Unlock()
+		p.CommitLeaseAsync(token, path, payload)
+	})
+	return nil
+}
+
+// CancelScheduledCommit stops a pending scheduled commit for path without
+// affecting the lease itself.
+func (p *Pool) CancelScheduledCommit(path string) error {
+	p.scheduled.mu.Lock()
+	defer p.scheduled.mu.Unlock()
+	timer, ok := p.scheduled.timers[path]
+	if !ok {
+		return ErrNoScheduledCommit{Path: path}
+	}
+	timer.Stop()
+	delete(p.scheduled.timers, path)
+	return nil
+}
@@ -0,0 +1,53 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestScheduleCommitRunsAtTheScheduledTime(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	if err := pool.ScheduleCommit(lease.Token, lease.Path, receiver.Payload{}, time.Now().Add(20*time.Millisecond)); err != nil {
+		t.Fatalf("ScheduleCommit: %v", err)
+	}
+
+	if _, err := pool.Leases.GetLease(lease.Path); err != nil {
+		t.Fatal("expected lease to still be held before the scheduled time")
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		if _, err := pool.Leases.GetLease(lease.Path); err != nil {
+			return
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	t.Fatal("expected scheduled commit to run and drop the lease")
+}
+
+func TestCancelScheduledCommitPreventsExecution(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	if err := pool.ScheduleCommit(lease.Token, lease.Path, receiver.Payload{}, time.Now().Add(50*time.Millisecond)); err != nil {
+		t.Fatalf("ScheduleCommit: %v", err)
+	}
+	if err := pool.CancelScheduledCommit(lease.Path); err != nil {
+		t.Fatalf("CancelScheduledCommit: %v", err)
+	}
+
+	time.Sleep(100 * time.Millisecond)
+	if _, err := pool.Leases.GetLease(lease.Path); err != nil {
+		t.Fatal("expected lease to remain held after cancelling the scheduled commit")
+	}
+}
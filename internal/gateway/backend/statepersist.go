@@ -0,0 +1,116 @@
+package backend
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/atomicfile"
+)
+
+// statePersistInterval is how often the state persistence loop
+// re-snapshots the lease DB to disk.
+const statePersistInterval = 30 * time.Second
+
+// StatePersistence periodically snapshots the pool's lease DB to a file
+// on disk, so a restarted gateway process can reload open leases and
+// their staged upload progress (Lease.BytesSubmitted) instead of a
+// publisher's in-flight transaction suddenly looking like an unknown
+// lease token after every restart. It reuses the same Snapshot type and
+// Backup/Restore methods handleLeaseBackup and handleLeaseRestore expose
+// for a manual host migration; this just does it automatically, to a
+// local file, on a schedule.
+type StatePersistence struct {
+	pool *Pool
+	path string
+
+	mu      sync.Mutex
+	lastAt  time.Time
+	lastErr error
+}
+
+// EnableStatePersistence turns on periodic lease DB snapshotting to
+// path. Call LoadPersistedState once at startup, before serving traffic,
+// to restore whatever was last written there; call
+// StartStatePersistence afterward to keep it up to date going forward.
+func (p *Pool) EnableStatePersistence(path string) {
+	p.StatePersist = &StatePersistence{pool: p, path: path}
+}
+
+// LoadPersistedState restores the lease DB from a snapshot file
+// previously written by StartStatePersistence, if one exists at path.
+// It's not an error for path to not exist yet: that's the ordinary case
+// on a gateway's very first start.
+func (p *Pool) LoadPersistedState(path string) error {
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("backend: could not read persisted lease state: %w", err)
+	}
+	var snap Snapshot
+	if err := json.Unmarshal(buf, &snap); err != nil {
+		return fmt.Errorf("backend: could not decode persisted lease state: %w", err)
+	}
+	return p.Restore(snap)
+}
+
+// StartStatePersistence runs the periodic snapshot loop until stopCh is
+// closed, writing one final snapshot before returning so a graceful
+// shutdown doesn't lose whatever changed since the last tick. It should
+// only be called after EnableStatePersistence, and started once, in its
+// own goroutine.
+func (p *Pool) StartStatePersistence(stopCh <-chan struct{}) {
+	sp := p.StatePersist
+	if sp == nil {
+		return
+	}
+	ticker := time.NewTicker(statePersistInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			sp.snapshot()
+		case <-stopCh:
+			sp.snapshot()
+			return
+		}
+	}
+}
+
+func (sp *StatePersistence) snapshot() {
+	err := sp.write()
+	sp.mu.Lock()
+	sp.lastAt = time.Now()
+	sp.lastErr = err
+	sp.mu.Unlock()
+}
+
+func (sp *StatePersistence) write() error {
+	snap, err := sp.pool.Backup()
+	if err != nil {
+		return err
+	}
+	buf, err := json.Marshal(snap)
+	if err != nil {
+		return fmt.Errorf("backend: could not encode lease state for persistence: %w", err)
+	}
+	if err := atomicfile.Write(sp.path, buf, 0600); err != nil {
+		return fmt.Errorf("backend: could not write persisted lease state: %w", err)
+	}
+	return nil
+}
+
+// LastResult reports when StatePersistence last attempted a snapshot and
+// the error it hit, if any, so the health endpoint can surface a
+// persistence backend that's silently failing (a full disk, a revoked
+// permission) instead of an operator only discovering it after a
+// restart loses lease state.
+func (sp *StatePersistence) LastResult() (time.Time, error) {
+	sp.mu.Lock()
+	defer sp.mu.Unlock()
+	return sp.lastAt, sp.lastErr
+}
@@ -0,0 +1,54 @@
+package backend
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadPersistedStateNoFileIsNotAnError(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if err := pool.LoadPersistedState(filepath.Join(t.TempDir(), "missing.json")); err != nil {
+		t.Fatalf("LoadPersistedState: %v", err)
+	}
+}
+
+func TestStatePersistenceSnapshotsAndReloads(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "leases.json")
+
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if _, err := pool.NewLease("key1", "token1", "repo.example.org", "repo.example.org/a", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	pool.EnableStatePersistence(path)
+	if err := pool.StatePersist.write(); err != nil {
+		t.Fatalf("write: %v", err)
+	}
+
+	restored := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if err := restored.LoadPersistedState(path); err != nil {
+		t.Fatalf("LoadPersistedState: %v", err)
+	}
+
+	l, err := restored.Leases.GetLease("repo.example.org/a")
+	if err != nil {
+		t.Fatalf("GetLease after reload: %v", err)
+	}
+	if l.KeyID != "key1" {
+		t.Fatalf("unexpected reloaded lease: %+v", l)
+	}
+}
+
+func TestStatePersistenceLastResultReportsErrors(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.EnableStatePersistence(filepath.Join(t.TempDir(), "does-not-exist", "leases.json"))
+
+	pool.StatePersist.snapshot()
+
+	at, err := pool.StatePersist.LastResult()
+	if at.IsZero() {
+		t.Fatal("expected LastResult to record an attempt time")
+	}
+	if err == nil {
+		t.Fatal("expected an error writing to a nonexistent directory")
+	}
+}
@@ -0,0 +1,101 @@
+package backend
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultTagTemplate is used for repositories with no configured tag
+// naming template.
+const defaultTagTemplate = "{date}-{seq}"
+
+// ErrDuplicateTag is returned when a commit's tag has already been used
+// for the same repository.
+type ErrDuplicateTag struct {
+	Repository string
+	Tag        string
+}
+
+func (e ErrDuplicateTag) Error() string {
+	return fmt.Sprintf("tag %q has already been used for repository %s", e.Tag, e.Repository)
+}
+
+// TagGenerator fills in an auto-generated tag name for commits that don't
+// specify one, and enforces tag uniqueness per repository. Templates
+// support the placeholders {date} (YYYYMMDD), {seq} (a per-repository
+// counter), and {key} (the committing key's ID).
+type TagGenerator struct {
+	// Template returns the tag naming template configured for repo, or
+	// "" to use defaultTagTemplate. Nil-safe: a nil Template always uses
+	// the default.
+	Template func(repo string) string
+
+	mu        sync.Mutex
+	sequences map[string]int
+	used      map[string]map[string]bool
+}
+
+// NewTagGenerator returns an empty TagGenerator using defaultTagTemplate
+// for every repository.
+func NewTagGenerator() *TagGenerator {
+	return &TagGenerator{
+		sequences: make(map[string]int),
+		used:      make(map[string]map[string]bool),
+	}
+}
+
+// Generate produces the next auto-generated tag for repo and reserves it,
+// so a concurrent commit cannot be assigned the same name.
+func (g *TagGenerator) Generate(repo, keyID string) string {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	template := defaultTagTemplate
+	if g.Template != nil {
+		if t := g.Template(repo); t != "" {
+			template = t
+		}
+	}
+
+	for {
+		g.sequences[repo]++
+		tag := render(template, repo, keyID, g.sequences[repo])
+		if g.reserveLocked(repo, tag) {
+			return tag
+		}
+	}
+}
+
+// Reserve records tag as used for repo, returning ErrDuplicateTag if it
+// was already used.
+func (g *TagGenerator) Reserve(repo, tag string) error {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+	if !g.reserveLocked(repo, tag) {
+		return ErrDuplicateTag{Repository: repo, Tag: tag}
+	}
+	return nil
+}
+
+func (g *TagGenerator) reserveLocked(repo, tag string) bool {
+	if g.used[repo] == nil {
+		g.used[repo] = make(map[string]bool)
+	}
+	if g.used[repo][tag] {
+		return false
+	}
+	g.used[repo][tag] = true
+	return true
+}
+
+func render(template, repo, keyID string, seq int) string {
+	r := strings.NewReplacer(
+		"{date}", time.Now().Format("20060102"),
+		"{seq}", strconv.Itoa(seq),
+		"{key}", keyID,
+	)
+	return r.Replace(template)
+}
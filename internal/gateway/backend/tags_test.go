@@ -0,0 +1,31 @@
+package backend
+
+import "testing"
+
+func TestTagGeneratorProducesUniqueSequentialTags(t *testing.T) {
+	g := NewTagGenerator()
+	a := g.Generate("repo.example.org", "keyA")
+	b := g.Generate("repo.example.org", "keyA")
+	if a == b {
+		t.Fatalf("expected distinct tags, got %q twice", a)
+	}
+}
+
+func TestTagGeneratorAppliesRepositoryTemplate(t *testing.T) {
+	g := NewTagGenerator()
+	g.Template = func(repo string) string { return "release-{seq}" }
+	tag := g.Generate("repo.example.org", "keyA")
+	if tag != "release-1" {
+		t.Fatalf("expected release-1, got %q", tag)
+	}
+}
+
+func TestReserveRejectsDuplicateTag(t *testing.T) {
+	g := NewTagGenerator()
+	if err := g.Reserve("repo.example.org", "v1"); err != nil {
+		t.Fatalf("Reserve: %v", err)
+	}
+	if err := g.Reserve("repo.example.org", "v1"); err == nil {
+		t.Fatal("expected duplicate tag to be rejected")
+	}
+}
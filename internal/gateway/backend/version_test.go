@@ -0,0 +1,40 @@
+package backend
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeVersionedReceiver struct {
+	fakeReceiver
+	version string
+}
+
+func (r fakeVersionedReceiver) Version() (receiver.VersionInfo, error) {
+	return receiver.VersionInfo{Version: r.version}, nil
+}
+
+func TestCheckReceiverVersionCachesCompatibleVersion(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeVersionedReceiver{version: "2.10.0"}, func() error { return nil })
+	if err := pool.CheckReceiverVersion(); err != nil {
+		t.Fatalf("CheckReceiverVersion: %v", err)
+	}
+	if pool.ReceiverVersion != "2.10.0" {
+		t.Fatalf("expected cached version 2.10.0, got %q", pool.ReceiverVersion)
+	}
+}
+
+func TestCheckReceiverVersionRejectsIncompatibleVersion(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeVersionedReceiver{version: "1.0.0"}, func() error { return nil })
+	if err := pool.CheckReceiverVersion(); err == nil {
+		t.Fatal("expected an incompatible receiver version to be rejected")
+	}
+}
+
+func TestCheckReceiverVersionSkipsNonVersioningReceivers(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if err := pool.CheckReceiverVersion(); err != nil {
+		t.Fatalf("expected a non-versioning receiver to be allowed through, got %v", err)
+	}
+}
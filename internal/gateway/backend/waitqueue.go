@@ -0,0 +1,76 @@
+package backend
+
+import (
+	"sync"
+	"time"
+)
+
+// waitQueue tracks, per path, the goroutines blocked in NewLeaseWait for a
+// busy path to free up. Waiters are woken in FIFO order so that a burst of
+// publishers targeting the same path queue up instead of polling and
+// racing each other for the lease the instant it's released.
+type waitQueue struct {
+	mu      sync.Mutex
+	waiters map[string][]chan struct{}
+}
+
+func newWaitQueue() *waitQueue {
+	return &waitQueue{waiters: make(map[string][]chan struct{})}
+}
+
+// wait blocks until path is released, timeout elapses, or the caller is
+// removed from the queue, whichever comes first. It reports whether the
+// path was actually released in time.
+func (q *waitQueue) wait(path string, timeout time.Duration) bool {
+	ch := make(chan struct{})
+	q.mu.Lock()
+	q.waiters[path] = append(q.waiters[path], ch)
+	q.mu.Unlock()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case <-ch:
+		return true
+	case <-timer.C:
+		q.remove(path, ch)
+		return false
+	}
+}
+
+// release wakes the longest-waiting goroutine queued for path, if any.
+func (q *waitQueue) release(path string) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waiters := q.waiters[path]
+	if len(waiters) == 0 {
+		return
+	}
+	next := waiters[0]
+	waiters = waiters[1:]
+	if len(waiters) == 0 {
+		delete(q.waiters, path)
+	} else {
+		q.waiters[path] = waiters
+	}
+	close(next)
+}
+
+// remove drops ch from path's wait list without waking it, used when a
+// waiter times out before being released.
+func (q *waitQueue) remove(path string, ch chan struct{}) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	waiters := q.waiters[path]
+	for i, w := range waiters {
+		if w == ch {
+			waiters = append(waiters[:i], waiters[i+1:]...)
+			break
+		}
+	}
+	if len(waiters) == 0 {
+		delete(q.waiters, path)
+	} else {
+		q.waiters[path] = waiters
+	}
+}
@@ -0,0 +1,170 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// blockingReceiver holds Commit open until release is closed, so tests can
+// deterministically observe a path while it's locked.
+type blockingReceiver struct {
+	release chan struct{}
+}
+
+func (r blockingReceiver) Commit(repository, path string, payload receiver.Payload) error {
+	<-r.release
+	return nil
+}
+
+func TestNewLeaseFailsImmediatelyWithoutWait(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(NewMemLeaseDB(), blockingReceiver{release: release}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	commitDone := make(chan struct{})
+	go func() {
+		pool.CommitLease("tok1", lease.Path, receiver.Payload{})
+		close(commitDone)
+	}()
+	waitUntilLocked(t, pool, lease.Path)
+
+	if _, err := pool.NewLease("keyB", "tok2", "repo.example.org", "repo.example.org/a", 0); err == nil {
+		t.Fatal("expected a busy path to be rejected immediately without a wait timeout")
+	}
+
+	close(release)
+	<-commitDone
+}
+
+func TestNewLeaseWaitGrantsLeaseOnceHolderReleases(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(NewMemLeaseDB(), blockingReceiver{release: release}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	commitDone := make(chan struct{})
+	go func() {
+		pool.CommitLease("tok1", lease.Path, receiver.Payload{})
+		close(commitDone)
+	}()
+	waitUntilLocked(t, pool, lease.Path)
+
+	waited := make(chan error, 1)
+	go func() {
+		_, err := pool.NewLeaseWait("keyB", "tok2", "repo.example.org", "repo.example.org/a", 0, time.Second)
+		waited <- err
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-commitDone
+
+	select {
+	case err := <-waited:
+		if err != nil {
+			t.Fatalf("NewLeaseWait: %v", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("NewLeaseWait was not granted the lease after the holder released it")
+	}
+}
+
+func TestNewLeaseWaitTimesOutIfPathStaysBusy(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	pool := NewPool(NewMemLeaseDB(), blockingReceiver{release: release}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	go pool.CommitLease("tok1", lease.Path, receiver.Payload{})
+	waitUntilLocked(t, pool, lease.Path)
+
+	start := time.Now()
+	if _, err := pool.NewLeaseWait("keyB", "tok2", "repo.example.org", "repo.example.org/a", 0, 50*time.Millisecond); err == nil {
+		t.Fatal("expected NewLeaseWait to time out while the path stays busy")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected NewLeaseWait to wait out its timeout, returned after %s", elapsed)
+	}
+}
+
+func TestWaitForPathFreeReturnsTrueOnceHolderReleases(t *testing.T) {
+	release := make(chan struct{})
+	pool := NewPool(NewMemLeaseDB(), blockingReceiver{release: release}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	commitDone := make(chan struct{})
+	go func() {
+		pool.CommitLease("tok1", lease.Path, receiver.Payload{})
+		close(commitDone)
+	}()
+	waitUntilLocked(t, pool, lease.Path)
+
+	waited := make(chan bool, 1)
+	go func() {
+		waited <- pool.WaitForPathFree(lease.Path, time.Second)
+	}()
+
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	<-commitDone
+
+	select {
+	case free := <-waited:
+		if !free {
+			t.Fatal("expected WaitForPathFree to report the path free once the holder released it")
+		}
+	case <-time.After(time.Second):
+		t.Fatal("WaitForPathFree did not return after the holder released the path")
+	}
+}
+
+func TestWaitForPathFreeTimesOutIfPathStaysBusy(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	pool := NewPool(NewMemLeaseDB(), blockingReceiver{release: release}, func() error { return nil })
+	lease, err := pool.NewLease("keyA", "tok1", "repo.example.org", "repo.example.org/a", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	go pool.CommitLease("tok1", lease.Path, receiver.Payload{})
+	waitUntilLocked(t, pool, lease.Path)
+
+	start := time.Now()
+	if free := pool.WaitForPathFree(lease.Path, 50*time.Millisecond); free {
+		t.Fatal("expected WaitForPathFree to time out while the path stays busy")
+	}
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Fatalf("expected WaitForPathFree to wait out its timeout, returned after %s", elapsed)
+	}
+}
+
+// waitUntilLocked polls until CommitLease has taken the path lock, so
+// tests don't race the goroutine that runs it.
+func waitUntilLocked(t *testing.T, p *Pool, path string) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		p.mu.Lock()
+		locked := p.locked[path]
+		p.mu.Unlock()
+		if locked {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("path %s was never locked", path)
+}
@@ -0,0 +1,167 @@
+package backend
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/jobqueue"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// JobTypeWhitelistResign is the jobqueue job type used to re-sign a
+// repository's whitelist after a commit or on WhitelistSchedule's
+// interval.
+const JobTypeWhitelistResign = "whitelist-resign"
+
+// WhitelistReportStore holds the most recently issued whitelist
+// signature for each repository, so ResignWhitelist's result can be
+// inspected after the fact and WhitelistSchedule can flag repositories
+// whose signatures are approaching expiry.
+type WhitelistReportStore struct {
+	mu    sync.Mutex
+	infos map[string]receiver.WhitelistInfo
+}
+
+// NewWhitelistReportStore returns an empty WhitelistReportStore.
+func NewWhitelistReportStore() *WhitelistReportStore {
+	return &WhitelistReportStore{infos: make(map[string]receiver.WhitelistInfo)}
+}
+
+func (s *WhitelistReportStore) save(info receiver.WhitelistInfo) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.infos[info.Repository] = info
+}
+
+// Get returns the most recently issued whitelist signature for repo, if
+// any.
+func (s *WhitelistReportStore) Get(repo string) (receiver.WhitelistInfo, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	info, ok := s.infos[repo]
+	return info, ok
+}
+
+// ExpiringWithin returns every tracked repository whose whitelist
+// signature expires within threshold of now, for an alerting loop to
+// warn about before the signature actually lapses.
+func (s *WhitelistReportStore) ExpiringWithin(threshold time.Duration, now time.Time) []receiver.WhitelistInfo {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var expiring []receiver.WhitelistInfo
+	for _, info := range s.infos {
+		if info.Expiry.Sub(now) <= threshold {
+			expiring = append(expiring, info)
+		}
+	}
+	return expiring
+}
+
+type whitelistResignJobArgs struct {
+	Repo string
+}
+
+// JobDescription implements jobqueue.Describer.
+func (a whitelistResignJobArgs) JobDescription() string { return a.Repo }
+
+func (p *Pool) runWhitelistResignJob(job jobqueue.Job) error {
+	args := job.Data.(whitelistResignJobArgs)
+	_, err := p.ResignWhitelist(args.Repo)
+	return err
+}
+
+// ResignWhitelist re-signs repo's whitelist via WhitelistSigner and
+// records the result in WhitelistReports. It requires WhitelistSigner to
+// be configured, either with a receiver that implements
+// receiver.WhitelistSigner or an external signing service such as
+// whitelist.HTTPSigner.
+func (p *Pool) ResignWhitelist(repo string) (receiver.WhitelistInfo, error) {
+	if p.WhitelistSigner == nil {
+		return receiver.WhitelistInfo{}, fmt.Errorf("backend: no whitelist signer configured")
+	}
+	info, err := p.WhitelistSigner.ResignWhitelist(repo)
+	if err != nil {
+		return receiver.WhitelistInfo{}, err
+	}
+	p.WhitelistReports.save(info)
+	return info, nil
+}
+
+// TriggerWhitelistResign enqueues an asynchronous whitelist re-signature
+// for repo. It's nil-safe: a nil WhitelistSigner disables it, as before
+// this field existed.
+func (p *Pool) TriggerWhitelistResign(repo string) {
+	if p.WhitelistSigner == nil {
+		return
+	}
+	p.Jobs.Enqueue(JobTypeWhitelistResign, whitelistResignJobArgs{Repo: repo})
+}
+
+// WhitelistSchedule periodically re-signs every known repository's
+// whitelist, and calls OnExpiring for any repository whose signature is
+// within AlertBefore of expiring, so an operator can be paged before a
+// stale whitelist actually blocks publishers.
+type WhitelistSchedule struct {
+	pool  *Pool
+	repos func() []string
+
+	// AlertBefore is how far ahead of a whitelist signature's expiry
+	// OnExpiring is called for it. It defaults to 0, which disables
+	// expiry alerting (repos are still re-signed on Interval).
+	AlertBefore time.Duration
+
+	// OnExpiring is called, once per sweep, for every repository whose
+	// whitelist signature expires within AlertBefore. Nil-safe: a nil
+	// OnExpiring means expiry alerting is disabled.
+	OnExpiring func(info receiver.WhitelistInfo)
+
+	interval time.Duration
+}
+
+// NewWhitelistSchedule returns a schedule that re-signs every repository
+// reported by repos every interval, via pool.WhitelistSigner.
+func NewWhitelistSchedule(pool *Pool, repos func() []string, interval time.Duration) *WhitelistSchedule {
+	return &WhitelistSchedule{pool: pool, repos: repos, interval: interval}
+}
+
+// Start runs the periodic resign loop until stopCh is closed. It should
+// be started once, in its own goroutine.
+func (w *WhitelistSchedule) Start(stopCh <-chan struct{}) {
+	w.runOnce()
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			w.runOnce()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// whitelistLeaderKey namespaces the whitelist schedule's leadership
+// campaign from any other singleton duty electing under the same
+// LeaseDB.
+const whitelistLeaderKey = "whitelist-schedule"
+
+func (w *WhitelistSchedule) runOnce() {
+	if w.repos == nil {
+		return
+	}
+	if !w.pool.IsLeader(whitelistLeaderKey) {
+		return
+	}
+	for _, repo := range w.repos() {
+		w.pool.TriggerWhitelistResign(repo)
+		if w.AlertBefore <= 0 || w.OnExpiring == nil {
+			continue
+		}
+		if info, ok := w.pool.WhitelistReports.Get(repo); ok {
+			if info.Expiry.Sub(time.Now()) <= w.AlertBefore {
+				w.OnExpiring(info)
+			}
+		}
+	}
+}
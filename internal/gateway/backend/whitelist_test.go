@@ -0,0 +1,81 @@
+package backend
+
+import (
+	"testing"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeWhitelistSigner struct {
+	info receiver.WhitelistInfo
+	err  error
+}
+
+func (f fakeWhitelistSigner) ResignWhitelist(repository string) (receiver.WhitelistInfo, error) {
+	if f.err != nil {
+		return receiver.WhitelistInfo{}, f.err
+	}
+	info := f.info
+	info.Repository = repository
+	return info, nil
+}
+
+func TestResignWhitelistSavesReport(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	expiry := time.Now().Add(30 * 24 * time.Hour)
+	pool.WhitelistSigner = fakeWhitelistSigner{info: receiver.WhitelistInfo{Expiry: expiry}}
+
+	info, err := pool.ResignWhitelist("repo.example.org")
+	if err != nil {
+		t.Fatalf("ResignWhitelist: %v", err)
+	}
+	if !info.Expiry.Equal(expiry) {
+		t.Fatalf("got expiry %v, want %v", info.Expiry, expiry)
+	}
+
+	saved, ok := pool.WhitelistReports.Get("repo.example.org")
+	if !ok || !saved.Expiry.Equal(expiry) {
+		t.Fatalf("expected the report to be saved, got %+v, ok=%v", saved, ok)
+	}
+}
+
+func TestResignWhitelistFailsWithoutSigner(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	if _, err := pool.ResignWhitelist("repo.example.org"); err == nil {
+		t.Fatal("expected an error when no WhitelistSigner is configured")
+	}
+}
+
+func TestWhitelistReportStoreExpiringWithin(t *testing.T) {
+	store := NewWhitelistReportStore()
+	now := time.Now()
+	store.save(receiver.WhitelistInfo{Repository: "soon", Expiry: now.Add(time.Hour)})
+	store.save(receiver.WhitelistInfo{Repository: "later", Expiry: now.Add(30 * 24 * time.Hour)})
+
+	expiring := store.ExpiringWithin(2*time.Hour, now)
+	if len(expiring) != 1 || expiring[0].Repository != "soon" {
+		t.Fatalf("expected only the soon-to-expire repository, got %+v", expiring)
+	}
+}
+
+func TestWhitelistScheduleResignsEveryKnownRepo(t *testing.T) {
+	pool := NewPool(NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	pool.WhitelistSigner = fakeWhitelistSigner{info: receiver.WhitelistInfo{Expiry: time.Now().Add(time.Hour)}}
+
+	schedule := NewWhitelistSchedule(pool, func() []string { return []string{"a", "b"} }, time.Hour)
+	schedule.runOnce()
+
+	deadline := time.Now().Add(time.Second)
+	for _, repo := range []string{"a", "b"} {
+		for {
+			if _, ok := pool.WhitelistReports.Get(repo); ok {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("expected %s to have been re-signed", repo)
+			}
+			time.Sleep(time.Millisecond)
+		}
+	}
+}
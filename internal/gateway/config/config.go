@@ -0,0 +1,359 @@
+// Package config loads the gateway's own runtime configuration (network
+// and storage settings, as opposed to the access configuration).
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// Config holds the gateway's top-level runtime settings.
+type Config struct {
+	Port           int    `json:"port"`
+	AccessConfig   string `json:"access_config"`
+	ReceiverBinary string `json:"receiver_binary"`
+	StoragePath    string `json:"storage_path"`
+
+	// ListenSocket, if set, makes the gateway listen on a Unix domain
+	// socket at this path instead of a TCP port. Useful when the gateway
+	// sits behind a local reverse proxy that already terminates TCP.
+	ListenSocket string `json:"listen_socket,omitempty"`
+
+	// ListenAddresses, if set, binds the API to these explicit host:port
+	// addresses instead of the implicit ":<Port>" bind, letting a site
+	// run IPv4-only, IPv6-only, or dual-stack (e.g.
+	// ["0.0.0.0:8080", "[::]:8080"]) by naming each address it wants
+	// bound. It's ignored when ListenSocket is set or a systemd socket
+	// was activated. These addresses also serve the /metrics scrape
+	// endpoint when MetricsBackend is "prometheus", since it shares the
+	// API's listener; "statsd" and "graphite" push to MetricsAddress
+	// instead. There is no separate metrics listener to configure.
+	ListenAddresses []string `json:"listen_addresses,omitempty"`
+
+	// ReceiverAffinity pins each repository to its own receiver process
+	// instance instead of sharing one across all repositories, so a
+	// receiver's per-repository caches stay warm and commits for
+	// different repositories never contend for the same process.
+	ReceiverAffinity bool `json:"receiver_affinity,omitempty"`
+
+	// MetricsBackend selects where gateway instrumentation goes:
+	// "prometheus" (the default) exposes an in-process /metrics scrape
+	// endpoint; "statsd" and "graphite" push observations to
+	// MetricsAddress instead.
+	MetricsBackend string `json:"metrics_backend,omitempty"`
+
+	// MetricsAddress is the host:port of the StatsD or Graphite carbon
+	// receiver to push metrics to. Ignored when MetricsBackend is
+	// "prometheus".
+	MetricsAddress string `json:"metrics_address,omitempty"`
+
+	// SigningKeyPath is where the lease token signing secret is
+	// persisted, so a restart doesn't silently invalidate every
+	// outstanding token. The file is created on first startup if it
+	// doesn't already exist.
+	SigningKeyPath string `json:"signing_key_path,omitempty"`
+
+	// VirtualHosts maps a Host header to the path of an access
+	// configuration file that should govern requests for it, letting one
+	// gateway process serve multiple independent sets of keys and
+	// repositories. Requests whose Host header isn't listed here fall
+	// back to AccessConfig. Leave empty to disable virtual hosting.
+	VirtualHosts map[string]string `json:"virtual_hosts,omitempty"`
+
+	// ShadowURL, if set, is the base URL of a shadow gateway instance
+	// that a sample of non-destructive API traffic is mirrored to for
+	// testing a new version against production load. Leave empty to
+	// disable mirroring.
+	ShadowURL string `json:"shadow_url,omitempty"`
+
+	// ShadowFraction is the fraction (0.0-1.0) of mirrorable requests
+	// sent to ShadowURL. Ignored when ShadowURL is empty.
+	ShadowFraction float64 `json:"shadow_fraction,omitempty"`
+
+	// ScratchDir, if set, is a local directory the gateway uses to spool
+	// payload data too large to hold comfortably in memory while a
+	// commit is in flight. Leave empty to keep everything in memory.
+	ScratchDir string `json:"scratch_dir,omitempty"`
+
+	// MinScratchFreeBytes, if set alongside ScratchDir, enables a
+	// background watchdog that flags the gateway unhealthy once the
+	// scratch filesystem's free space drops below this many bytes,
+	// catching a filling disk before it aborts a commit mid-flight.
+	// Leave at 0 to disable the watchdog.
+	MinScratchFreeBytes int64 `json:"min_scratch_free_bytes,omitempty"`
+
+	// EncryptScratchAtRest, if set alongside ScratchDir, encrypts every
+	// payload staged to disk with a per-repository AES-256 key before
+	// writing it, and decrypts it again when the receiver reads it back.
+	// It's for sites with data-at-rest requirements on gateway hosts
+	// shared with other tenants. Leave false to keep staged payloads
+	// stored as plain bytes, as before this field existed.
+	EncryptScratchAtRest bool `json:"encrypt_scratch_at_rest,omitempty"`
+
+	// ScratchKeyPath is where the per-repository scratch encryption keys
+	// are persisted when EncryptScratchAtRest is set. Defaults to
+	// /etc/cvmfs/gateway/scratch.keys.
+	ScratchKeyPath string `json:"scratch_key_path,omitempty"`
+
+	// AccessFragmentsDir, if set, is a directory of per-repository
+	// access configuration fragments (e.g. /etc/cvmfs/gateway/repos.d/)
+	// merged on top of AccessConfig and watched for additions, edits,
+	// and removals so repositories can be managed independently without
+	// a gateway restart. Leave empty to disable.
+	AccessFragmentsDir string `json:"access_fragments_dir,omitempty"`
+
+	// GlobalIngestBytesPerSecond, if set, caps the aggregate payload
+	// upload throughput across every publisher, on top of whatever
+	// per-key cap individual keys are configured with. Leave at 0 for
+	// no gateway-wide cap.
+	GlobalIngestBytesPerSecond int64 `json:"global_ingest_bytes_per_second,omitempty"`
+
+	// CommitLockStuckSeconds, if set, enables a background watchdog that
+	// flags the gateway unhealthy once a path has been held locked by an
+	// in-flight commit for at least this many seconds, catching a
+	// wedged receiver process instead of letting it hold the path
+	// forever. Leave at 0 to disable the watchdog.
+	CommitLockStuckSeconds int `json:"commit_lock_stuck_seconds,omitempty"`
+
+	// ReceiverCommitTimeoutSeconds, if set, kills a receiver process's
+	// commit invocation once it has run for this many seconds, failing
+	// the commit with a timeout error instead of leaving it (and,
+	// invoked through the job queue, one of its workers) blocked on a
+	// hung receiver forever. Leave at 0 to disable the limit.
+	ReceiverCommitTimeoutSeconds int `json:"receiver_commit_timeout_seconds,omitempty"`
+
+	// ReceiverPayloadTimeoutSeconds is ReceiverCommitTimeoutSeconds's
+	// counterpart for a single small-object submission via the
+	// objects/ fast path, set separately since it's expected to
+	// complete far faster than a full commit. Leave at 0 to disable.
+	ReceiverPayloadTimeoutSeconds int `json:"receiver_payload_timeout_seconds,omitempty"`
+
+	// MinUploadBytesPerSecond, if set, evicts a payload upload whose
+	// observed throughput stays below this rate for at least
+	// SlowClientEvictionSeconds, freeing the worker and socket a dead or
+	// crawling upload — one stuck behind a NAT hairpin timeout, say —
+	// would otherwise hold open indefinitely. Leave at 0 to disable
+	// eviction.
+	MinUploadBytesPerSecond int64 `json:"min_upload_bytes_per_second,omitempty"`
+
+	// SlowClientEvictionSeconds is how long an upload's throughput may
+	// stay below MinUploadBytesPerSecond before it's evicted. It has no
+	// effect unless MinUploadBytesPerSecond is also set.
+	SlowClientEvictionSeconds int `json:"slow_client_eviction_seconds,omitempty"`
+
+	// EnablePprof exposes Go's runtime profiler under
+	// /api/v1/debug/pprof/, protected by the same admin authorization as
+	// the rest of the admin API. Leave false in normal production use;
+	// profiling data (stack traces, heap contents) can be sensitive.
+	EnablePprof bool `json:"enable_pprof,omitempty"`
+
+	// MaxInlineObjectBytes, if set, enables the small-file fast path at
+	// /api/v1/repos/{name}/objects: a publisher may submit an individual
+	// object up to this many bytes directly to upstream storage without
+	// opening a lease or paying pack-processing overhead. Leave at 0 to
+	// disable the fast path entirely.
+	MaxInlineObjectBytes int64 `json:"max_inline_object_bytes,omitempty"`
+
+	// CanaryFeatures lists the feature names currently enabled only for
+	// repositories marked "canary" in the access configuration,
+	// reported to a canary repository's capabilities endpoint so its
+	// publisher tooling can opt into using them ahead of a general
+	// rollout. Leave empty when there is no feature currently being
+	// canaried.
+	CanaryFeatures []string `json:"canary_features,omitempty"`
+
+	// FeatureFlags seeds the gateway's runtime feature-flag set with
+	// initial global values at startup, e.g. to ship a flag already
+	// enabled by default. Flags not listed here start out disabled until
+	// set through the admin API. Leave empty to start with every flag
+	// disabled.
+	FeatureFlags map[string]bool `json:"feature_flags,omitempty"`
+
+	// PayloadSpoolThresholdBytes, if set, spools a submitted payload body
+	// to ScratchDir once it exceeds this many bytes instead of letting
+	// an in-memory buffer keep growing, to stabilize memory usage under
+	// concurrent large uploads. Leave at 0 to keep every payload
+	// entirely in memory, as before this field existed. Has no effect
+	// unless ScratchDir is also set.
+	PayloadSpoolThresholdBytes int64 `json:"payload_spool_threshold_bytes,omitempty"`
+
+	// RequireAuthForReads, if set, requires a valid key's bearer token on
+	// the read-only lease listing, stats, and health endpoints, exactly
+	// as write endpoints already require one. Leave false to keep those
+	// endpoints open to unauthenticated callers such as a monitoring
+	// dashboard, as they have always been; an unauthenticated caller's
+	// lease listing has its key IDs redacted either way.
+	RequireAuthForReads bool `json:"require_auth_for_reads,omitempty"`
+
+	// DebugRecorderCapacity, if positive, enables the request/response
+	// recording ring buffer at /api/v1/debug/recorder, retaining at
+	// most this many exchanges for keys or repositories an operator
+	// enrolls, to debug a client protocol issue without a packet
+	// capture. Leave at 0 to disable the feature (and its endpoint)
+	// entirely.
+	DebugRecorderCapacity int `json:"debug_recorder_capacity,omitempty"`
+
+	// LeaseHistoryRetentionSeconds, if set, keeps a record of every
+	// committed or cancelled lease for this many seconds after it leaves
+	// the active lease database, queryable at /api/v1/leases/history for
+	// auditing and stats. Leave at 0 to disable history retention.
+	LeaseHistoryRetentionSeconds int `json:"lease_history_retention_seconds,omitempty"`
+
+	// LeaseStatePath, if set, enables periodic on-disk snapshotting of
+	// the lease database to this file, restored at startup so a
+	// publisher's open lease (and any bytes already staged toward it)
+	// survives a gateway restart instead of coming back to an
+	// invalid-lease error and having to restart the transaction from
+	// scratch. Leave empty to disable persistence, matching this
+	// gateway's historical behavior of keeping the lease DB in memory
+	// alone.
+	LeaseStatePath string `json:"lease_state_path,omitempty"`
+
+	// ConcurrencyLimits caps how many requests may be in flight at once
+	// per endpoint class: "new-lease", "payload", "commit", "admin". A
+	// class absent from the map, or set to 0, is left uncapped. Leave
+	// the map empty to disable concurrency limiting entirely.
+	ConcurrencyLimits map[string]int `json:"concurrency_limits,omitempty"`
+
+	// ClockSkewToleranceSeconds is added to a lease token's claimed
+	// expiry before it's rejected, so a publisher host whose clock runs
+	// slightly ahead of the gateway's doesn't see its tokens expire
+	// early. A rejected token's response carries the gateway's own
+	// server_time so the client can detect and correct real drift.
+	// Leave at 0 to enforce the token's nominal expiry exactly.
+	ClockSkewToleranceSeconds int `json:"clock_skew_tolerance_seconds,omitempty"`
+
+	// FIPSMode restricts the gateway's cryptography to FIPS-approved
+	// algorithms: lease token signing already uses HMAC-SHA256, so this
+	// mainly narrows payload digest algorithm negotiation (see
+	// backend.Pool.SupportsDigestAlgorithm) to the FIPS-approved digests,
+	// rejecting a receiver's advertised support for anything else (e.g.
+	// BLAKE3) even if the receiver itself supports it. It's required by
+	// some government-lab deployments; enabling it is logged at startup.
+	// Note this narrows algorithm choice within the pure-Go crypto this
+	// gateway already uses, not certified module linkage — that depends
+	// on the Go toolchain and platform the binary is built and run with.
+	FIPSMode bool `json:"fips_mode,omitempty"`
+
+	// WhitelistSigningServiceURL, if set, delegates whitelist re-signing
+	// to an external signing service at this base URL instead of asking
+	// the configured receiver to re-sign whitelists itself. Leave empty
+	// to sign via the receiver, when it supports it.
+	WhitelistSigningServiceURL string `json:"whitelist_signing_service_url,omitempty"`
+
+	// WhitelistResignIntervalSeconds enables the periodic whitelist
+	// re-signing schedule, re-signing every known repository's
+	// whitelist this often. Leave at 0 to disable the schedule (commits
+	// can still trigger a re-sign; see ResignWhitelistAfterCommit).
+	WhitelistResignIntervalSeconds int `json:"whitelist_resign_interval_seconds,omitempty"`
+
+	// ResignWhitelistAfterCommit re-signs a repository's whitelist after
+	// every successful commit to it, in addition to any interval set by
+	// WhitelistResignIntervalSeconds.
+	ResignWhitelistAfterCommit bool `json:"resign_whitelist_after_commit,omitempty"`
+
+	// WhitelistExpiryAlertSeconds, if positive, logs a warning for any
+	// repository whose whitelist signature is within this many seconds
+	// of expiring, checked on the same interval as
+	// WhitelistResignIntervalSeconds.
+	WhitelistExpiryAlertSeconds int `json:"whitelist_expiry_alert_seconds,omitempty"`
+
+	// InstanceID identifies this gateway instance among others sharing
+	// the same access configuration and LeaseDB in a load-balanced
+	// deployment, carried inside every lease token this instance issues
+	// (see tokenkey.Claims.Instance) so a later payload submission can
+	// be routed back here. Leave empty to have one generated randomly at
+	// startup, which is fine for a single-instance deployment but
+	// useless for InstancePeers, since peers need a stable ID to name.
+	InstanceID string `json:"instance_id,omitempty"`
+
+	// InstancePeers maps another instance's InstanceID to its base URL.
+	// A commit whose lease token names an instance other than this one
+	// is redirected there instead of being serviced locally against
+	// upload progress this instance doesn't have. Leave empty to
+	// service every commit locally regardless of which instance issued
+	// its token, as before this field existed.
+	InstancePeers map[string]string `json:"instance_peers,omitempty"`
+
+	// LeadershipTTLSeconds enables leader election for the janitor sweep
+	// and the whitelist re-signing schedule, so that of several gateway
+	// instances sharing the same LeaseDB, only the elected leader runs
+	// them. It's the duration a won campaign remains valid without
+	// renewal. Leave at 0 (the default) to disable election: every
+	// instance runs its own background duties unconditionally, which is
+	// correct for a single-instance deployment or one whose LeaseDB
+	// doesn't implement backend.LeaderElector.
+	LeadershipTTLSeconds int `json:"leadership_ttl_seconds,omitempty"`
+
+	// S3Endpoint, if set, switches commit handling to the experimental
+	// receiver-less ingestion path: instead of handing payloads to the
+	// cvmfs_receiver binary named by ReceiverBinary, the gateway unpacks
+	// them itself and writes objects directly to this S3-compatible
+	// endpoint (see the ingest and objectstore packages). Leave empty to
+	// keep using ReceiverBinary for everything, as before this field
+	// existed.
+	S3Endpoint string `json:"s3_endpoint,omitempty"`
+
+	// S3Region is the region to sign S3 requests for, e.g. "us-east-1".
+	// Required if S3Endpoint is set; most S3-compatible stores accept
+	// any non-empty value even when they don't have real regions.
+	S3Region string `json:"s3_region,omitempty"`
+
+	// S3Bucket names the bucket objects are written to. Required if
+	// S3Endpoint is set.
+	S3Bucket string `json:"s3_bucket,omitempty"`
+
+	// S3AccessKeyID and S3SecretAccessKey authenticate the gateway to
+	// the object store. Required if S3Endpoint is set.
+	S3AccessKeyID     string `json:"s3_access_key_id,omitempty"`
+	S3SecretAccessKey string `json:"s3_secret_access_key,omitempty"`
+
+	// S3ObjectPrefix is prepended to every object's digest to form its
+	// key. Leave empty to key objects by digest alone.
+	S3ObjectPrefix string `json:"s3_object_prefix,omitempty"`
+
+	// S3DelegateCommitsToReceiver, if true, still runs ReceiverBinary
+	// after every object in a commit's pack has been written to the
+	// object store, so its catalog-management logic keeps running in a
+	// hybrid deployment. Leave false for an object-store-only
+	// deployment that has no external receiver to delegate to.
+	S3DelegateCommitsToReceiver bool `json:"s3_delegate_commits_to_receiver,omitempty"`
+
+	// ObjectCacheCapacity enables the object existence cache backing the
+	// /api/v1/repos/{name}/objects/missing endpoint: publishers can ask
+	// it which objects of a prospective pack are already known to exist
+	// upstream and skip re-uploading them. It's the maximum number of
+	// digests the cache retains, evicting the least recently used once
+	// full. Leave at 0 (the default) to disable the cache and the
+	// endpoint entirely.
+	ObjectCacheCapacity int `json:"object_cache_capacity,omitempty"`
+}
+
+// Default returns the gateway's built-in default configuration.
+func Default() Config {
+	return Config{
+		Port:           4929,
+		AccessConfig:   "/etc/cvmfs/gateway/access.conf",
+		ReceiverBinary: "/usr/bin/cvmfs_receiver",
+		StoragePath:    "/srv/cvmfs",
+		MetricsBackend: "prometheus",
+		SigningKeyPath: "/etc/cvmfs/gateway/signing.key",
+		ScratchKeyPath: "/etc/cvmfs/gateway/scratch.keys",
+	}
+}
+
+// ReadConfig loads a Config from a JSON file, falling back to defaults
+// for any field not present.
+func ReadConfig(path string) (Config, error) {
+	cfg := Default()
+
+	buf, err := os.ReadFile(path)
+	if err != nil {
+		return Config{}, fmt.Errorf("could not read gateway config: %w", err)
+	}
+	if err := json.Unmarshal(buf, &cfg); err != nil {
+		return Config{}, fmt.Errorf("could not parse gateway config: %w", err)
+	}
+	return cfg, nil
+}
@@ -0,0 +1,70 @@
+package featureflag
+
+import "testing"
+
+func TestEnabledFallsBackToGlobalValue(t *testing.T) {
+	s := NewSet()
+	s.SetGlobal("streaming-uploads", true)
+
+	if !s.Enabled("repo.example.org", "streaming-uploads") {
+		t.Fatal("expected the global value to apply when no repo override is set")
+	}
+	if !s.Enabled("", "streaming-uploads") {
+		t.Fatal("expected the global value to apply with no repository")
+	}
+}
+
+func TestEnabledPrefersRepoOverride(t *testing.T) {
+	s := NewSet()
+	s.SetGlobal("async-commits", false)
+	s.SetForRepo("canary.example.org", "async-commits", true)
+
+	if !s.Enabled("canary.example.org", "async-commits") {
+		t.Fatal("expected the repo override to enable the flag")
+	}
+	if s.Enabled("other.example.org", "async-commits") {
+		t.Fatal("expected an unrelated repo to keep the disabled global value")
+	}
+}
+
+func TestClearForRepoFallsBackToGlobal(t *testing.T) {
+	s := NewSet()
+	s.SetGlobal("async-commits", true)
+	s.SetForRepo("canary.example.org", "async-commits", false)
+	s.ClearForRepo("canary.example.org", "async-commits")
+
+	if !s.Enabled("canary.example.org", "async-commits") {
+		t.Fatal("expected clearing the override to fall back to the global value")
+	}
+}
+
+func TestUnrecognizedFlagIsDisabled(t *testing.T) {
+	s := NewSet()
+	if s.Enabled("repo.example.org", "nonexistent") {
+		t.Fatal("expected an unrecognized flag to default to disabled")
+	}
+}
+
+func TestForRepoMergesOverridesOntoGlobalSnapshot(t *testing.T) {
+	s := NewSet()
+	s.SetGlobal("a", true)
+	s.SetGlobal("b", false)
+	s.SetForRepo("canary.example.org", "b", true)
+
+	got := s.ForRepo("canary.example.org")
+	if !got["a"] || !got["b"] {
+		t.Fatalf("expected both flags enabled for the canary repo, got %+v", got)
+	}
+
+	global := s.Global()
+	if global["b"] {
+		t.Fatal("expected the repo override not to leak back into the global snapshot")
+	}
+}
+
+func TestNewSetFromDefaultsSeedsGlobalValues(t *testing.T) {
+	s := NewSetFromDefaults(map[string]bool{"streaming-uploads": true})
+	if !s.Enabled("", "streaming-uploads") {
+		t.Fatal("expected the seeded default to be enabled")
+	}
+}
@@ -0,0 +1,405 @@
+// Package frontend implements the gateway's public HTTP API.
+package frontend
+
+import (
+	"encoding/json"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/featureflag"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/oidc"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/recorder"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/relay"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/throttle"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/tokenkey"
+)
+
+// API bundles the dependencies needed to serve the gateway HTTP API.
+type API struct {
+	Pool   *backend.Pool
+	Access *access.Config
+
+	// Authorizer runs secondary authorization on every lease request
+	// after the built-in key/repo/IP checks. It defaults to
+	// access.PassthroughAuthorizer, which allows everything.
+	Authorizer access.Authorizer
+
+	// OIDCVerifier, if set, lets human operators authenticate to admin
+	// endpoints with a bearer ID token obtained via gateway-ctl's device
+	// flow login, instead of an HMAC key. Machine publishers are
+	// unaffected: leases and commits keep using HMAC keys exclusively.
+	OIDCVerifier *oidc.Verifier
+
+	// SigningKey manages the secret used to sign lease tokens, including
+	// rotation with an overlap window. Admins can trigger a rotation via
+	// handleSigningKeyRotate.
+	SigningKey *tokenkey.Store
+
+	// VHosts, if set, routes each request to an access configuration
+	// selected by its Host header instead of always using Access. Set it
+	// via UseVirtualHosts, which also rewires the pool's repo-keyed
+	// lookups to search every registered host's configuration.
+	VHosts *VirtualHosts
+
+	// Relay forwards lease, payload, and cancel requests for
+	// repositories configured with a RemoteURL to their upstream
+	// gateway, so this gateway can act as an edge proxy in front of a
+	// repository it doesn't host itself. It's populated from Access by
+	// NewAPI and rewired by UseVirtualHosts.
+	Relay *relay.Relay
+
+	// EnablePprof mounts Go's runtime profiler under
+	// /api/v1/debug/pprof/, still gated by adminAuthorized. It defaults
+	// to false; set it explicitly to opt in.
+	EnablePprof bool
+
+	// GlobalLimiter, if set, caps the aggregate payload upload
+	// throughput across every publisher, on top of whatever per-key cap
+	// each key's own access configuration sets. Nil-safe: a nil
+	// GlobalLimiter disables the gateway-wide cap.
+	GlobalLimiter *throttle.Limiter
+
+	// MinUploadBytesPerSecond, if positive, evicts a payload upload whose
+	// observed throughput (the same figure RecordUploadProgress computes
+	// for the lease listing) stays below this rate for longer than
+	// SlowClientEvictionTimeout, freeing the worker and socket a dead or
+	// crawling upload — for example one stuck behind a NAT hairpin
+	// timeout — would otherwise hold open indefinitely. It defaults to
+	// 0, which disables eviction entirely.
+	MinUploadBytesPerSecond float64
+
+	// SlowClientEvictionTimeout is how long an upload's throughput may
+	// stay below MinUploadBytesPerSecond before instrumentUpload's
+	// reader evicts it. It has no effect unless MinUploadBytesPerSecond
+	// is also positive.
+	SlowClientEvictionTimeout time.Duration
+
+	// MaxInlineObjectBytes, if positive, enables the small-file fast path
+	// at /api/v1/repos/{name}/objects for objects up to this many bytes.
+	// It defaults to 0, which keeps the endpoint disabled.
+	MaxInlineObjectBytes int64
+
+	// SpoolThresholdBytes, if positive, spools a submitted payload body
+	// to Pool.Scratch once it exceeds this many bytes instead of letting
+	// it keep growing an in-memory buffer, stabilizing memory usage
+	// under concurrent large uploads. It defaults to 0, which keeps
+	// every payload entirely in memory as before this field existed.
+	// Has no effect unless Pool.Scratch is also configured.
+	SpoolThresholdBytes int64
+
+	// RequireAuthForReads, if set, requires a valid key's bearer token on
+	// the read-only lease listing, stats, and health endpoints, exactly
+	// as write endpoints already require one. It defaults to false,
+	// which keeps those endpoints open to unauthenticated callers (e.g.
+	// a monitoring dashboard) as they have always been; an unauthenticated
+	// caller's lease listing has its KeyID fields redacted either way.
+	RequireAuthForReads bool
+
+	// Limiter, if set, caps how many requests may be in flight at once
+	// per endpoint class ("new-lease", "payload", "commit", "admin"), so
+	// a flood of one kind of request can't starve out the others. It
+	// defaults to nil, which leaves every class uncapped.
+	Limiter *ConcurrencyLimiter
+
+	// ClockSkewTolerance is added to a lease token's claimed expiry
+	// before handleCommitLease rejects it, so a publisher host whose
+	// clock runs slightly ahead of the gateway's doesn't see its tokens
+	// expire early. It defaults to 0, matching the token's nominal
+	// expiry exactly.
+	ClockSkewTolerance time.Duration
+
+	// InstancePeers maps another gateway instance's Pool.InstanceID to
+	// its base URL, for load-balanced deployments where multiple
+	// instances share an access configuration and LeaseDB but not the
+	// in-memory upload progress a commit needs. A commit whose lease
+	// token names an instance other than this one is redirected there
+	// instead of being serviced locally. Nil-safe: a nil (or incomplete)
+	// map means such a commit is serviced locally anyway, on a
+	// best-effort basis, as before this field existed.
+	InstancePeers map[string]string
+
+	// Recorder, if set, captures request/response pairs for keys or
+	// repositories enrolled via handleDebugRecorder into a downloadable
+	// ring buffer, for debugging client protocol issues without a
+	// packet capture. It defaults to nil, which disables the feature
+	// (and the endpoints that control it) entirely. It's populated by
+	// wrapping the gateway's HTTP handler in recorder.New, so it also
+	// captures requests handled outside API itself (e.g. /metrics).
+	Recorder *recorder.Recorder
+
+	// Features holds the gateway's runtime feature-flag set, consulted
+	// by frontend and backend code paths that want to be rolled out or
+	// back without a redeploy. It defaults to nil; the handful of
+	// handlers that check it (see handleFeatureFlags and
+	// handleRepoFeatureFlags) treat a nil Features as every flag
+	// disabled everywhere.
+	Features *featureflag.Set
+
+	// CanaryFeatures lists the feature names currently enabled only for
+	// repositories marked Canary in the access configuration, so a new
+	// protocol feature can be rolled out to a small number of consenting
+	// repositories and observed before it's enabled everywhere. It's
+	// reported to a repository's capabilities endpoint, empty for a
+	// non-canary repository regardless of this field. It defaults to
+	// nil, which reports no canary-only features anywhere.
+	CanaryFeatures []string
+
+	// StrictHTTPStatus, if set, makes v1 handlers that have always
+	// returned HTTP 200 with a statuscode.* value in the response body
+	// (e.g. path_busy, invalid_token, clock_skew) instead map that value
+	// to a real HTTP status code (409, 401, 410, ...), so generic HTTP
+	// clients and proxies that key off the status line see failures
+	// without parsing the body. It defaults to false, which preserves
+	// every existing client's behavior; a caller can also opt in for a
+	// single request with the X-Strict-Status header regardless of this
+	// field. The response body's Status field and shape are unchanged
+	// either way. See strict_status.go.
+	StrictHTTPStatus bool
+
+	keyLimitersMu sync.Mutex
+	keyLimiters   map[string]*throttle.Limiter
+}
+
+// NewAPI constructs an API handler set and wires the pool's mirror
+// trigger to the access configuration's per-repository mirror list.
+func NewAPI(pool *backend.Pool, acc *access.Config) *API {
+	pool.MirrorLookup = acc.MirrorURLs
+	pool.Policy.ForbiddenPatterns = acc.ForbiddenPaths
+	pool.Tags.Template = acc.TagTemplate
+	pool.RepoKnown = acc.KnownRepo
+	pool.FreezeWindows = freezeWindowsLookup(acc)
+	pool.ProtectedRepos = acc.Protected
+	return &API{
+		Pool:       pool,
+		Access:     acc,
+		Authorizer: access.PassthroughAuthorizer{},
+		Relay:      relay.New(remoteLookup(acc)),
+	}
+}
+
+// freezeWindowsLookup adapts a single access configuration's
+// FreezeWindowsFor method to backend.Pool's FreezeWindows field, which
+// deals in backend.FreezeWindow rather than access.FreezeWindow.
+func freezeWindowsLookup(acc *access.Config) func(repo string) []backend.FreezeWindow {
+	return func(repo string) []backend.FreezeWindow {
+		windows := acc.FreezeWindowsFor(repo)
+		if len(windows) == 0 {
+			return nil
+		}
+		out := make([]backend.FreezeWindow, len(windows))
+		for i, w := range windows {
+			out[i] = backend.FreezeWindow{
+				Schedule: w.Schedule,
+				Duration: time.Duration(w.DurationSeconds) * time.Second,
+				Reason:   w.Reason,
+			}
+		}
+		return out
+	}
+}
+
+// remoteLookup adapts a single access configuration's RemoteUpstream
+// method to the relay package's Upstream type.
+func remoteLookup(acc *access.Config) func(repo string) (relay.Upstream, bool) {
+	return func(repo string) (relay.Upstream, bool) {
+		rc, ok := acc.RemoteUpstream(repo)
+		if !ok {
+			return relay.Upstream{}, false
+		}
+		return relay.Upstream{URL: rc.URL, KeyID: rc.KeyID}, true
+	}
+}
+
+// Register attaches the API's routes to mux.
+func (a *API) Register(mux *http.ServeMux) {
+	mux.HandleFunc("/api/v1/leases", a.handleLeases)
+	mux.HandleFunc("/api/v1/leases/conflicts", a.handleLeaseConflicts)
+	mux.HandleFunc("/api/v1/leases/history", a.handleLeaseHistory)
+	mux.HandleFunc("/api/v1/leases/wait", a.handleLeaseWait)
+	mux.HandleFunc("/api/v1/leases/backup", a.handleLeaseBackup)
+	mux.HandleFunc("/api/v1/leases/restore", a.handleLeaseRestore)
+	mux.HandleFunc("/api/v1/leases/consistency-check", a.handleLeaseConsistencyCheck)
+	mux.HandleFunc("/api/v1/features", a.handleFeatureFlags)
+	mux.HandleFunc("/api/v1/admin/pool", a.handlePoolTelemetry)
+	mux.HandleFunc("/api/v1/leases/approve", a.handleApproveCommit)
+	mux.HandleFunc("/api/v1/leases/", a.handleLeaseByPath)
+	mux.HandleFunc("/api/v1/jobs/", a.handleJobStatus)
+	mux.HandleFunc("/api/v1/health", a.handleHealth)
+	mux.HandleFunc("/api/v1/health/score", a.handleHealthScore)
+	mux.HandleFunc("/api/v1/sessions", a.handleNewSession)
+	mux.HandleFunc("/api/v1/diagnostics/", a.handleDiagnostics)
+	mux.HandleFunc("/api/v1/diagnostics/stream/", a.handleDiagnosticsStream)
+	mux.HandleFunc("/api/v1/stats/", a.handleStats)
+	mux.HandleFunc("/api/v1/receipts/", a.handleReceipt)
+	mux.HandleFunc("/api/v1/keys", a.handleKeys)
+	mux.HandleFunc("/api/v1/keys/stale", a.handleStaleKeys)
+	mux.HandleFunc("/api/v1/keys/", a.handleKeyByID)
+	mux.HandleFunc("/api/v1/repos/", a.handleRepos)
+	mux.HandleFunc("/api/v1/janitor", a.handleJanitor)
+	mux.HandleFunc("/api/v1/signing-key/rotate", a.handleSigningKeyRotate)
+	mux.HandleFunc("/api/v1/debug/pprof/", a.handleDebugPprof)
+	mux.HandleFunc("/api/v1/debug/recorder", a.handleDebugRecorder)
+	mux.HandleFunc("/api/v1/openapi.json", a.handleOpenAPI)
+	mux.HandleFunc("/api/v1/drain", a.handleDrain)
+
+	// /api/v2 is a REST-styled surface over the same backend.Pool and
+	// access.Config as v1, added alongside it rather than replacing it:
+	// existing v1 clients are unaffected, and new client tooling can
+	// adopt typed resources and standard HTTP status codes at its own
+	// pace. See v2.go.
+	mux.HandleFunc("/api/v2/leases", a.handleLeasesV2)
+	mux.HandleFunc("/api/v2/leases/", a.handleLeaseByPathV2)
+	mux.HandleFunc("/api/v2/sessions", a.handleSessionsV2)
+	mux.HandleFunc("/api/v2/jobs/", a.handleJobStatusV2)
+	mux.HandleFunc("/api/v2/repos", a.handleReposV2)
+	mux.HandleFunc("/api/v2/repos/", a.handleRepoByNameV2)
+}
+
+// accessFor returns the access configuration that should govern r: the
+// one selected by its Host header when VHosts is configured, or Access
+// otherwise.
+func (a *API) accessFor(r *http.Request) *access.Config {
+	if a.VHosts != nil {
+		return a.VHosts.For(r)
+	}
+	return a.Access
+}
+
+// UseVirtualHosts enables per-Host-header access configuration routing
+// and rewires the pool's repo-keyed lookups (mirror URLs, content
+// policy, tag templates, known-repository checks) to search every
+// registered virtual host's configuration in turn, since a repository
+// name is global regardless of which host a client used to reach it.
+func (a *API) UseVirtualHosts(v *VirtualHosts) {
+	a.VHosts = v
+	a.Pool.MirrorLookup = func(repo string) []string {
+		for _, cfg := range v.All() {
+			if urls := cfg.MirrorURLs(repo); len(urls) > 0 {
+				return urls
+			}
+		}
+		return nil
+	}
+	a.Pool.Policy.ForbiddenPatterns = func(repo string) []string {
+		for _, cfg := range v.All() {
+			if patterns := cfg.ForbiddenPaths(repo); len(patterns) > 0 {
+				return patterns
+			}
+		}
+		return nil
+	}
+	a.Pool.Tags.Template = func(repo string) string {
+		for _, cfg := range v.All() {
+			if t := cfg.TagTemplate(repo); t != "" {
+				return t
+			}
+		}
+		return ""
+	}
+	a.Pool.RepoKnown = func(repo string) bool {
+		for _, cfg := range v.All() {
+			if cfg.KnownRepo(repo) {
+				return true
+			}
+		}
+		return false
+	}
+	a.Pool.FreezeWindows = func(repo string) []backend.FreezeWindow {
+		for _, cfg := range v.All() {
+			if windows := freezeWindowsLookup(cfg)(repo); len(windows) > 0 {
+				return windows
+			}
+		}
+		return nil
+	}
+	a.Pool.ProtectedRepos = func(repo string) bool {
+		for _, cfg := range v.All() {
+			if cfg.Protected(repo) {
+				return true
+			}
+		}
+		return false
+	}
+	a.Relay = relay.New(func(repo string) (relay.Upstream, bool) {
+		for _, cfg := range v.All() {
+			if rc, ok := cfg.RemoteUpstream(repo); ok {
+				return relay.Upstream{URL: rc.URL, KeyID: rc.KeyID}, true
+			}
+		}
+		return relay.Upstream{}, false
+	})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+// clientIPAllowed enforces the repository's IP allow/deny lists against
+// the request's remote address, regardless of the key used.
+func (a *API) clientIPAllowed(r *http.Request, repo string) bool {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	addr := net.ParseIP(host)
+	if addr == nil {
+		return true
+	}
+	return a.accessFor(r).IPAllowed(repo, addr)
+}
+
+// roleAuthorized reports whether r carries a credential authorized for at
+// least min role: either an HMAC key whose effective Role (Admin implies
+// RoleAdmin) meets it, or, if OIDCVerifier is set, a valid OIDC ID token
+// whose groups claim meets it via access.Config.GroupRole. This lets a
+// monitoring account hold a RoleViewer key or belong to a viewer group
+// and read admin introspection endpoints without also being able to
+// perform RoleOperator or RoleAdmin actions.
+func (a *API) roleAuthorized(r *http.Request, min access.Role) bool {
+	token := bearerToken(r)
+	if token == "" {
+		return false
+	}
+
+	if a.OIDCVerifier != nil {
+		if claims, err := a.OIDCVerifier.Verify(token); err == nil {
+			return a.accessFor(r).GroupRole(claims.Groups).AtLeast(min)
+		}
+	}
+
+	key, ok := a.accessFor(r).Key(token)
+	return ok && key.Role.AtLeast(min)
+}
+
+// adminAuthorized reports whether r carries a credential authorized for
+// the highest role, RoleAdmin: either an HMAC key configured with
+// Admin: true (or Role: "admin"), or, if OIDCVerifier is set, a valid
+// OIDC ID token from a group mapped to RoleAdmin.
+func (a *API) adminAuthorized(r *http.Request) bool {
+	return a.roleAuthorized(r, access.RoleAdmin)
+}
+
+// readAuthenticated reports whether r carries any recognized key's bearer
+// token, admin or not. It backs RequireAuthForReads and the KeyID
+// redaction on anonymous lease listings; unlike adminAuthorized, any
+// known key counts, since a dashboard only needs to prove it's a
+// trusted reader, not that it can publish or administer anything.
+func (a *API) readAuthenticated(r *http.Request) bool {
+	token := bearerToken(r)
+	if token == "" {
+		return false
+	}
+	if a.OIDCVerifier != nil {
+		if _, err := a.OIDCVerifier.Verify(token); err == nil {
+			return true
+		}
+	}
+	_, ok := a.accessFor(r).Key(token)
+	return ok
+}
@@ -0,0 +1,63 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+)
+
+// ConcurrencyLimiter caps how many requests may be in flight at once for
+// each of a fixed set of endpoint classes ("new-lease", "payload",
+// "commit", "admin"), so a flood of payload uploads can't starve out
+// lease cancels or admin actions competing for the same downstream
+// resources.
+type ConcurrencyLimiter struct {
+	sems map[string]chan struct{}
+}
+
+// NewConcurrencyLimiter returns a limiter enforcing limits, keyed by
+// endpoint class. A class absent from limits, or set to 0, is left
+// uncapped.
+func NewConcurrencyLimiter(limits map[string]int) *ConcurrencyLimiter {
+	l := &ConcurrencyLimiter{sems: make(map[string]chan struct{})}
+	for class, n := range limits {
+		if n > 0 {
+			l.sems[class] = make(chan struct{}, n)
+		}
+	}
+	return l
+}
+
+// Acquire reserves a concurrency slot for class, returning a release
+// function to call once the request finishes and ok=false if class is
+// already at its configured concurrency limit.
+func (l *ConcurrencyLimiter) Acquire(class string) (release func(), ok bool) {
+	sem, capped := l.sems[class]
+	if !capped {
+		return func() {}, true
+	}
+	select {
+	case sem <- struct{}{}:
+		return func() { <-sem }, true
+	default:
+		return func() {}, false
+	}
+}
+
+// limitConcurrency enforces a.Limiter for class, writing a 503 response
+// and returning ok=false if class is at its configured concurrency
+// limit. It is nil-safe: with no Limiter configured, every class is
+// uncapped and release is a no-op.
+func (a *API) limitConcurrency(w http.ResponseWriter, class string) (release func(), ok bool) {
+	if a.Limiter == nil {
+		return func() {}, true
+	}
+	release, ok = a.Limiter.Acquire(class)
+	if !ok {
+		writeJSON(w, http.StatusServiceUnavailable, statusResponse{
+			Status:  statuscode.Overloaded,
+			Message: "too many concurrent " + class + " requests",
+		})
+	}
+	return release, ok
+}
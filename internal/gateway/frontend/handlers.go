@@ -0,0 +1,2219 @@
+package frontend
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/recorder"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/relay"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/tokenkey"
+)
+
+func readAll(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	return io.ReadAll(r.Body)
+}
+
+// spoolingReadAll reads r.Body the same as readAll, except that once
+// SpoolThresholdBytes is exceeded it copies the remainder to a scratch
+// file instead of letting an in-memory buffer keep growing, then reads
+// the assembled body back from disk. It's disabled (falls back to
+// readAll) unless both SpoolThresholdBytes is positive and Pool.Scratch
+// is configured. The scratch file is always removed before returning,
+// regardless of outcome.
+func (a *API) spoolingReadAll(r *http.Request) ([]byte, error) {
+	defer r.Body.Close()
+	if a.SpoolThresholdBytes <= 0 || a.Pool.Scratch == nil {
+		return io.ReadAll(r.Body)
+	}
+
+	limited := io.LimitReader(r.Body, a.SpoolThresholdBytes)
+	head, err := io.ReadAll(limited)
+	if err != nil {
+		return nil, err
+	}
+	peek := make([]byte, 1)
+	n, _ := r.Body.Read(peek)
+	if n == 0 {
+		return head, nil
+	}
+
+	name := "payload-" + newRequestID()
+	w, err := a.Pool.Scratch.Create(name)
+	if err != nil {
+		return nil, fmt.Errorf("frontend: could not open scratch file for spooling: %w", err)
+	}
+	defer a.Pool.Scratch.Remove(name)
+
+	if _, err := w.Write(head); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("frontend: could not spool payload to disk: %w", err)
+	}
+	if _, err := w.Write(peek[:n]); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("frontend: could not spool payload to disk: %w", err)
+	}
+	if _, err := io.Copy(w, r.Body); err != nil {
+		w.Close()
+		return nil, fmt.Errorf("frontend: could not spool payload to disk: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return nil, fmt.Errorf("frontend: could not spool payload to disk: %w", err)
+	}
+
+	rc, err := a.Pool.Scratch.Open(name)
+	if err != nil {
+		return nil, fmt.Errorf("frontend: could not read back spooled payload: %w", err)
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// maxMultipartMemory bounds how much of a multipart payload submission
+// readPayload buffers in memory before ParseMultipartForm spills the
+// rest to a temporary file.
+const maxMultipartMemory = 32 << 20
+
+// readPayload extracts a submitted payload's digest, tag, and body from
+// r. The default is a raw request body with X-Digest and X-Tag headers
+// (optionally followed by an X-Digest trailer for chunked uploads),
+// spooled to disk above SpoolThresholdBytes via spoolingReadAll; a
+// multipart/form-data Content-Type switches to a compatibility mode for
+// tools that can only submit form uploads, where the payload is the
+// "payload" file part and digest/tag come from "digest"/"tag" form
+// fields, falling back to the X-Digest/X-Tag headers if a field is
+// absent.
+func (a *API) readPayload(r *http.Request) (digest, tag string, body []byte, err error) {
+	digest = r.Header.Get("X-Digest")
+	tag = r.Header.Get("X-Tag")
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "multipart/form-data" {
+		body, err = a.spoolingReadAll(r)
+		if err != nil {
+			return "", "", nil, err
+		}
+		if digest == "" {
+			digest = trailerDigest(r)
+		}
+		return digest, tag, body, nil
+	}
+
+	if err := r.ParseMultipartForm(maxMultipartMemory); err != nil {
+		return "", "", nil, err
+	}
+	if v := r.FormValue("digest"); v != "" {
+		digest = v
+	}
+	if v := r.FormValue("tag"); v != "" {
+		tag = v
+	}
+	file, _, err := r.FormFile("payload")
+	if err != nil {
+		return "", "", nil, err
+	}
+	defer file.Close()
+	body, err = io.ReadAll(file)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return digest, tag, body, nil
+}
+
+// newLeaseRequest is a capability-token style credential: KeyID is
+// trusted as asserted, with no signature or MAC binding the request to
+// the key's Secret. This gateway relies on transport-level trust (e.g. a
+// private network, mTLS, or a reverse proxy that authenticates callers)
+// to keep KeyID from being usable by anyone but its intended holder; it
+// is not, by itself, a bearer-token or HMAC scheme. See access.Config.Secret.
+type newLeaseRequest struct {
+	KeyID string `json:"key_id"`
+	Path  string `json:"path"`
+}
+
+type statusResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+
+	// RetryAfterSeconds suggests how long a client should wait before
+	// retrying a statuscode.PathBusy response. It grows exponentially
+	// with each consecutive rejection of the same path; see
+	// backend.ErrPathBusy.
+	RetryAfterSeconds int `json:"retry_after_seconds,omitempty"`
+
+	// ServerTime carries the gateway's current time on a
+	// statuscode.ClockSkew response, so a client whose clock has drifted
+	// can compare it against its own and correct before retrying.
+	ServerTime string `json:"server_time,omitempty"`
+}
+
+// newLeaseResponse is the response to a successful new-lease request. Its
+// upload manifest fields (UploadURL, Expiration, MaxBytes) are only
+// populated when the request opted in with ?upload_manifest=true, so a
+// publisher that wants to pipeline lease acquisition and its first
+// payload upload can do so from a single round trip instead of needing a
+// second request to /api/v1/sessions to learn the commit endpoint.
+type newLeaseResponse struct {
+	Status     string `json:"status"`
+	Token      string `json:"session_token,omitempty"`
+	UploadURL  string `json:"upload_url,omitempty"`
+	Expiration string `json:"expiration,omitempty"`
+	MaxBytes   int64  `json:"max_bytes,omitempty"`
+}
+
+func newToken() string {
+	buf := make([]byte, 16)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// requestID returns the client-supplied X-Request-ID for r, or mints a
+// new one if it didn't send one, so a commit can be correlated across
+// the gateway's response, the receiver process it invokes, and the
+// diagnostics bundle captured for that invocation.
+func requestID(r *http.Request) string {
+	if id := r.Header.Get("X-Request-ID"); id != "" {
+		return id
+	}
+	return newRequestID()
+}
+
+func newRequestID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// issueToken mints the lease token handed back to the publisher. When a
+// signing key is configured, the token is a signed, self-describing
+// claims token that lets handleCommitLease reject an obvious mismatch
+// (wrong path, expired lease) before ever touching the LeaseDB. Without
+// one, it falls back to a plain random token, as before.
+func (a *API) issueToken(keyID, repository, path string) (string, error) {
+	if a.SigningKey == nil {
+		return newToken(), nil
+	}
+	return a.SigningKey.IssueToken(tokenkey.Claims{
+		Repository: repository,
+		Path:       path,
+		KeyID:      keyID,
+		Expiry:     time.Now().Add(backend.DefaultLeaseTime),
+		Instance:   a.Pool.InstanceID,
+	})
+}
+
+// handleLeases handles POST (acquire a new lease) and GET (list active
+// leases) on /api/v1/leases.
+func (a *API) handleLeases(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodPost:
+		a.handleNewLease(w, r)
+	case http.MethodGet:
+		a.handleGetLeases(w, r)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+func (a *API) handleNewLease(w http.ResponseWriter, r *http.Request) {
+	release, ok := a.limitConcurrency(w, "new-lease")
+	if !ok {
+		return
+	}
+	defer release()
+
+	var req newLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	lease, status, err := a.acquireLease(r, req)
+	if err != nil {
+		a.writeLeaseAcquisitionError(w, r, status, err)
+		return
+	}
+
+	resp := newLeaseResponse{Status: statuscode.Ok, Token: lease.Token}
+	if r.URL.Query().Get("upload_manifest") == "true" {
+		resp.UploadURL = "/api/v1/leases/" + lease.Path
+		resp.Expiration = lease.Expiration.UTC().Format(time.RFC3339)
+		resp.MaxBytes = lease.MaxBytes
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// writeLeaseAcquisitionError writes the failure from acquireLease as a
+// statusResponse, adding a Retry-After header and RetryAfterSeconds hint
+// when the failure is a busy path so a client can back off exponentially
+// instead of retrying at a fixed interval.
+func (a *API) writeLeaseAcquisitionError(w http.ResponseWriter, r *http.Request, status string, err error) {
+	if busy, ok := err.(backend.ErrPathBusy); ok {
+		retryAfter := busy.RetryAfter()
+		w.Header().Set("Retry-After", strconv.Itoa(int(retryAfter.Seconds())))
+		a.writeStatusJSON(w, r, http.StatusOK, status, statusResponse{Status: status, Message: err.Error(), RetryAfterSeconds: int(retryAfter.Seconds())})
+		return
+	}
+	a.writeStatusJSON(w, r, http.StatusOK, status, statusResponse{Status: status, Message: err.Error()})
+}
+
+// maxLeaseWait bounds how long a lease request can queue for a busy path
+// via ?wait=, so a slow or stuck publisher can't tie up an arbitrary
+// number of connections on other clients' behalf.
+const maxLeaseWait = 30 * time.Second
+
+// acquireLease runs the shared authorization and lease-acquisition steps
+// used by both the plain lease endpoint and the combined session
+// endpoint. If the request carries ?wait=<duration> and the target path
+// is busy, it queues in FIFO order for up to that long instead of
+// failing immediately, so a burst of publishers targeting the same path
+// don't have to poll and race for it.
+//
+// Authorization here is identity-based, not cryptographic: req.KeyID is
+// trusted as asserted against the access config's repo/key mapping and
+// IP allow-list, with no signature or MAC computed from the key's Secret
+// binding the request to its claimed KeyID. See newLeaseRequest and
+// access.Config.Secret.
+func (a *API) acquireLease(r *http.Request, req newLeaseRequest) (backend.Lease, string, error) {
+	repo := repositoryFromPath(req.Path)
+	acc := a.accessFor(r)
+	req.Path = acc.ExpandLeasePath(req.KeyID, repo, req.Path)
+
+	if !a.clientIPAllowed(r, repo) {
+		return backend.Lease{}, statuscode.InvalidKey, fmt.Errorf("client address not permitted for this repository")
+	}
+	if !acc.Authorized(req.KeyID, repo) {
+		return backend.Lease{}, statuscode.InvalidKey, fmt.Errorf("key %s is not authorized for repository %s", req.KeyID, repo)
+	}
+	if err := a.Authorizer.Authorize(req.KeyID, repo, req.Path); err != nil {
+		return backend.Lease{}, statuscode.InvalidKey, err
+	}
+	acc.RecordUse(req.KeyID, time.Now())
+
+	if up, ok := a.Relay.Remote(repo); ok {
+		token, err := a.Relay.NewLease(up, req.Path)
+		if err != nil {
+			return backend.Lease{}, statuscode.Error, err
+		}
+		return backend.Lease{Token: token, Repository: repo, Path: req.Path, KeyID: req.KeyID}, statuscode.Ok, nil
+	}
+
+	token, err := a.issueToken(req.KeyID, repo, req.Path)
+	if err != nil {
+		return backend.Lease{}, statuscode.Error, err
+	}
+	maxBytes := acc.MaxTransactionSize(repo)
+	lease, err := a.Pool.NewLeaseWait(req.KeyID, token, repo, req.Path, maxBytes, leaseWait(r))
+	if err != nil {
+		if _, ok := err.(backend.ErrPathRejected); ok {
+			return backend.Lease{}, statuscode.InvalidPath, err
+		}
+		if _, ok := err.(backend.ErrDraining); ok {
+			return backend.Lease{}, statuscode.Draining, err
+		}
+		if _, ok := err.(backend.ErrRepositoryFrozen); ok {
+			return backend.Lease{}, statuscode.Frozen, err
+		}
+		return backend.Lease{}, statuscode.PathBusy, err
+	}
+	return lease, statuscode.Ok, nil
+}
+
+type sessionResponse struct {
+	Status     string `json:"status"`
+	Token      string `json:"session_token"`
+	UploadURL  string `json:"upload_url"`
+	Expiration string `json:"expiration"`
+	MaxBytes   int64  `json:"max_bytes,omitempty"`
+}
+
+// handleNewSession combines lease acquisition with the upload manifest a
+// publisher needs to submit its payload, sparing clients a second
+// round-trip to discover the commit endpoint.
+func (a *API) handleNewSession(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+
+	var req newLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	lease, status, err := a.acquireLease(r, req)
+	if err != nil {
+		a.writeLeaseAcquisitionError(w, r, status, err)
+		return
+	}
+
+	writeJSON(w, http.StatusOK, sessionResponse{
+		Status:     statuscode.Ok,
+		Token:      lease.Token,
+		UploadURL:  "/api/v1/leases/" + lease.Path,
+		Expiration: lease.Expiration.UTC().Format(time.RFC3339),
+		MaxBytes:   lease.MaxBytes,
+	})
+}
+
+// leaseView augments a Lease with a flag warning that it will expire
+// soon, so publishers polling the listing can renew or commit in time,
+// and, while a payload upload is in flight for it, the observed
+// throughput and an estimated time to completion.
+type leaseView struct {
+	backend.Lease
+	ExpiresSoon          bool    `json:"expires_soon"`
+	UploadBytesPerSecond float64 `json:"upload_bytes_per_second,omitempty"`
+	UploadETASeconds     float64 `json:"upload_eta_seconds,omitempty"`
+}
+
+// expiryWarningWindow is how close to expiration a lease must be before
+// it is flagged as expiring soon.
+const expiryWarningWindow = 60 * time.Second
+
+// leasePage is the paginated, filtered result returned by GetLeases.
+type leasePage struct {
+	Status string      `json:"status"`
+	Data   []leaseView `json:"data"`
+	Total  int         `json:"total"`
+	Offset int         `json:"offset"`
+	Limit  int         `json:"limit"`
+}
+
+const defaultLeasePageLimit = 100
+
+// handleGetLeases lists active leases, optionally filtered by repository,
+// sorted by expiry (soonest first) by default or by path with
+// ?sort=path, and paginated via ?offset= and ?limit= query parameters.
+func (a *API) handleGetLeases(w http.ResponseWriter, r *http.Request) {
+	authenticated := a.readAuthenticated(r)
+	if a.RequireAuthForReads && !authenticated {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	leases, err := a.Pool.Leases.GetLeases()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	repoFilter := r.URL.Query().Get("repo")
+	now := time.Now()
+	views := make([]leaseView, 0, len(leases))
+	for _, l := range leases {
+		if repoFilter != "" && l.Repository != repoFilter {
+			continue
+		}
+		if !authenticated {
+			l.KeyID = ""
+		}
+		view := leaseView{Lease: l, ExpiresSoon: l.Expiration.Sub(now) <= expiryWarningWindow}
+		if prog, ok := a.Pool.UploadProgress(l.Path); ok && prog.BytesPerSecond > 0 {
+			view.UploadBytesPerSecond = prog.BytesPerSecond
+			if remaining := l.MaxBytes - l.BytesSubmitted; l.MaxBytes > 0 && remaining > 0 {
+				view.UploadETASeconds = float64(remaining) / prog.BytesPerSecond
+			}
+		}
+		views = append(views, view)
+	}
+
+	if r.URL.Query().Get("sort") == "path" {
+		sort.Slice(views, func(i, j int) bool { return views[i].Path < views[j].Path })
+	} else {
+		sort.Slice(views, func(i, j int) bool { return views[i].Expiration.Before(views[j].Expiration) })
+	}
+
+	offset := parseIntParam(r, "offset", 0)
+	limit := parseIntParam(r, "limit", defaultLeasePageLimit)
+	total := len(views)
+	if offset > total {
+		offset = total
+	}
+	end := offset + limit
+	if end > total || limit <= 0 {
+		end = total
+	}
+
+	body, err := json.Marshal(leasePage{Status: statuscode.Ok, Data: views[offset:end], Total: total, Offset: offset, Limit: limit})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	etag := weakETag(body)
+	w.Header().Set("ETag", etag)
+	w.Header().Set("Cache-Control", "no-cache")
+	if r.Header.Get("If-None-Match") == etag {
+		w.WriteHeader(http.StatusNotModified)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	w.Write(body)
+}
+
+// weakETag computes a weak validator for body so that clients polling
+// GetLeases can skip re-downloading an unchanged lease set.
+func weakETag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return fmt.Sprintf(`W/"%x"`, sum[:8])
+}
+
+// handleLeaseByPath handles POST (commit) and DELETE (cancel) on
+// /api/v1/leases/{path}.
+func (a *API) handleLeaseByPath(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/leases/")
+	if path == "" {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	switch r.Method {
+	case http.MethodPost:
+		a.handleCommitLease(w, r, path)
+	case http.MethodDelete:
+		a.handleCancelLease(w, r, path)
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+// handleCommitLease applies the request body as the payload committed to
+// the leased path. If the request carries ?async=true, the commit runs
+// in the background and a job ID is returned immediately so that clients
+// behind proxies with short read timeouts don't have to hold the
+// connection open for the whole commit.
+func (a *API) handleCommitLease(w http.ResponseWriter, r *http.Request, path string) {
+	release, ok := a.limitConcurrency(w, "commit")
+	if !ok {
+		return
+	}
+	defer release()
+
+	reqID := requestID(r)
+	w.Header().Set("X-Request-ID", reqID)
+
+	token := bearerToken(r)
+	if token == "" {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidToken, statusResponse{Status: statuscode.InvalidToken, Message: "missing session token"})
+		return
+	}
+
+	repo := repositoryFromPath(path)
+	if up, ok := a.Relay.Remote(repo); ok {
+		a.relayCommit(w, r, up, path, token)
+		return
+	}
+
+	var keyID string
+	if a.SigningKey != nil {
+		claims, err := a.SigningKey.ParseToken(token)
+		if err != nil {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidToken, statusResponse{Status: statuscode.InvalidToken, Message: err.Error()})
+			return
+		}
+		if claims.Path != path {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidToken, statusResponse{Status: statuscode.InvalidToken, Message: "token was not issued for this path"})
+			return
+		}
+		if now := time.Now(); now.After(claims.Expiry.Add(a.ClockSkewTolerance)) {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.ClockSkew, statusResponse{
+				Status:     statuscode.ClockSkew,
+				Message:    "lease token has expired",
+				ServerTime: now.UTC().Format(time.RFC3339),
+			})
+			return
+		}
+		if a.redirectToInstance(w, r, claims.Instance) {
+			return
+		}
+		keyID = claims.KeyID
+	}
+
+	a.instrumentUpload(r, path, keyID)
+	defer a.Pool.ClearUploadProgress(path)
+
+	digest, tag, body, err := a.readPayload(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	digestAlgorithm := r.Header.Get("X-Digest-Algorithm")
+	if digestAlgorithm != "" && !a.Pool.SupportsDigestAlgorithm(digestAlgorithm) {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.UnsupportedDigest, statusResponse{
+			Status:  statuscode.UnsupportedDigest,
+			Message: fmt.Sprintf("receiver does not support digest algorithm %q", digestAlgorithm),
+		})
+		return
+	}
+
+	payload := receiver.Payload{Digest: digest, Data: body, Tag: tag, RequestID: reqID, DigestAlgorithm: digestAlgorithm, ManifestDigests: manifestDigests(r)}
+
+	if commitAt := r.Header.Get("X-Commit-At"); commitAt != "" {
+		at, err := time.Parse(time.RFC3339, commitAt)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "X-Commit-At must be an RFC3339 timestamp"})
+			return
+		}
+		if err := a.Pool.ScheduleCommit(token, path, payload, at); err != nil {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.Error, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, statusResponse{Status: statuscode.Ok, Message: fmt.Sprintf("commit scheduled for %s", at.Format(time.RFC3339))})
+		return
+	}
+
+	if r.URL.Query().Get("async") == "true" {
+		job, err := a.Pool.CommitLeaseAsync(token, path, payload)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusAccepted, jobResponse{Status: statuscode.Ok, JobID: job.ID})
+		return
+	}
+
+	summary, err := a.Pool.CommitLease(token, path, payload)
+	if err != nil {
+		if err == backend.ErrTransactionTooLarge {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.TransactionTooLarge, statusResponse{Status: statuscode.TransactionTooLarge, Message: err.Error()})
+			return
+		}
+		if _, ok := err.(backend.ErrCircuitOpen); ok {
+			writeJSON(w, http.StatusServiceUnavailable, statusResponse{Status: statuscode.CircuitOpen, Message: err.Error()})
+			return
+		}
+		if _, ok := err.(backend.ErrApprovalRequired); ok {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.PendingApproval, statusResponse{Status: statuscode.PendingApproval, Message: err.Error()})
+			return
+		}
+		if _, ok := err.(backend.ErrMaintenanceLocked); ok {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.MaintenanceLocked, statusResponse{Status: statuscode.MaintenanceLocked, Message: err.Error()})
+			return
+		}
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.TransactionAborted, statusResponse{Status: statuscode.TransactionAborted, Message: err.Error()})
+		return
+	}
+	resp := commitResponse{Status: statuscode.Ok, Summary: &summary}
+	if receipt, ok := a.Pool.Receipts.Latest(path); ok {
+		resp.ReceiptID = receipt.ID
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// relayCommit forwards a payload submission for a remote repository to
+// its upstream gateway and passes the upstream's response back to the
+// publisher unchanged.
+func (a *API) relayCommit(w http.ResponseWriter, r *http.Request, up relay.Upstream, path, token string) {
+	a.instrumentUpload(r, path, "")
+	defer a.Pool.ClearUploadProgress(path)
+
+	digest, tag, body, err := a.readPayload(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	respBody, status, err := a.Relay.CommitPayload(up, path, token, digest, tag, body)
+	if err != nil {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.Error, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(respBody)
+}
+
+// trailerDigest returns the X-Digest value sent as an HTTP trailer, for
+// publishers streaming a payload with chunked transfer encoding that
+// can't know its digest until the last byte has been written. r.Body
+// must already have been fully read (e.g. via readAll) before this is
+// called, since trailers only become available once the body has been
+// drained.
+func trailerDigest(r *http.Request) string {
+	if r.Trailer == nil {
+		return ""
+	}
+	return r.Trailer.Get("X-Digest")
+}
+
+// manifestDigests parses the comma-separated X-Manifest-Digests header
+// into the full set of object digests a differential commit's catalog
+// references, whether or not the publisher's payload included their
+// data. Returns nil if the header is absent, meaning the payload is
+// assumed to be a complete, non-differential pack.
+func manifestDigests(r *http.Request) []string {
+	header := r.Header.Get("X-Manifest-Digests")
+	if header == "" {
+		return nil
+	}
+	parts := strings.Split(header, ",")
+	digests := make([]string, 0, len(parts))
+	for _, p := range parts {
+		if p = strings.TrimSpace(p); p != "" {
+			digests = append(digests, p)
+		}
+	}
+	return digests
+}
+
+// bearerToken extracts the session token from the Authorization header of
+// a payload submission request.
+func bearerToken(r *http.Request) string {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(h, prefix)
+}
+
+// redirectToInstance responds with a 307 Temporary Redirect to the peer
+// gateway instance that issued this session's lease token, if instance
+// names one other than this instance and InstancePeers has an address
+// for it. 307 preserves the request's method and body, so a publisher's
+// HTTP client resubmits the same commit against the right instance
+// instead of losing the payload. It reports whether it wrote a response,
+// so the caller knows whether to keep handling the request itself.
+func (a *API) redirectToInstance(w http.ResponseWriter, r *http.Request, instance string) bool {
+	if instance == "" || instance == a.Pool.InstanceID {
+		return false
+	}
+	peer, ok := a.InstancePeers[instance]
+	if !ok {
+		return false
+	}
+	http.Redirect(w, r, peer+r.URL.RequestURI(), http.StatusTemporaryRedirect)
+	return true
+}
+
+// leaseWait parses the ?wait= query parameter on a lease request into a
+// duration to queue for a busy path, capped at maxLeaseWait. A missing or
+// unparseable value disables queueing, preserving the old
+// fail-immediately behavior.
+func leaseWait(r *http.Request) time.Duration {
+	raw := r.URL.Query().Get("wait")
+	if raw == "" {
+		return 0
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil || d <= 0 {
+		return 0
+	}
+	if d > maxLeaseWait {
+		return maxLeaseWait
+	}
+	return d
+}
+
+type jobResponse struct {
+	Status string `json:"status"`
+	JobID  string `json:"job_id"`
+}
+
+// commitResponse is the response to a successful synchronous commit.
+// ReceiptID is empty if the commit was held for approval rather than
+// applied immediately, since no receipt is issued until ApproveCommit
+// applies it.
+type commitResponse struct {
+	Status    string `json:"status"`
+	ReceiptID string `json:"receipt_id,omitempty"`
+
+	// Summary reports the transaction's accounting (payloads received,
+	// bytes, and time spent) for publisher tooling to log a meaningful
+	// publish report. It's omitted for responses that don't come from a
+	// completed backend.LeaseSummary, such as scheduled or async commits.
+	Summary *backend.LeaseSummary `json:"summary,omitempty"`
+}
+
+type jobStatusResponse struct {
+	Status string `json:"status"`
+	JobID  string `json:"job_id"`
+	State  string `json:"state"`
+	Error  string `json:"error,omitempty"`
+}
+
+// handleJobStatus reports the current state of a background job created
+// by an asynchronous commit.
+func (a *API) handleJobStatus(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/jobs/")
+	job, ok := a.Pool.Jobs.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, jobStatusResponse{
+		Status: statuscode.Ok,
+		JobID:  job.ID,
+		State:  string(job.State),
+		Error:  job.Error,
+	})
+}
+
+func (a *API) handleCancelLease(w http.ResponseWriter, r *http.Request, path string) {
+	repo := repositoryFromPath(path)
+	if up, ok := a.Relay.Remote(repo); ok {
+		respBody, status, err := a.Relay.CancelLease(up, path, bearerToken(r))
+		if err != nil {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.Error, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		w.Write(respBody)
+		return
+	}
+
+	a.Pool.CancelScheduledCommit(path)
+	summary, err := a.Pool.CancelLease(path)
+	if err != nil {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidLease, statusResponse{Status: statuscode.InvalidLease, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, cancelResponse{Status: statuscode.Ok, Summary: &summary})
+}
+
+// cancelResponse is the response to a successful lease cancellation.
+type cancelResponse struct {
+	Status  string                `json:"status"`
+	Summary *backend.LeaseSummary `json:"summary,omitempty"`
+}
+
+// healthResponse augments the cached upstream storage health status with
+// the receiver version the gateway is configured to talk to and, if a
+// scratch space disk watchdog or commit lock deadlock watchdog is
+// enabled, their most recent results.
+type healthResponse struct {
+	backend.Status
+	ReceiverVersion string          `json:"receiver_version,omitempty"`
+	DiskHealth      *backend.Status `json:"disk_health,omitempty"`
+	LockHealth      *backend.Status `json:"lock_health,omitempty"`
+
+	// Draining reports whether the pool is in drain mode ahead of a
+	// blue-green upgrade handoff, refusing new leases while letting
+	// leases already held finish normally. It is a deliberate, healthy
+	// state, so it doesn't affect the response's status code.
+	Draining bool `json:"draining,omitempty"`
+}
+
+// handleHealth reports the cached upstream storage health status, and the
+// cached scratch space disk watchdog and commit lock deadlock watchdog
+// statuses if enabled.
+func (a *API) handleHealth(w http.ResponseWriter, r *http.Request) {
+	if a.RequireAuthForReads && !a.readAuthenticated(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	status := a.Pool.Health.Status()
+	code := http.StatusOK
+	if !status.Healthy {
+		code = http.StatusServiceUnavailable
+	}
+
+	resp := healthResponse{Status: status, ReceiverVersion: a.Pool.ReceiverVersion, Draining: a.Pool.Draining()}
+	if a.Pool.DiskHealth != nil {
+		diskStatus := a.Pool.DiskHealth.Status()
+		resp.DiskHealth = &diskStatus
+		if !diskStatus.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+	}
+	if a.Pool.LockHealth != nil {
+		lockStatus := a.Pool.LockHealth.Status()
+		resp.LockHealth = &lockStatus
+		if !lockStatus.Healthy {
+			code = http.StatusServiceUnavailable
+		}
+	}
+	writeJSON(w, code, resp)
+}
+
+// healthScoreResponse wraps the composite health score for the
+// /api/v1/health/score endpoint.
+type healthScoreResponse struct {
+	Status string              `json:"status"`
+	Score  backend.HealthScore `json:"score"`
+}
+
+// handleHealthScore reports a composite 0-100 health score derived from
+// the current job queue depth, recent commit error rate, upstream
+// storage latency, and lease database latency, broken down by
+// contributing factor. Unlike handleHealth, this makes a live LeaseDB
+// call on every request, so it's meant for an operator dashboard or
+// alerting rule evaluation, not a liveness probe hit on every request.
+func (a *API) handleHealthScore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	writeJSON(w, http.StatusOK, healthScoreResponse{Status: statuscode.Ok, Score: a.Pool.HealthScore()})
+}
+
+// parseIntParam parses the named query parameter as an int, falling back
+// to def if it is absent or malformed.
+func parseIntParam(r *http.Request, name string, def int) int {
+	v := r.URL.Query().Get(name)
+	if v == "" {
+		return def
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		return def
+	}
+	return n
+}
+
+// handleDiagnostics returns the captured stdout/stderr from the most
+// recent receiver invocation for a repository subpath, for triaging
+// publish failures.
+func (a *API) handleDiagnostics(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/diagnostics/")
+	if path == "" {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	pr, ok := a.Pool.Receiver.(*receiver.ProcessReceiver)
+	if !ok || pr.Diagnostics == nil {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "no diagnostics available"})
+		return
+	}
+
+	bundle, ok := pr.Diagnostics.Get(path)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	writeJSON(w, http.StatusOK, bundle)
+}
+
+// handleDiagnosticsStream attaches to the live stdout/stderr of the
+// receiver process currently committing a repository subpath, streamed
+// as Server-Sent Events, so an admin can watch a catalog merge problem
+// happen in real time instead of waiting for the diagnostic bundle
+// captured after the process exits. The stream ends as soon as the
+// commit running against the path finishes; if none is running when the
+// client attaches, it waits for the next commit on the path and ends
+// when that one finishes, or when the client disconnects. It requires operator authorization
+// (access.RoleOperator or above), since receiver output can include
+// repository content details.
+func (a *API) handleDiagnosticsStream(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleOperator) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/diagnostics/stream/")
+	if path == "" {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	pr, ok := a.Pool.Receiver.(*receiver.ProcessReceiver)
+	if !ok || pr.Streams == nil {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "live output streaming not available"})
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: "streaming not supported"})
+		return
+	}
+
+	lines, unsubscribe := pr.Streams.Subscribe(path)
+	defer unsubscribe()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	for {
+		select {
+		case line, open := <-lines:
+			if !open {
+				return
+			}
+			fmt.Fprintf(w, "data: %s\n\n", line)
+			flusher.Flush()
+		case <-r.Context().Done():
+			return
+		}
+	}
+}
+
+// handleStats returns the publish statistics (files added/removed,
+// bytes, duration, catalogs touched) reported by the receiver for the
+// most recent commit to a repository subpath, mirroring what
+// "cvmfs_server stats" reports for a local publish.
+func (a *API) handleStats(w http.ResponseWriter, r *http.Request) {
+	if a.RequireAuthForReads && !a.readAuthenticated(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	path := strings.TrimPrefix(r.URL.Path, "/api/v1/stats/")
+	if path == "" {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	pr, ok := a.Pool.Receiver.(*receiver.ProcessReceiver)
+	if !ok || pr.Stats == nil {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "no publish statistics available"})
+		return
+	}
+
+	stats, ok := pr.Stats.Get(path)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	writeJSON(w, http.StatusOK, stats)
+}
+
+type receiptResponse struct {
+	Status  string                `json:"status"`
+	Receipt backend.CommitReceipt `json:"receipt"`
+}
+
+// handleReceipt returns the gateway-signed commit receipt for a
+// previously issued receipt ID, at GET /api/v1/receipts/{id}, giving a
+// publisher cryptographic proof of a commit for their own release
+// records without having to keep it around from the commit response.
+func (a *API) handleReceipt(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/receipts/")
+	if id == "" {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	receipt, ok := a.Pool.Receipts.Get(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	writeJSON(w, http.StatusOK, receiptResponse{Status: statuscode.Ok, Receipt: receipt})
+}
+
+type keysResponse struct {
+	Status string           `json:"status"`
+	Keys   []access.KeyInfo `json:"keys"`
+}
+
+type keyResponse struct {
+	Status string         `json:"status"`
+	Key    access.KeyInfo `json:"key"`
+}
+
+// handleKeys lists metadata for every configured key, secrets redacted.
+func (a *API) handleKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleViewer) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+	writeJSON(w, http.StatusOK, keysResponse{Status: statuscode.Ok, Keys: a.accessFor(r).Keys()})
+}
+
+// handleKeyByID returns metadata for a single key, secret redacted.
+func (a *API) handleKeyByID(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleViewer) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	id := strings.TrimPrefix(r.URL.Path, "/api/v1/keys/")
+	if id == "" {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	key, ok := a.accessFor(r).Key(id)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.InvalidKey})
+		return
+	}
+	writeJSON(w, http.StatusOK, keyResponse{Status: statuscode.Ok, Key: key})
+}
+
+// defaultStaleKeyDays is how long a key may go unused before it is
+// reported as stale, absent an explicit ?days= query parameter.
+const defaultStaleKeyDays = 90
+
+// handleStaleKeys reports keys that have never authenticated, or whose
+// last successful authentication is older than ?days= (default
+// defaultStaleKeyDays), so operators can retire forgotten credentials.
+func (a *API) handleStaleKeys(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleViewer) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	days := parseIntParam(r, "days", defaultStaleKeyDays)
+	cutoff := time.Now().Add(-time.Duration(days) * 24 * time.Hour)
+	writeJSON(w, http.StatusOK, keysResponse{Status: statuscode.Ok, Keys: a.accessFor(r).StaleKeys(cutoff)})
+}
+
+type janitorResponse struct {
+	Status string                `json:"status"`
+	Report backend.JanitorReport `json:"report"`
+}
+
+// handleJanitor reports the outcome of the most recent orphaned/stale
+// lease sweep. A POST triggers a sweep immediately instead of waiting for
+// the next scheduled run.
+func (a *API) handleJanitor(w http.ResponseWriter, r *http.Request) {
+	// Reading the last report only requires RoleViewer; triggering a
+	// sweep is a RoleOperator action.
+	minRole := access.RoleViewer
+	if r.Method == http.MethodPost {
+		minRole = access.RoleOperator
+	}
+	if !a.roleAuthorized(r, minRole) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	switch r.Method {
+	case http.MethodGet:
+		writeJSON(w, http.StatusOK, janitorResponse{Status: statuscode.Ok, Report: a.Pool.Janitor.LastReport()})
+	case http.MethodPost:
+		writeJSON(w, http.StatusOK, janitorResponse{Status: statuscode.Ok, Report: a.Pool.RunJanitorSweep()})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+// handleSigningKeyRotate rotates the lease token signing key. The
+// outgoing key keeps validating tokens signed under it for its
+// configured overlap window, so leases already handed out don't break.
+func (a *API) handleSigningKeyRotate(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.adminAuthorized(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+	if a.SigningKey == nil {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "no signing key configured"})
+		return
+	}
+
+	if err := a.SigningKey.Rotate(); err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{Status: statuscode.Ok, Message: "signing key rotated: " + a.SigningKey.Current().ID})
+}
+
+type diffResponse struct {
+	Status  string               `json:"status"`
+	Entries []receiver.DiffEntry `json:"entries"`
+}
+
+// handleRepos dispatches the /api/v1/repos/{name}/{action} routes.
+func (a *API) handleRepos(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v1/repos/")
+	repo, sub, ok := strings.Cut(rest, "/")
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+
+	switch sub {
+	case "diff":
+		a.handleRepoDiff(w, r, repo)
+	case "gc":
+		a.handleRepoGC(w, r, repo)
+	case "whitelist":
+		a.handleRepoWhitelist(w, r, repo)
+	case "objects":
+		a.handleSubmitObject(w, r, repo)
+	case "objects/batch":
+		a.handleSubmitObjectBatch(w, r, repo)
+	case "objects/missing":
+		a.handleMissingObjects(w, r, repo)
+	case "maintenance-lock":
+		a.handleMaintenanceLock(w, r, repo)
+	case "capabilities":
+		a.handleRepoCapabilities(w, r, repo)
+	case "features":
+		a.handleRepoFeatureFlags(w, r, repo)
+	default:
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+	}
+}
+
+// handleRepoDiff summarizes the catalog entries that changed between two
+// root hashes, for change review and publish verification workflows.
+// Expects GET /api/v1/repos/{name}/diff?from=...&to=....
+func (a *API) handleRepoDiff(w http.ResponseWriter, r *http.Request, repo string) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if a.RequireAuthForReads && !a.readAuthenticated(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	from := r.URL.Query().Get("from")
+	to := r.URL.Query().Get("to")
+	if from == "" || to == "" {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "from and to query parameters are required"})
+		return
+	}
+
+	differ, ok := a.Pool.Receiver.(receiver.Differ)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "receiver does not support catalog diffs"})
+		return
+	}
+
+	entries, err := differ.Diff(repo, from, to)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, diffResponse{Status: statuscode.Ok, Entries: entries})
+}
+
+type gcRequest struct {
+	DryRun        bool `json:"dry_run"`
+	KeepRevisions int  `json:"keep_revisions,omitempty"`
+}
+
+type gcResponse struct {
+	Status string            `json:"status"`
+	Report receiver.GCReport `json:"report"`
+}
+
+// handleRepoGC runs (or reports on) garbage collection for a repository.
+// POST /api/v1/repos/{name}/gc runs it, honoring ?async=true; GET returns
+// the most recently saved report, useful for reviewing a dry run or
+// polling an asynchronous run's outcome.
+func (a *API) handleRepoGC(w http.ResponseWriter, r *http.Request, repo string) {
+	switch r.Method {
+	case http.MethodGet:
+		report, ok := a.Pool.GCReports.Get(repo)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+			return
+		}
+		writeJSON(w, http.StatusOK, gcResponse{Status: statuscode.Ok, Report: report})
+	case http.MethodPost:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		var req gcRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		opts := receiver.GCOptions{DryRun: req.DryRun, KeepRevisions: req.KeepRevisions}
+
+		if r.URL.Query().Get("async") == "true" {
+			job, err := a.Pool.RunGCAsync(repo, opts)
+			if err != nil {
+				writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+				return
+			}
+			writeJSON(w, http.StatusAccepted, jobResponse{Status: statuscode.Ok, JobID: job.ID})
+			return
+		}
+
+		report, err := a.Pool.RunGC(repo, opts)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, gcResponse{Status: statuscode.Ok, Report: report})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+// maintenanceLockRequest is the body of a POST to acquire a repository's
+// maintenance lock.
+type maintenanceLockRequest struct {
+	Holder     string `json:"holder"`
+	TTLSeconds int    `json:"ttl_seconds"`
+}
+
+// maintenanceLockResponse reports a repository's maintenance lock state.
+type maintenanceLockResponse struct {
+	Status string `json:"status"`
+	Holder string `json:"holder,omitempty"`
+	Locked bool   `json:"locked"`
+}
+
+// defaultMaintenanceLockTTL bounds how long an acquired maintenance lock
+// is held if the caller doesn't specify one, so a script that crashes
+// before releasing it doesn't lock a repository out of publishing
+// indefinitely.
+const defaultMaintenanceLockTTL = 30 * time.Minute
+
+// handleMaintenanceLock lets gateway-driven GC (see backend.Pool.RunGC)
+// and an external cvmfs_server operation coordinate exclusive access to
+// a repository through the same small API: GET reports who currently
+// holds the lock, POST acquires or renews it for the caller-supplied
+// holder identity, and DELETE releases it. A held lock blocks new
+// commits to the repository (see backend.ErrMaintenanceLocked) but has
+// no effect on a commit already in flight, since revoking a receiver
+// process mid-write would be the destructive overlap this exists to
+// prevent. Acquiring and releasing require RoleOperator, since holding
+// the lock blocks every publisher to the repository; reading its state
+// does not.
+func (a *API) handleMaintenanceLock(w http.ResponseWriter, r *http.Request, repo string) {
+	switch r.Method {
+	case http.MethodGet:
+		holder, locked := a.Pool.MaintenanceLockHolder(repo)
+		writeJSON(w, http.StatusOK, maintenanceLockResponse{Status: statuscode.Ok, Holder: holder, Locked: locked})
+	case http.MethodPost:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		var req maintenanceLockRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		if req.Holder == "" {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "holder is required"})
+			return
+		}
+		ttl := time.Duration(req.TTLSeconds) * time.Second
+		if ttl <= 0 {
+			ttl = defaultMaintenanceLockTTL
+		}
+		granted, err := a.Pool.AcquireMaintenanceLock(repo, req.Holder, ttl)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		if !granted {
+			holder, _ := a.Pool.MaintenanceLockHolder(repo)
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.MaintenanceLocked, maintenanceLockResponse{Status: statuscode.MaintenanceLocked, Holder: holder, Locked: true})
+			return
+		}
+		writeJSON(w, http.StatusOK, maintenanceLockResponse{Status: statuscode.Ok, Holder: req.Holder, Locked: true})
+	case http.MethodDelete:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		holder := r.URL.Query().Get("holder")
+		if holder == "" {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "holder query parameter is required"})
+			return
+		}
+		if err := a.Pool.ReleaseMaintenanceLock(repo, holder); err != nil {
+			writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, statusResponse{Status: statuscode.Ok})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+type whitelistResponse struct {
+	Status string                 `json:"status"`
+	Info   receiver.WhitelistInfo `json:"info"`
+}
+
+// handleRepoWhitelist reports (GET) or triggers (POST) a repository's
+// whitelist re-signing. GET returns the most recently issued signature;
+// POST re-signs it immediately, requiring an operator credential
+// (access.RoleOperator or above) since it's
+// a privileged operation that talks to the receiver or an external
+// signing service on the caller's behalf.
+func (a *API) handleRepoWhitelist(w http.ResponseWriter, r *http.Request, repo string) {
+	switch r.Method {
+	case http.MethodGet:
+		info, ok := a.Pool.WhitelistReports.Get(repo)
+		if !ok {
+			writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+			return
+		}
+		writeJSON(w, http.StatusOK, whitelistResponse{Status: statuscode.Ok, Info: info})
+	case http.MethodPost:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		info, err := a.Pool.ResignWhitelist(repo)
+		if err != nil {
+			writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		writeJSON(w, http.StatusOK, whitelistResponse{Status: statuscode.Ok, Info: info})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+// capabilitiesResponse is the response to handleRepoCapabilities.
+type capabilitiesResponse struct {
+	Status   string   `json:"status"`
+	Repo     string   `json:"repo"`
+	Canary   bool     `json:"canary"`
+	Features []string `json:"features,omitempty"`
+}
+
+// handleRepoCapabilities reports which of the gateway's canary-only
+// features, if any, repo currently has enabled. A repository marked
+// Canary in the access configuration is reported with API.CanaryFeatures
+// in full; every other repository always gets an empty feature list,
+// regardless of CanaryFeatures, so a new protocol feature can be rolled
+// out to a small number of consenting repositories and observed before
+// it's enabled everywhere. Publisher tooling is expected to query this
+// before relying on a feature that isn't universally available yet.
+func (a *API) handleRepoCapabilities(w http.ResponseWriter, r *http.Request, repo string) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+
+	resp := capabilitiesResponse{Status: statuscode.Ok, Repo: repo, Canary: a.accessFor(r).Canary(repo)}
+	if resp.Canary {
+		resp.Features = a.CanaryFeatures
+	}
+	writeJSON(w, http.StatusOK, resp)
+}
+
+// objectResponse is the response to a successful inline object submission.
+type objectResponse struct {
+	Status string `json:"status"`
+}
+
+// authorizeInlineSubmission runs the same authorization a lease
+// acquisition performs against X-Key-ID, for handlers that submit
+// objects directly to upstream storage without ever acquiring a lease.
+// It writes the rejection response itself and returns ok=false if the
+// request should stop there. Like acquireLease, this trusts X-Key-ID as
+// asserted; see the capability-token caveat on newLeaseRequest.
+func (a *API) authorizeInlineSubmission(w http.ResponseWriter, r *http.Request, repo string) (keyID string, ok bool) {
+	keyID = r.Header.Get("X-Key-ID")
+	acc := a.accessFor(r)
+	if !a.clientIPAllowed(r, repo) {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidKey, statusResponse{Status: statuscode.InvalidKey, Message: "client address not permitted for this repository"})
+		return "", false
+	}
+	if !acc.Authorized(keyID, repo) {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidKey, statusResponse{Status: statuscode.InvalidKey, Message: fmt.Sprintf("key %s is not authorized for repository %s", keyID, repo)})
+		return "", false
+	}
+	if err := a.Authorizer.Authorize(keyID, repo, ""); err != nil {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.InvalidKey, statusResponse{Status: statuscode.InvalidKey, Message: err.Error()})
+		return "", false
+	}
+	acc.RecordUse(keyID, time.Now())
+	return keyID, true
+}
+
+// handleSubmitObject implements the small-file fast path: POST
+// /api/v1/repos/{name}/objects submits a single object, identified by
+// its X-Digest header, straight to upstream storage via the receiver,
+// skipping lease acquisition and pack-processing overhead. It is
+// disabled unless MaxInlineObjectBytes is positive, and rejects any
+// object larger than that limit. The submitting key is authorized the
+// same way a lease request is, via X-Key-ID.
+func (a *API) handleSubmitObject(w http.ResponseWriter, r *http.Request, repo string) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if a.MaxInlineObjectBytes <= 0 {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "payload")
+	if !ok {
+		return
+	}
+	defer release()
+
+	if _, ok := a.authorizeInlineSubmission(w, r, repo); !ok {
+		return
+	}
+
+	digest := r.Header.Get("X-Digest")
+	if digest == "" {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "X-Digest header is required"})
+		return
+	}
+
+	body, err := readAll(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	if int64(len(body)) > a.MaxInlineObjectBytes {
+		a.writeStatusJSON(w, r, http.StatusOK, statuscode.TransactionTooLarge, statusResponse{Status: statuscode.TransactionTooLarge, Message: "object exceeds the inline submission limit"})
+		return
+	}
+
+	submitter, ok := a.Pool.Receiver.(receiver.ObjectSubmitter)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "receiver does not support inline object submission"})
+		return
+	}
+	if err := submitter.SubmitObject(repo, digest, body); err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	if a.Pool.ObjectCache != nil {
+		a.Pool.ObjectCache.Add(digest)
+	}
+	writeJSON(w, http.StatusOK, objectResponse{Status: statuscode.Ok})
+}
+
+// maxBatchSubmitWorkers bounds how many objects handleSubmitObjectBatch
+// dispatches to the receiver concurrently, so a manifest listing many
+// packs doesn't spawn an unbounded number of receiver processes at once.
+const maxBatchSubmitWorkers = 8
+
+// batchObjectResult reports one manifest entry's outcome.
+type batchObjectResult struct {
+	Digest  string `json:"digest"`
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// batchObjectResponse is the response to a batch inline object
+// submission. Status is Ok as long as the request itself was
+// well-formed; check each entry's own Status for its outcome.
+type batchObjectResponse struct {
+	Status  string              `json:"status"`
+	Results []batchObjectResult `json:"results"`
+}
+
+// parsePackManifest parses the X-Pack-Manifest header, a comma-separated
+// list of "digest:length" entries describing how the request body is
+// divided into consecutively concatenated packs, in order.
+func parsePackManifest(header string) ([]struct {
+	Digest string
+	Length int64
+}, error) {
+	var entries []struct {
+		Digest string
+		Length int64
+	}
+	for _, raw := range strings.Split(header, ",") {
+		raw = strings.TrimSpace(raw)
+		if raw == "" {
+			continue
+		}
+		digest, lengthStr, ok := strings.Cut(raw, ":")
+		if !ok || digest == "" {
+			return nil, fmt.Errorf("malformed manifest entry %q", raw)
+		}
+		length, err := strconv.ParseInt(lengthStr, 10, 64)
+		if err != nil || length < 0 {
+			return nil, fmt.Errorf("malformed length in manifest entry %q", raw)
+		}
+		entries = append(entries, struct {
+			Digest string
+			Length int64
+		}{Digest: digest, Length: length})
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("X-Pack-Manifest header is required")
+	}
+	return entries, nil
+}
+
+// handleSubmitObjectBatch is handleSubmitObject's multi-pack sibling:
+// POST /api/v1/repos/{name}/objects/batch submits several small objects
+// in a single request, described by an X-Pack-Manifest header
+// ("digest:length,digest:length,...") against a body holding each pack's
+// bytes concatenated in that same order. Submitting several packs this
+// way amortizes the connection and key-authorization overhead a
+// publisher would otherwise pay per object with the single-object fast
+// path. It shares that path's size limit and authorization rules, and
+// is disabled under the same conditions.
+func (a *API) handleSubmitObjectBatch(w http.ResponseWriter, r *http.Request, repo string) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if a.MaxInlineObjectBytes <= 0 {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "payload")
+	if !ok {
+		return
+	}
+	defer release()
+
+	if _, ok := a.authorizeInlineSubmission(w, r, repo); !ok {
+		return
+	}
+
+	entries, err := parsePackManifest(r.Header.Get("X-Pack-Manifest"))
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	var total int64
+	for _, e := range entries {
+		if e.Length > a.MaxInlineObjectBytes {
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.TransactionTooLarge, statusResponse{Status: statuscode.TransactionTooLarge, Message: fmt.Sprintf("pack %s exceeds the inline submission limit", e.Digest)})
+			return
+		}
+		total += e.Length
+	}
+
+	body, err := readAll(r)
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	if int64(len(body)) != total {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "request body length does not match X-Pack-Manifest"})
+		return
+	}
+
+	submitter, ok := a.Pool.Receiver.(receiver.ObjectSubmitter)
+	if !ok {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "receiver does not support inline object submission"})
+		return
+	}
+
+	results := make([]batchObjectResult, len(entries))
+	sem := make(chan struct{}, maxBatchSubmitWorkers)
+	var wg sync.WaitGroup
+	var offset int64
+	for i, e := range entries {
+		data := body[offset : offset+e.Length]
+		offset += e.Length
+
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, digest string, data []byte) {
+			defer wg.Done()
+			defer func() { <-sem }()
+
+			if err := submitter.SubmitObject(repo, digest, data); err != nil {
+				results[i] = batchObjectResult{Digest: digest, Status: statuscode.Error, Message: err.Error()}
+				return
+			}
+			if a.Pool.ObjectCache != nil {
+				a.Pool.ObjectCache.Add(digest)
+			}
+			results[i] = batchObjectResult{Digest: digest, Status: statuscode.Ok}
+		}(i, e.Digest, data)
+	}
+	wg.Wait()
+
+	writeJSON(w, http.StatusOK, batchObjectResponse{Status: statuscode.Ok, Results: results})
+}
+
+type missingObjectsRequest struct {
+	Digests []string `json:"digests"`
+}
+
+type missingObjectsResponse struct {
+	Status  string   `json:"status"`
+	Missing []string `json:"missing"`
+}
+
+// handleMissingObjects lets a publisher check, before uploading a pack,
+// which of its objects the gateway doesn't already have cached as
+// present upstream. POST /api/v1/repos/{name}/objects/missing with a
+// {"digests": [...]} body returns the subset it should still upload. A
+// digest the cache has never seen is reported missing even if it does
+// exist upstream (e.g. it predates ObjectCacheCapacity being configured,
+// or was evicted since), so a wrong answer only ever costs a redundant
+// upload, never a lost one. It's unavailable (404) unless
+// ObjectCacheCapacity is configured.
+func (a *API) handleMissingObjects(w http.ResponseWriter, r *http.Request, repo string) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if a.Pool.ObjectCache == nil {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "object existence cache is not enabled"})
+		return
+	}
+	var req missingObjectsRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, missingObjectsResponse{Status: statuscode.Ok, Missing: a.Pool.ObjectCache.Missing(req.Digests)})
+}
+
+type backupResponse struct {
+	Status   string           `json:"status"`
+	Snapshot backend.Snapshot `json:"snapshot"`
+}
+
+// handleLeaseBackup returns a snapshot of every active lease and
+// in-flight commit lock, for an admin to save (to a file, object
+// storage, wherever they like) before migrating the gateway to a new
+// host. Access-config state already lives in its own on-disk JSON file
+// and needs no separate backup.
+func (a *API) handleLeaseBackup(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleOperator) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	snap, err := a.Pool.Backup()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, backupResponse{Status: statuscode.Ok, Snapshot: snap})
+}
+
+type restoreRequest struct {
+	Snapshot backend.Snapshot `json:"snapshot"`
+}
+
+// handleLeaseRestore installs a snapshot produced by handleLeaseBackup,
+// so leases and in-flight commit locks active on another gateway
+// instance carry over instead of being orphaned during a host
+// migration.
+func (a *API) handleLeaseRestore(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleOperator) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	var req restoreRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	if err := a.Pool.Restore(req.Snapshot); err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, statusResponse{Status: statuscode.Ok})
+}
+
+// leaseConsistencyResponse is the response to handleLeaseConsistencyCheck.
+type leaseConsistencyResponse struct {
+	Status   string                         `json:"status"`
+	Report   backend.LeaseConsistencyReport `json:"report"`
+	Repaired int                            `json:"repaired,omitempty"`
+}
+
+// handleLeaseConsistencyCheck cross-checks the lease database against
+// the receiver's own view of which paths have an active upstream
+// session, catching drift like a lease that received bytes but whose
+// receiver session crashed without the gateway noticing, or upstream
+// session state orphaned by a force-cancelled lease. GET reports
+// discrepancies; POST additionally repairs any orphaned upstream
+// sessions it can, by discarding them via the receiver's Cleaner
+// interface. Report.Supported is false, and there is nothing to repair,
+// unless the configured Receiver implements receiver.SessionReporter.
+func (a *API) handleLeaseConsistencyCheck(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleOperator) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	report, err := a.Pool.CheckLeaseConsistency()
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusOK, leaseConsistencyResponse{Status: statuscode.Ok, Report: report})
+		return
+	}
+
+	repaired, err := a.Pool.RepairLeaseConsistency(report)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, leaseConsistencyResponse{Status: statuscode.Ok, Report: report, Repaired: repaired})
+}
+
+// featureFlagsResponse is the response to handleFeatureFlags and
+// handleRepoFeatureFlags.
+type featureFlagsResponse struct {
+	Status string          `json:"status"`
+	Flags  map[string]bool `json:"flags"`
+}
+
+// setFeatureFlagRequest is the body of a POST to handleFeatureFlags or
+// handleRepoFeatureFlags.
+type setFeatureFlagRequest struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+}
+
+// handleFeatureFlags reports (GET) or sets (POST) the gateway's global
+// feature flags, the switches capabilities like streaming uploads or
+// async commits are gated behind so they can be rolled out and rolled
+// back without a redeploy. Setting a flag requires RoleOperator; reading
+// the current values does not. A gateway with no Features configured
+// reports an empty flag set and rejects attempts to set one, since
+// there's nowhere to persist the change.
+func (a *API) handleFeatureFlags(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		flags := map[string]bool{}
+		if a.Features != nil {
+			flags = a.Features.Global()
+		}
+		writeJSON(w, http.StatusOK, featureFlagsResponse{Status: statuscode.Ok, Flags: flags})
+	case http.MethodPost:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		if a.Features == nil {
+			writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "feature flags are not configured for this gateway"})
+			return
+		}
+		var req setFeatureFlagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		if req.Name == "" {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "name is required"})
+			return
+		}
+		a.Features.SetGlobal(req.Name, req.Enabled)
+		writeJSON(w, http.StatusOK, featureFlagsResponse{Status: statuscode.Ok, Flags: a.Features.Global()})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+// handleRepoFeatureFlags reports (GET) repo's effective feature flags —
+// its own overrides layered on the gateway's global values — sets (POST)
+// a per-repo override, or clears (DELETE, via a name query parameter) a
+// previously set override so repo falls back to the global value again.
+// Setting or clearing an override requires RoleOperator.
+func (a *API) handleRepoFeatureFlags(w http.ResponseWriter, r *http.Request, repo string) {
+	switch r.Method {
+	case http.MethodGet:
+		flags := map[string]bool{}
+		if a.Features != nil {
+			flags = a.Features.ForRepo(repo)
+		}
+		writeJSON(w, http.StatusOK, featureFlagsResponse{Status: statuscode.Ok, Flags: flags})
+	case http.MethodPost:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		if a.Features == nil {
+			writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "feature flags are not configured for this gateway"})
+			return
+		}
+		var req setFeatureFlagRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		if req.Name == "" {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "name is required"})
+			return
+		}
+		a.Features.SetForRepo(repo, req.Name, req.Enabled)
+		writeJSON(w, http.StatusOK, featureFlagsResponse{Status: statuscode.Ok, Flags: a.Features.ForRepo(repo)})
+	case http.MethodDelete:
+		if !a.roleAuthorized(r, access.RoleOperator) {
+			writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+			return
+		}
+		if a.Features == nil {
+			writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "feature flags are not configured for this gateway"})
+			return
+		}
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "name query parameter is required"})
+			return
+		}
+		a.Features.ClearForRepo(repo, name)
+		writeJSON(w, http.StatusOK, featureFlagsResponse{Status: statuscode.Ok, Flags: a.Features.ForRepo(repo)})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+type drainResponse struct {
+	Status   string `json:"status"`
+	Draining bool   `json:"draining"`
+}
+
+// handleDrain manages the pool's drain mode, the first step of a
+// blue-green upgrade handoff: once drained, no new leases are accepted,
+// so an operator can export a backup via handleLeaseBackup and hand it
+// to the incoming instance's handleLeaseRestore without a lease slipping
+// in between the two, then retire this instance once the new one has
+// taken over. Handing off the VIP or DNS record itself is an
+// infrastructure step outside the gateway's scope. GET reports the
+// current state; POST enters drain mode; DELETE leaves it.
+func (a *API) handleDrain(w http.ResponseWriter, r *http.Request) {
+	if !a.adminAuthorized(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	switch r.Method {
+	case http.MethodGet:
+	case http.MethodPost:
+		a.Pool.Drain()
+	case http.MethodDelete:
+		a.Pool.Undrain()
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	writeJSON(w, http.StatusOK, drainResponse{Status: statuscode.Ok, Draining: a.Pool.Draining()})
+}
+
+// recorderRequest enrolls or unenrolls a key or repository in debug
+// recording, or clears the buffer, via POST /api/v1/debug/recorder. At
+// most one of the fields should be set per request.
+type recorderRequest struct {
+	EnableKey   string `json:"enable_key,omitempty"`
+	DisableKey  string `json:"disable_key,omitempty"`
+	EnableRepo  string `json:"enable_repo,omitempty"`
+	DisableRepo string `json:"disable_repo,omitempty"`
+	Clear       bool   `json:"clear,omitempty"`
+}
+
+type recorderResponse struct {
+	Status    string              `json:"status"`
+	Keys      []string            `json:"enrolled_keys,omitempty"`
+	Repos     []string            `json:"enrolled_repos,omitempty"`
+	Exchanges []recorder.Exchange `json:"exchanges,omitempty"`
+}
+
+// handleDebugRecorder controls and downloads the request/response
+// recording ring buffer: GET returns the current enrollment and
+// buffered exchanges; POST enrolls or unenrolls a key or repository, or
+// clears the buffer. It's unavailable (404) unless Recorder is
+// configured. Because a recorded exchange's digests can help an
+// attacker fingerprint repository content, both methods require
+// RoleOperator.
+func (a *API) handleDebugRecorder(w http.ResponseWriter, r *http.Request) {
+	if a.Recorder == nil {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: "request/response recording is not enabled"})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleOperator) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	switch r.Method {
+	case http.MethodGet:
+		keys, repos := a.Recorder.Enrolled()
+		writeJSON(w, http.StatusOK, recorderResponse{Status: statuscode.Ok, Keys: keys, Repos: repos, Exchanges: a.Recorder.Snapshot()})
+	case http.MethodPost:
+		var req recorderRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: err.Error()})
+			return
+		}
+		switch {
+		case req.EnableKey != "":
+			a.Recorder.EnableKey(req.EnableKey)
+		case req.DisableKey != "":
+			a.Recorder.DisableKey(req.DisableKey)
+		case req.EnableRepo != "":
+			a.Recorder.EnableRepo(req.EnableRepo)
+		case req.DisableRepo != "":
+			a.Recorder.DisableRepo(req.DisableRepo)
+		case req.Clear:
+			a.Recorder.Clear()
+		}
+		keys, repos := a.Recorder.Enrolled()
+		writeJSON(w, http.StatusOK, recorderResponse{Status: statuscode.Ok, Keys: keys, Repos: repos})
+	default:
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+	}
+}
+
+type conflictsResponse struct {
+	Status    string                  `json:"status"`
+	Conflicts []backend.LeaseConflict `json:"conflicts"`
+}
+
+// handleLeaseConflicts reports which existing lease(s) or in-flight
+// commit lock(s) would block a new lease on ?path=..., so a publisher
+// rejected with a path_busy error can diagnose it without operator help.
+func (a *API) handleLeaseConflicts(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+
+	authenticated := a.readAuthenticated(r)
+	if a.RequireAuthForReads && !authenticated {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "path query parameter is required"})
+		return
+	}
+
+	conflicts, err := a.Pool.LeaseConflicts(path)
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		return
+	}
+	if !authenticated {
+		for i := range conflicts {
+			conflicts[i].KeyID = ""
+		}
+	}
+	writeJSON(w, http.StatusOK, conflictsResponse{Status: statuscode.Ok, Conflicts: conflicts})
+}
+
+type historyResponse struct {
+	Status  string                      `json:"status"`
+	History []backend.LeaseHistoryEntry `json:"history"`
+}
+
+// handleLeaseHistory reports the committed/cancelled lease history
+// retained for ?path=..., if EnableLeaseHistory was configured. It
+// returns an empty list, not an error, when history retention is off,
+// since an operator querying an unconfigured gateway should see "no
+// history" rather than a failure.
+func (a *API) handleLeaseHistory(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+
+	authenticated := a.readAuthenticated(r)
+	if a.RequireAuthForReads && !authenticated {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "path query parameter is required"})
+		return
+	}
+
+	var history []backend.LeaseHistoryEntry
+	if a.Pool.History != nil {
+		history = a.Pool.History.Query(path)
+	}
+	if !authenticated {
+		for i := range history {
+			history[i].KeyID = ""
+		}
+	}
+	writeJSON(w, http.StatusOK, historyResponse{Status: statuscode.Ok, History: history})
+}
+
+type leaseWaitResponse struct {
+	Status string `json:"status"`
+
+	// Free reports whether path was no longer held by an in-flight
+	// commit lock by the time the poll returned. false means timeout
+	// elapsed while the path was still locked.
+	Free bool `json:"free"`
+}
+
+// handleLeaseWait long-polls GET /api/v1/leases/wait?path=...&timeout=...
+// until path is no longer held by an in-flight commit lock, or timeout
+// elapses, whichever comes first, using the same FIFO wait-queue
+// NewLeaseWait uses to wake queued lease requests. It's a keepalive-
+// friendly alternative to a client retry loop: the connection stays open
+// for the poll instead of being re-established on every retry attempt.
+// timeout is capped at maxLeaseWait and defaults to it if omitted.
+func (a *API) handleLeaseWait(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "path query parameter is required"})
+		return
+	}
+
+	timeout := maxLeaseWait
+	if raw := r.URL.Query().Get("timeout"); raw != "" {
+		if d, err := time.ParseDuration(raw); err == nil && d > 0 && d < maxLeaseWait {
+			timeout = d
+		}
+	}
+
+	free := a.Pool.WaitForPathFree(path, timeout)
+	writeJSON(w, http.StatusOK, leaseWaitResponse{Status: statuscode.Ok, Free: free})
+}
+
+// handleApproveCommit handles POST /api/v1/leases/approve?path=..., the
+// second step of the two-person approval workflow: an operator distinct
+// from the original submitter approves a commit that CommitLease held
+// back with ErrApprovalRequired because the repository is protected.
+// The approving key's identity is taken from the same bearer token
+// roleAuthorized already validated.
+func (a *API) handleApproveCommit(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.roleAuthorized(r, access.RoleOperator) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+	release, ok := a.limitConcurrency(w, "admin")
+	if !ok {
+		return
+	}
+	defer release()
+
+	path := r.URL.Query().Get("path")
+	if path == "" {
+		writeJSON(w, http.StatusBadRequest, statusResponse{Status: statuscode.Error, Message: "path query parameter is required"})
+		return
+	}
+
+	summary, err := a.Pool.ApproveCommit(path, bearerToken(r))
+	if err != nil {
+		switch err.(type) {
+		case backend.ErrSelfApproval:
+			writeJSON(w, http.StatusForbidden, statusResponse{Status: statuscode.Error, Message: err.Error()})
+		case backend.ErrApprovalNotFound:
+			writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound, Message: err.Error()})
+		default:
+			a.writeStatusJSON(w, r, http.StatusOK, statuscode.TransactionAborted, statusResponse{Status: statuscode.TransactionAborted, Message: err.Error()})
+		}
+		return
+	}
+	writeJSON(w, http.StatusOK, commitResponse{Status: statuscode.Ok, Summary: &summary})
+}
+
+func repositoryFromPath(path string) string {
+	parts := strings.SplitN(strings.TrimPrefix(path, "/"), "/", 2)
+	return parts[0]
+}
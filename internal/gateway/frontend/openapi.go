@@ -0,0 +1,232 @@
+package frontend
+
+import (
+	"net/http"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+)
+
+// handleOpenAPI serves a static OpenAPI 3 document describing the v1 API,
+// so third-party publisher tooling can generate its own client instead of
+// reverse-engineering the JSON formats from the handlers or this repo's
+// gateway-client package. The document is hand-maintained alongside the
+// handlers it describes, the same way the wire-format doc comments on the
+// response types in handlers.go are: there's no schema-generation step in
+// this repo's build, so keeping it accurate is a review discipline, not a
+// generated artifact.
+func (a *API) handleOpenAPI(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Write([]byte(openAPIDocument))
+}
+
+// openAPIDocument is the OpenAPI 3 document served at
+// /api/v1/openapi.json. It covers the lease lifecycle in full detail
+// (leases, sessions, commit, cancel, jobs) and lists a few read endpoints
+// (health, health score, repos, keys, stats, receipts) without full
+// request/response schemas. The other admin and diagnostic endpoints
+// (janitor, drain, diagnostics, features, debug and the like) are not
+// listed; those are operator-facing rather than integration surfaces
+// third-party publisher tooling depends on.
+const openAPIDocument = `{
+  "openapi": "3.0.3",
+  "info": {
+    "title": "CVMFS Repository Gateway API",
+    "version": "1.0.0",
+    "description": "Lease, payload, and repository management API for CVMFS stratum-0 publishing."
+  },
+  "paths": {
+    "/api/v1/leases": {
+      "get": {
+        "operationId": "listLeases",
+        "summary": "List active leases",
+        "parameters": [
+          {"name": "repo", "in": "query", "schema": {"type": "string"}, "description": "Filter to a single repository."}
+        ],
+        "responses": {
+          "200": {"description": "Lease listing", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/LeasePage"}}}}
+        }
+      },
+      "post": {
+        "operationId": "newLease",
+        "summary": "Acquire a lease on a path",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewLeaseRequest"}}}},
+        "responses": {
+          "200": {"description": "Lease acquired, or a statuscode.* failure with a 200 status unless X-Strict-Status is set", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewLeaseResponse"}}}}
+        }
+      }
+    },
+    "/api/v1/leases/{path}": {
+      "post": {
+        "operationId": "commitLease",
+        "summary": "Submit a payload and commit the lease",
+        "parameters": [
+          {"name": "path", "in": "path", "required": true, "schema": {"type": "string"}},
+          {"name": "async", "in": "query", "schema": {"type": "boolean"}, "description": "Run the commit as a background job and return a job ID immediately."}
+        ],
+        "requestBody": {"required": true, "content": {"application/octet-stream": {"schema": {"type": "string", "format": "binary"}}}},
+        "responses": {
+          "200": {"description": "Commit applied", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/CommitResponse"}}}},
+          "202": {"description": "Commit accepted as a background job", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/JobResponse"}}}}
+        }
+      },
+      "delete": {
+        "operationId": "cancelLease",
+        "summary": "Cancel a lease without committing it",
+        "parameters": [
+          {"name": "path", "in": "path", "required": true, "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "Lease cancelled", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/CancelResponse"}}}}
+        }
+      }
+    },
+    "/api/v1/sessions": {
+      "post": {
+        "operationId": "newSession",
+        "summary": "Acquire a lease and receive its upload URL in one round trip",
+        "requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/NewLeaseRequest"}}}},
+        "responses": {
+          "200": {"description": "Session created", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/SessionResponse"}}}}
+        }
+      }
+    },
+    "/api/v1/jobs/{id}": {
+      "get": {
+        "operationId": "getJobStatus",
+        "summary": "Poll the status of an asynchronous commit job",
+        "parameters": [
+          {"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}
+        ],
+        "responses": {
+          "200": {"description": "Job status", "content": {"application/json": {"schema": {"$ref": "#/components/schemas/JobStatusResponse"}}}},
+          "404": {"description": "Job not found"}
+        }
+      }
+    },
+    "/api/v1/health": {
+      "get": {"operationId": "getHealth", "summary": "Upstream storage and receiver health", "responses": {"200": {"description": "Health status"}}}
+    },
+    "/api/v1/health/score": {
+      "get": {"operationId": "getHealthScore", "summary": "Composite health score", "responses": {"200": {"description": "Health score"}}}
+    },
+    "/api/v1/repos/{name}": {
+      "get": {"operationId": "getRepo", "summary": "Repository configuration and status", "parameters": [{"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}], "responses": {"200": {"description": "Repository details"}}}
+    },
+    "/api/v1/keys": {
+      "get": {"operationId": "listKeys", "summary": "List keys visible to the caller (viewer role or above)", "responses": {"200": {"description": "Key listing"}}}
+    },
+    "/api/v1/stats/{name}": {
+      "get": {"operationId": "getStats", "summary": "Publish statistics for a repository", "parameters": [{"name": "name", "in": "path", "required": true, "schema": {"type": "string"}}], "responses": {"200": {"description": "Publish statistics"}}}
+    },
+    "/api/v1/receipts/{id}": {
+      "get": {"operationId": "getReceipt", "summary": "Signed commit receipt", "parameters": [{"name": "id", "in": "path", "required": true, "schema": {"type": "string"}}], "responses": {"200": {"description": "Commit receipt"}}}
+    }
+  },
+  "components": {
+    "schemas": {
+      "NewLeaseRequest": {
+        "type": "object",
+        "required": ["key_id", "path"],
+        "properties": {
+          "key_id": {"type": "string"},
+          "path": {"type": "string"}
+        }
+      },
+      "Lease": {
+        "type": "object",
+        "properties": {
+          "token": {"type": "string"},
+          "repository": {"type": "string"},
+          "path": {"type": "string"},
+          "key_id": {"type": "string"},
+          "expiration": {"type": "string", "format": "date-time"},
+          "bytes_submitted": {"type": "integer", "format": "int64"},
+          "max_bytes": {"type": "integer", "format": "int64"},
+          "acquired_at": {"type": "string", "format": "date-time"}
+        }
+      },
+      "LeasePage": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "data": {"type": "array", "items": {"$ref": "#/components/schemas/Lease"}},
+          "total": {"type": "integer"},
+          "offset": {"type": "integer"},
+          "limit": {"type": "integer"}
+        }
+      },
+      "LeaseSummary": {
+        "type": "object",
+        "properties": {
+          "path": {"type": "string"},
+          "repository": {"type": "string"},
+          "payloads_received": {"type": "integer"},
+          "bytes_submitted": {"type": "integer", "format": "int64"},
+          "duration_ns": {"type": "integer", "format": "int64"}
+        }
+      },
+      "NewLeaseResponse": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "session_token": {"type": "string"},
+          "upload_url": {"type": "string"},
+          "expiration": {"type": "string", "format": "date-time"},
+          "max_bytes": {"type": "integer", "format": "int64"},
+          "message": {"type": "string"},
+          "retry_after_seconds": {"type": "integer"}
+        }
+      },
+      "SessionResponse": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "session_token": {"type": "string"},
+          "upload_url": {"type": "string"},
+          "expiration": {"type": "string", "format": "date-time"},
+          "max_bytes": {"type": "integer", "format": "int64"}
+        }
+      },
+      "CommitResponse": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "receipt_id": {"type": "string"},
+          "summary": {"$ref": "#/components/schemas/LeaseSummary"},
+          "message": {"type": "string"}
+        }
+      },
+      "CancelResponse": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "summary": {"$ref": "#/components/schemas/LeaseSummary"}
+        }
+      },
+      "JobResponse": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "job_id": {"type": "string"}
+        }
+      },
+      "JobStatusResponse": {
+        "type": "object",
+        "properties": {
+          "status": {"type": "string"},
+          "job_id": {"type": "string"},
+          "state": {"type": "string"},
+          "error": {"type": "string"}
+        }
+      }
+    },
+    "securitySchemes": {
+      "leaseToken": {"type": "http", "scheme": "bearer", "description": "HMAC key ID (or an OIDC ID token where configured), or a session token for the commit/cancel endpoints."}
+    }
+  }
+}
+`
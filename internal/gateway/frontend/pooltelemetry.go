@@ -0,0 +1,59 @@
+package frontend
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/jobqueue"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+)
+
+// poolWorker extends jobqueue.WorkerStatus with a task age computed at
+// response time, so a caller doesn't need to also know the gateway's
+// clock to make sense of Since.
+type poolWorker struct {
+	jobqueue.WorkerStatus
+	TaskAgeSeconds float64 `json:"task_age_seconds"`
+}
+
+// poolTelemetryResponse is the response to handlePoolTelemetry.
+type poolTelemetryResponse struct {
+	Status      string       `json:"status"`
+	WorkerCount int          `json:"worker_count"`
+	QueueLength int          `json:"queue_length"`
+	Workers     []poolWorker `json:"workers"`
+}
+
+// handlePoolTelemetry reports the live state of the background job
+// worker pool backing asynchronous commits, GC runs, mirror
+// notifications, whitelist re-signing, and lease cleanups: how many
+// workers are running, which are busy versus idle, what each busy
+// worker is currently working on (job type and, where available, the
+// repository or lease path it names), how long it's been at it, and how
+// many jobs are still waiting for a free worker. It's indispensable when
+// diagnosing why publishes are queueing. Reads always reflect the
+// moment the request is served; there is no historical view.
+func (a *API) handlePoolTelemetry(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeJSON(w, http.StatusMethodNotAllowed, statusResponse{Status: statuscode.Error})
+		return
+	}
+	if !a.adminAuthorized(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	now := time.Now()
+	statuses := a.Pool.Jobs.WorkerStatuses()
+	workers := make([]poolWorker, len(statuses))
+	for i, s := range statuses {
+		workers[i] = poolWorker{WorkerStatus: s, TaskAgeSeconds: now.Sub(s.Since).Seconds()}
+	}
+
+	writeJSON(w, http.StatusOK, poolTelemetryResponse{
+		Status:      statuscode.Ok,
+		WorkerCount: a.Pool.Jobs.ActiveWorkers(),
+		QueueLength: a.Pool.Jobs.QueueDepth(),
+		Workers:     workers,
+	})
+}
@@ -0,0 +1,44 @@
+package frontend
+
+import (
+	"net/http"
+	"net/http/pprof"
+	"strings"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+)
+
+// handleDebugPprof serves Go's runtime profiler under
+// /api/v1/debug/pprof/, delegating to the standard library's net/http/pprof
+// handlers. It's disabled unless EnablePprof is set, and always requires
+// admin authorization on top of that, since profiling data can reveal
+// request contents held in memory.
+func (a *API) handleDebugPprof(w http.ResponseWriter, r *http.Request) {
+	if !a.EnablePprof {
+		writeJSON(w, http.StatusNotFound, statusResponse{Status: statuscode.NotFound})
+		return
+	}
+	if !a.adminAuthorized(r) {
+		writeJSON(w, http.StatusUnauthorized, statusResponse{Status: statuscode.InvalidToken})
+		return
+	}
+
+	// The stdlib pprof handlers key their behavior off r.URL.Path,
+	// expecting to be mounted at /debug/pprof/; rewrite the path so they
+	// see the suffix they expect regardless of where we mounted them.
+	name := strings.TrimPrefix(r.URL.Path, "/api/v1/debug/pprof/")
+	switch name {
+	case "cmdline":
+		pprof.Cmdline(w, r)
+	case "profile":
+		pprof.Profile(w, r)
+	case "symbol":
+		pprof.Symbol(w, r)
+	case "trace":
+		pprof.Trace(w, r)
+	case "":
+		pprof.Index(w, r)
+	default:
+		pprof.Handler(name).ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,80 @@
+package frontend
+
+import (
+	"net/http"
+	"strconv"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+)
+
+// strictHTTPStatus reports whether the current request should get a real
+// HTTP status code for a statuscode.* failure instead of the legacy
+// always-200 envelope, per either the API-wide StrictHTTPStatus flag or a
+// per-request X-Strict-Status override.
+func (a *API) strictHTTPStatus(r *http.Request) bool {
+	if v := r.Header.Get("X-Strict-Status"); v != "" {
+		if b, err := strconv.ParseBool(v); err == nil {
+			return b
+		}
+	}
+	return a.StrictHTTPStatus
+}
+
+// writeStatusJSON writes resp with legacy, the HTTP status this endpoint
+// has always returned for code, unless strict HTTP status codes are
+// enabled for this request, in which case code is mapped to a real HTTP
+// status via httpStatusForCode instead. resp's own Status field and body
+// shape are unaffected either way; only the HTTP status line changes.
+func (a *API) writeStatusJSON(w http.ResponseWriter, r *http.Request, legacy int, code string, resp interface{}) {
+	status := legacy
+	if a.strictHTTPStatus(r) {
+		status = httpStatusForCode(code)
+	}
+	writeJSON(w, status, resp)
+}
+
+// httpStatusForCode maps a statuscode.* value to the HTTP status a strict
+// client would expect for it. Codes that already get a distinct HTTP
+// status in the legacy envelope (e.g. CircuitOpen -> 503) map to that same
+// status here, so enabling strict mode never changes a response that was
+// already using a real status code.
+func httpStatusForCode(code string) int {
+	switch code {
+	case statuscode.Ok:
+		return http.StatusOK
+	case statuscode.InvalidToken:
+		return http.StatusUnauthorized
+	case statuscode.PathBusy:
+		return http.StatusConflict
+	case statuscode.InvalidLease:
+		return http.StatusNotFound
+	case statuscode.InvalidPath:
+		return http.StatusUnprocessableEntity
+	case statuscode.InvalidKey:
+		return http.StatusForbidden
+	case statuscode.NotFound:
+		return http.StatusNotFound
+	case statuscode.TransactionAborted:
+		return http.StatusUnprocessableEntity
+	case statuscode.TransactionTooLarge:
+		return http.StatusRequestEntityTooLarge
+	case statuscode.CircuitOpen:
+		return http.StatusServiceUnavailable
+	case statuscode.Draining:
+		return http.StatusServiceUnavailable
+	case statuscode.Overloaded:
+		return http.StatusServiceUnavailable
+	case statuscode.ClockSkew:
+		return http.StatusGone
+	case statuscode.UnsupportedDigest:
+		return http.StatusUnprocessableEntity
+	case statuscode.Frozen:
+		return http.StatusLocked
+	case statuscode.PendingApproval:
+		return http.StatusAccepted
+	case statuscode.MaintenanceLocked:
+		return http.StatusLocked
+	default:
+		return http.StatusInternalServerError
+	}
+}
@@ -0,0 +1,145 @@
+package frontend
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/throttle"
+)
+
+// ErrSlowClient is returned by a payload upload's request body once
+// instrumentUpload's reader evicts it for stalling below
+// API.MinUploadBytesPerSecond for longer than
+// API.SlowClientEvictionTimeout.
+type ErrSlowClient struct {
+	Path              string
+	MinBytesPerSecond float64
+}
+
+func (e ErrSlowClient) Error() string {
+	return fmt.Sprintf("upload for %s stalled below %.0f bytes/sec for too long and was evicted", e.Path, e.MinBytesPerSecond)
+}
+
+// instrumentUpload wraps r.Body so reading it is capped at GlobalLimiter's
+// rate and, if keyID is non-empty and its access configuration sets a
+// bandwidth cap, that key's rate too, and so the pool's upload progress
+// tracker for path is kept up to date as bytes stream in. If
+// MinUploadBytesPerSecond is configured, a read that finds the upload has
+// stalled below it for longer than SlowClientEvictionTimeout fails with
+// ErrSlowClient instead of continuing to hold the connection open.
+// Callers should clear that progress once the read completes,
+// successfully or not. It must be called before the payload body is read.
+func (a *API) instrumentUpload(r *http.Request, path, keyID string) {
+	var reader io.Reader = &progressReader{
+		source:            r.Body,
+		pool:              a.Pool,
+		path:              path,
+		started:           time.Now(),
+		minBytesPerSecond: a.MinUploadBytesPerSecond,
+		stallTimeout:      a.SlowClientEvictionTimeout,
+	}
+
+	if a.GlobalLimiter != nil {
+		reader = &throttle.Reader{Source: reader, R: a.GlobalLimiter}
+	}
+	if keyID != "" {
+		if l := a.keyLimiter(keyID, a.accessFor(r).KeyBandwidth(keyID)); l != nil {
+			reader = &throttle.Reader{Source: reader, R: l}
+		}
+	}
+
+	r.Body = throttledBody{Reader: reader, Closer: r.Body}
+}
+
+// keyLimiter returns the cached Limiter enforcing keyID's configured
+// upload rate, creating one on first use. It returns nil if ratePerSecond
+// is not positive, meaning the key has no cap of its own.
+func (a *API) keyLimiter(keyID string, ratePerSecond int64) *throttle.Limiter {
+	if ratePerSecond <= 0 {
+		return nil
+	}
+
+	a.keyLimitersMu.Lock()
+	defer a.keyLimitersMu.Unlock()
+	if a.keyLimiters == nil {
+		a.keyLimiters = make(map[string]*throttle.Limiter)
+	}
+	l, ok := a.keyLimiters[keyID]
+	if !ok {
+		l = throttle.NewLimiter(ratePerSecond, ratePerSecond)
+		a.keyLimiters[keyID] = l
+	}
+	return l
+}
+
+// throttledBody pairs a throttled io.Reader with the original request
+// body's Closer, so wrapping r.Body for throttling doesn't lose the
+// ability to close the underlying connection.
+type throttledBody struct {
+	io.Reader
+	io.Closer
+}
+
+// progressReader records path's observed upload throughput in pool as it
+// reads from source, so a lease listing can report a live bytes-per-second
+// rate while the upload is still in flight. If minBytesPerSecond is
+// positive, it also watches a trailing one-second window of throughput and
+// evicts the upload with ErrSlowClient once that window has stayed below
+// minBytesPerSecond for longer than stallTimeout.
+type progressReader struct {
+	source  io.Reader
+	pool    *backend.Pool
+	path    string
+	started time.Time
+	total   int64
+
+	minBytesPerSecond float64
+	stallTimeout      time.Duration
+	windowStart       time.Time
+	windowBytes       int64
+	belowMinSince     time.Time
+}
+
+// slowClientWindow is how often progressReader re-evaluates the recent
+// throughput window against minBytesPerSecond.
+const slowClientWindow = time.Second
+
+func (r *progressReader) Read(p []byte) (int, error) {
+	n, err := r.source.Read(p)
+	if n > 0 {
+		r.total += int64(n)
+		r.windowBytes += int64(n)
+		if elapsed := time.Since(r.started).Seconds(); elapsed > 0 {
+			r.pool.RecordUploadProgress(r.path, float64(r.total)/elapsed)
+		}
+	}
+
+	if r.minBytesPerSecond <= 0 || r.stallTimeout <= 0 {
+		return n, err
+	}
+
+	now := time.Now()
+	if r.windowStart.IsZero() {
+		r.windowStart = now
+	}
+	if windowElapsed := now.Sub(r.windowStart); windowElapsed >= slowClientWindow {
+		rate := float64(r.windowBytes) / windowElapsed.Seconds()
+		r.windowBytes = 0
+		r.windowStart = now
+		if rate < r.minBytesPerSecond {
+			if r.belowMinSince.IsZero() {
+				r.belowMinSince = now
+			}
+		} else {
+			r.belowMinSince = time.Time{}
+		}
+	}
+	if !r.belowMinSince.IsZero() && now.Sub(r.belowMinSince) > r.stallTimeout {
+		return n, ErrSlowClient{Path: r.path, MinBytesPerSecond: r.minBytesPerSecond}
+	}
+
+	return n, err
+}
@@ -0,0 +1,373 @@
+package frontend
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/statuscode"
+)
+
+// v2Error is the body of every /api/v2 error response: a single message
+// under the standard HTTP status code already carried on the response
+// itself, rather than v1's convention of always answering 200 with a
+// "status" field a client has to inspect to learn what happened.
+type v2Error struct {
+	Error string `json:"error"`
+}
+
+func writeV2Error(w http.ResponseWriter, status int, err error) {
+	writeJSON(w, status, v2Error{Error: err.Error()})
+}
+
+func writeV2MethodNotAllowed(w http.ResponseWriter, allow string) {
+	w.Header().Set("Allow", allow)
+	writeV2Error(w, http.StatusMethodNotAllowed, fmt.Errorf("method not allowed"))
+}
+
+// v2StatusForAcquire maps acquireLease's v1 status code to the HTTP
+// status a REST client expects for the corresponding failure.
+func v2StatusForAcquire(status string) int {
+	switch status {
+	case statuscode.Ok:
+		return http.StatusCreated
+	case statuscode.InvalidKey:
+		return http.StatusForbidden
+	case statuscode.InvalidPath:
+		return http.StatusUnprocessableEntity
+	case statuscode.PathBusy:
+		return http.StatusConflict
+	case statuscode.Draining:
+		return http.StatusServiceUnavailable
+	case statuscode.Frozen:
+		return http.StatusLocked
+	default:
+		return http.StatusInternalServerError
+	}
+}
+
+// v2StatusForCommitError maps a CommitLease error to the HTTP status a
+// REST client expects, mirroring the same error classification
+// handleCommitLease uses for v1's status field.
+func v2StatusForCommitError(err error) int {
+	if err == backend.ErrTransactionTooLarge {
+		return http.StatusRequestEntityTooLarge
+	}
+	if _, ok := err.(backend.ErrCircuitOpen); ok {
+		return http.StatusServiceUnavailable
+	}
+	if _, ok := err.(backend.ErrApprovalRequired); ok {
+		return http.StatusAccepted
+	}
+	if _, ok := err.(backend.ErrMaintenanceLocked); ok {
+		return http.StatusLocked
+	}
+	return http.StatusUnprocessableEntity
+}
+
+// handleLeasesV2 implements the leases collection resource: GET lists
+// active leases, POST creates one.
+func (a *API) handleLeasesV2(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		a.listLeasesV2(w, r)
+	case http.MethodPost:
+		a.createLeaseV2(w, r)
+	default:
+		writeV2MethodNotAllowed(w, "GET, POST")
+	}
+}
+
+func (a *API) listLeasesV2(w http.ResponseWriter, r *http.Request) {
+	authenticated := a.readAuthenticated(r)
+	if a.RequireAuthForReads && !authenticated {
+		writeV2Error(w, http.StatusUnauthorized, fmt.Errorf("authentication required"))
+		return
+	}
+
+	leases, err := a.Pool.Leases.GetLeases()
+	if err != nil {
+		writeV2Error(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	repoFilter := r.URL.Query().Get("repo")
+	out := make([]backend.Lease, 0, len(leases))
+	for _, l := range leases {
+		if repoFilter != "" && l.Repository != repoFilter {
+			continue
+		}
+		if !authenticated {
+			l.KeyID = ""
+		}
+		out = append(out, l)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Path < out[j].Path })
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (a *API) createLeaseV2(w http.ResponseWriter, r *http.Request) {
+	release, ok := a.limitConcurrency(w, "new-lease")
+	if !ok {
+		return
+	}
+	defer release()
+
+	var req newLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeV2Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lease, status, err := a.acquireLease(r, req)
+	if err != nil {
+		writeV2Error(w, v2StatusForAcquire(status), err)
+		return
+	}
+	w.Header().Set("Location", "/api/v2/leases/"+lease.Path)
+	writeJSON(w, http.StatusCreated, lease)
+}
+
+// handleLeaseByPathV2 implements the lease item resource at
+// /api/v2/leases/{path}: GET reads it, DELETE cancels it. The nested
+// path /api/v2/leases/{path}/commit is handled as a sub-action, since
+// committing consumes the lease rather than reading or replacing it.
+//
+// Unlike v1, this does not yet support relaying to a remote gateway for
+// a repository configured with RemoteURL, or the async/scheduled commit
+// variants; a client that needs those still uses the v1 endpoints for
+// that path.
+func (a *API) handleLeaseByPathV2(w http.ResponseWriter, r *http.Request) {
+	rest := strings.TrimPrefix(r.URL.Path, "/api/v2/leases/")
+	if rest == "" {
+		writeV2Error(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+	if path, ok := strings.CutSuffix(rest, "/commit"); ok {
+		a.commitLeaseV2(w, r, path)
+		return
+	}
+
+	path := rest
+	switch r.Method {
+	case http.MethodGet:
+		a.getLeaseV2(w, r, path)
+	case http.MethodDelete:
+		a.cancelLeaseV2(w, r, path)
+	default:
+		writeV2MethodNotAllowed(w, "GET, DELETE")
+	}
+}
+
+func (a *API) getLeaseV2(w http.ResponseWriter, r *http.Request, path string) {
+	lease, err := a.Pool.Leases.GetLease(path)
+	if err != nil {
+		writeV2Error(w, http.StatusNotFound, err)
+		return
+	}
+	if !a.readAuthenticated(r) {
+		lease.KeyID = ""
+	}
+	writeJSON(w, http.StatusOK, lease)
+}
+
+func (a *API) cancelLeaseV2(w http.ResponseWriter, r *http.Request, path string) {
+	if _, ok := a.Relay.Remote(repositoryFromPath(path)); ok {
+		writeV2Error(w, http.StatusBadGateway, fmt.Errorf("path %s is hosted on a remote gateway; use the v1 API", path))
+		return
+	}
+	a.Pool.CancelScheduledCommit(path)
+	summary, err := a.Pool.CancelLease(path)
+	if err != nil {
+		writeV2Error(w, http.StatusNotFound, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+func (a *API) commitLeaseV2(w http.ResponseWriter, r *http.Request, path string) {
+	if r.Method != http.MethodPost {
+		writeV2MethodNotAllowed(w, "POST")
+		return
+	}
+	if _, ok := a.Relay.Remote(repositoryFromPath(path)); ok {
+		writeV2Error(w, http.StatusBadGateway, fmt.Errorf("path %s is hosted on a remote gateway; use the v1 API", path))
+		return
+	}
+
+	release, ok := a.limitConcurrency(w, "commit")
+	if !ok {
+		return
+	}
+	defer release()
+
+	token := bearerToken(r)
+	if token == "" {
+		writeV2Error(w, http.StatusUnauthorized, fmt.Errorf("missing session token"))
+		return
+	}
+
+	var keyID string
+	if a.SigningKey != nil {
+		claims, err := a.SigningKey.ParseToken(token)
+		if err != nil {
+			writeV2Error(w, http.StatusUnauthorized, err)
+			return
+		}
+		if claims.Path != path {
+			writeV2Error(w, http.StatusUnauthorized, fmt.Errorf("token was not issued for this path"))
+			return
+		}
+		if now := time.Now(); now.After(claims.Expiry.Add(a.ClockSkewTolerance)) {
+			writeV2Error(w, http.StatusUnauthorized, fmt.Errorf("lease token has expired"))
+			return
+		}
+		if a.redirectToInstance(w, r, claims.Instance) {
+			return
+		}
+		keyID = claims.KeyID
+	}
+
+	reqID := requestID(r)
+	w.Header().Set("X-Request-ID", reqID)
+	a.instrumentUpload(r, path, keyID)
+	defer a.Pool.ClearUploadProgress(path)
+
+	digest, tag, body, err := a.readPayload(r)
+	if err != nil {
+		writeV2Error(w, http.StatusBadRequest, err)
+		return
+	}
+	payload := receiver.Payload{Digest: digest, Data: body, Tag: tag, RequestID: reqID, ManifestDigests: manifestDigests(r)}
+
+	summary, err := a.Pool.CommitLease(token, path, payload)
+	if err != nil {
+		writeV2Error(w, v2StatusForCommitError(err), err)
+		return
+	}
+	writeJSON(w, http.StatusOK, summary)
+}
+
+// jobResourceV2 mirrors the subset of jobqueue.Job safe to expose over
+// the API: its Data field can hold an in-flight commit's full payload
+// bytes, which must never be serialized back to a client.
+type jobResourceV2 struct {
+	ID    string `json:"id"`
+	Type  string `json:"type"`
+	State string `json:"state"`
+	Error string `json:"error,omitempty"`
+}
+
+// handleJobStatusV2 implements the job item resource at
+// /api/v2/jobs/{id}.
+func (a *API) handleJobStatusV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeV2MethodNotAllowed(w, "GET")
+		return
+	}
+	id := strings.TrimPrefix(r.URL.Path, "/api/v2/jobs/")
+	job, ok := a.Pool.Jobs.Get(id)
+	if !ok {
+		writeV2Error(w, http.StatusNotFound, fmt.Errorf("job %s not found", id))
+		return
+	}
+	writeJSON(w, http.StatusOK, jobResourceV2{ID: job.ID, Type: job.Type, State: string(job.State), Error: job.Error})
+}
+
+// repoResourceV2 describes a repository as an /api/v2/repos resource.
+type repoResourceV2 struct {
+	Name               string   `json:"name"`
+	MaxTransactionSize int64    `json:"max_transaction_size,omitempty"`
+	Protected          bool     `json:"protected,omitempty"`
+	MirrorURLs         []string `json:"mirror_urls,omitempty"`
+}
+
+func (a *API) repoResourceV2(acc *access.Config, name string) repoResourceV2 {
+	return repoResourceV2{
+		Name:               name,
+		MaxTransactionSize: acc.MaxTransactionSize(name),
+		Protected:          acc.Protected(name),
+		MirrorURLs:         acc.MirrorURLs(name),
+	}
+}
+
+// handleReposV2 implements the repositories collection resource: GET
+// lists every repository in the caller's access configuration.
+func (a *API) handleReposV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeV2MethodNotAllowed(w, "GET")
+		return
+	}
+	acc := a.accessFor(r)
+	names := acc.Repos()
+	out := make([]repoResourceV2, len(names))
+	for i, name := range names {
+		out[i] = a.repoResourceV2(acc, name)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+// handleRepoByNameV2 implements the repository item resource at
+// /api/v2/repos/{name}.
+func (a *API) handleRepoByNameV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		writeV2MethodNotAllowed(w, "GET")
+		return
+	}
+	name := strings.TrimPrefix(r.URL.Path, "/api/v2/repos/")
+	if name == "" {
+		writeV2Error(w, http.StatusNotFound, fmt.Errorf("not found"))
+		return
+	}
+	acc := a.accessFor(r)
+	if !acc.KnownRepo(name) {
+		writeV2Error(w, http.StatusNotFound, fmt.Errorf("repository %s not found", name))
+		return
+	}
+	writeJSON(w, http.StatusOK, a.repoResourceV2(acc, name))
+}
+
+// sessionResourceV2 is the response to creating a publishing session: a
+// lease plus the URL a client submits its payload to, for callers who
+// think in terms of "start a session, upload, done" rather than
+// directly managing a lease resource.
+type sessionResourceV2 struct {
+	backend.Lease
+	UploadURL string `json:"upload_url"`
+}
+
+// handleSessionsV2 implements the sessions collection resource: POST
+// creates a session, which is a lease presented with its upload URL
+// attached. There is no session item resource distinct from the lease
+// it wraps; a client manages it thereafter via /api/v2/leases/{path}.
+func (a *API) handleSessionsV2(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		writeV2MethodNotAllowed(w, "POST")
+		return
+	}
+	release, ok := a.limitConcurrency(w, "new-lease")
+	if !ok {
+		return
+	}
+	defer release()
+
+	var req newLeaseRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeV2Error(w, http.StatusBadRequest, err)
+		return
+	}
+
+	lease, status, err := a.acquireLease(r, req)
+	if err != nil {
+		writeV2Error(w, v2StatusForAcquire(status), err)
+		return
+	}
+	w.Header().Set("Location", "/api/v2/leases/"+lease.Path)
+	writeJSON(w, http.StatusCreated, sessionResourceV2{Lease: lease, UploadURL: "/api/v2/leases/" + lease.Path})
+}
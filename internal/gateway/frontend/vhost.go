@@ -0,0 +1,56 @@
+package frontend
+
+import (
+	"net"
+	"net/http"
+	"strings"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+)
+
+// VirtualHosts selects an access configuration by the request's Host
+// header, so a single gateway process can serve multiple independent
+// sets of keys and repositories ("virtual hosting") in place of one
+// daemon per experiment.
+type VirtualHosts struct {
+	byHost   map[string]*access.Config
+	fallback *access.Config
+}
+
+// NewVirtualHosts returns an empty registry that serves fallback for any
+// Host header it doesn't recognize.
+func NewVirtualHosts(fallback *access.Config) *VirtualHosts {
+	return &VirtualHosts{byHost: make(map[string]*access.Config), fallback: fallback}
+}
+
+// Add registers cfg as the access configuration served for host.
+func (v *VirtualHosts) Add(host string, cfg *access.Config) {
+	v.byHost[strings.ToLower(host)] = cfg
+}
+
+// For returns the access configuration registered for r's Host header,
+// or the fallback configuration if the host isn't recognized.
+func (v *VirtualHosts) For(r *http.Request) *access.Config {
+	host := r.Host
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if cfg, ok := v.byHost[strings.ToLower(host)]; ok {
+		return cfg
+	}
+	return v.fallback
+}
+
+// All returns every access configuration known to the registry,
+// including the fallback. It's used to wire pool-level lookups (mirror
+// URLs, content policy, tag templates) that are keyed by repository
+// name rather than by host, since a repository name is global regardless
+// of which virtual host a client used to reach it.
+func (v *VirtualHosts) All() []*access.Config {
+	all := make([]*access.Config, 0, len(v.byHost)+1)
+	all = append(all, v.fallback)
+	for _, cfg := range v.byHost {
+		all = append(all, cfg)
+	}
+	return all
+}
@@ -0,0 +1,148 @@
+// Package ingest implements the gateway's experimental receiver-less
+// commit path: it unpacks a payload's object pack and
+// writes each object directly to an objectstore.Store in pure Go,
+// instead of handing the whole payload to the external cvmfs_receiver
+// binary to unpack. It's meant for object-store-only deployments that
+// don't need the external receiver's catalog-management logic at all,
+// and for hybrid deployments that still want it for the final commit but
+// not for the I/O-heavy bulk object writes.
+package ingest
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/objectcache"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/objectstore"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// PackEntry is a single object framed inside a payload pack.
+type PackEntry struct {
+	Digest string
+	Data   []byte
+}
+
+// ParsePack decodes a payload's Data as a sequence of framed objects:
+// each entry is a big-endian uint16 digest length, the digest itself,
+// a big-endian uint64 data length, and the data itself, repeated until
+// EOF.
+func ParsePack(data []byte) ([]PackEntry, error) {
+	var entries []PackEntry
+	buf := data
+	for len(buf) > 0 {
+		if len(buf) < 2 {
+			return nil, fmt.Errorf("ingest: truncated pack: short digest length header")
+		}
+		digestLen := binary.BigEndian.Uint16(buf)
+		buf = buf[2:]
+		if len(buf) < int(digestLen) {
+			return nil, fmt.Errorf("ingest: truncated pack: short digest")
+		}
+		digest := string(buf[:digestLen])
+		buf = buf[digestLen:]
+
+		if len(buf) < 8 {
+			return nil, fmt.Errorf("ingest: truncated pack: short data length header")
+		}
+		dataLen := binary.BigEndian.Uint64(buf)
+		buf = buf[8:]
+		if uint64(len(buf)) < dataLen {
+			return nil, fmt.Errorf("ingest: truncated pack: short object data")
+		}
+		entries = append(entries, PackEntry{Digest: digest, Data: buf[:dataLen]})
+		buf = buf[dataLen:]
+	}
+	return entries, nil
+}
+
+// WritePack encodes entries in the format ParsePack decodes, mainly for
+// tests and for a future publisher-side pack writer.
+func WritePack(w io.Writer, entries []PackEntry) error {
+	for _, e := range entries {
+		var lenBuf [8]byte
+		binary.BigEndian.PutUint16(lenBuf[:2], uint16(len(e.Digest)))
+		if _, err := w.Write(lenBuf[:2]); err != nil {
+			return err
+		}
+		if _, err := io.WriteString(w, e.Digest); err != nil {
+			return err
+		}
+		binary.BigEndian.PutUint64(lenBuf[:8], uint64(len(e.Data)))
+		if _, err := w.Write(lenBuf[:8]); err != nil {
+			return err
+		}
+		if _, err := w.Write(e.Data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DirectReceiver implements receiver.Receiver by unpacking a commit's
+// payload and writing every object it contains straight to Store,
+// bypassing the external cvmfs_receiver binary for that part entirely.
+type DirectReceiver struct {
+	Store objectstore.Store
+
+	// Finalizer, if set, still runs after every object in the pack has
+	// been written to Store, receiving the original payload unchanged so
+	// it can perform whatever catalog-commit logic it needs. Nil-safe: a
+	// nil Finalizer means Commit returns success once objects are
+	// written, appropriate only for an object-store-only deployment with
+	// no separate catalog to maintain.
+	Finalizer receiver.Receiver
+
+	// Cache, if set, records every object's digest as known to exist
+	// once it's been written to Store, so a later objects/missing query
+	// can tell a publisher not to bother re-uploading it. Nil-safe: a
+	// nil Cache just skips recording.
+	Cache *objectcache.Cache
+}
+
+// NewDirectReceiver returns a DirectReceiver that writes objects to
+// store and, if finalizer is non-nil, delegates the catalog commit to it
+// afterwards.
+func NewDirectReceiver(store objectstore.Store, finalizer receiver.Receiver) *DirectReceiver {
+	return &DirectReceiver{Store: store, Finalizer: finalizer}
+}
+
+// Commit unpacks payload.Data and writes every object it contains to
+// Store, then, if payload.ManifestDigests names any object Data didn't
+// include, confirms it's already known to Cache before proceeding — a
+// publisher that skipped uploading an object based on a stale or wrong
+// objects/missing answer must not be allowed to commit a catalog that
+// references content the gateway doesn't actually have. Finally it
+// delegates to Finalizer, if configured.
+func (d *DirectReceiver) Commit(repository, path string, payload receiver.Payload) error {
+	entries, err := ParsePack(payload.Data)
+	if err != nil {
+		return fmt.Errorf("ingest: could not unpack payload for %s: %w", path, err)
+	}
+
+	uploaded := make(map[string]bool, len(entries))
+	for _, e := range entries {
+		if err := d.Store.Put(e.Digest, e.Data); err != nil {
+			return fmt.Errorf("ingest: could not write object %s to store: %w", e.Digest, err)
+		}
+		uploaded[e.Digest] = true
+		if d.Cache != nil {
+			d.Cache.Add(e.Digest)
+		}
+	}
+
+	for _, digest := range payload.ManifestDigests {
+		if uploaded[digest] {
+			continue
+		}
+		if d.Cache == nil || !d.Cache.Contains(digest) {
+			return fmt.Errorf("ingest: manifest for %s references object %s, which was omitted from the pack and is not known to exist", path, digest)
+		}
+	}
+
+	if d.Finalizer == nil {
+		return nil
+	}
+	return d.Finalizer.Commit(repository, path, payload)
+}
@@ -0,0 +1,128 @@
+package ingest
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/objectcache"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+func TestParsePackRoundTripsWritePack(t *testing.T) {
+	want := []PackEntry{
+		{Digest: "aaaa", Data: []byte("first object")},
+		{Digest: "bbbb", Data: []byte("second object")},
+	}
+
+	var buf bytes.Buffer
+	if err := WritePack(&buf, want); err != nil {
+		t.Fatalf("WritePack: %v", err)
+	}
+
+	got, err := ParsePack(buf.Bytes())
+	if err != nil {
+		t.Fatalf("ParsePack: %v", err)
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d entries, got %d", len(want), len(got))
+	}
+	for i := range want {
+		if got[i].Digest != want[i].Digest || string(got[i].Data) != string(want[i].Data) {
+			t.Fatalf("entry %d mismatch: got %+v, want %+v", i, got[i], want[i])
+		}
+	}
+}
+
+func TestParsePackRejectsTruncatedData(t *testing.T) {
+	if _, err := ParsePack([]byte{0, 4, 'a', 'b'}); err == nil {
+		t.Fatal("expected an error on a truncated pack")
+	}
+}
+
+type fakeStore struct {
+	puts map[string][]byte
+}
+
+func (s *fakeStore) Put(digest string, data []byte) error {
+	if s.puts == nil {
+		s.puts = make(map[string][]byte)
+	}
+	s.puts[digest] = data
+	return nil
+}
+
+type fakeFinalizer struct {
+	committed bool
+}
+
+func (f *fakeFinalizer) Commit(repository, path string, payload receiver.Payload) error {
+	f.committed = true
+	return nil
+}
+
+func TestDirectReceiverWritesEveryObjectAndDelegatesFinalize(t *testing.T) {
+	store := &fakeStore{}
+	finalizer := &fakeFinalizer{}
+	d := NewDirectReceiver(store, finalizer)
+
+	var buf bytes.Buffer
+	WritePack(&buf, []PackEntry{{Digest: "abc123", Data: []byte("payload bytes")}})
+
+	if err := d.Commit("repo.example.org", "repo.example.org/a", receiver.Payload{Data: buf.Bytes()}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if string(store.puts["abc123"]) != "payload bytes" {
+		t.Fatalf("expected object to be written to the store, got %+v", store.puts)
+	}
+	if !finalizer.committed {
+		t.Fatal("expected the finalizer to be delegated to")
+	}
+}
+
+func TestDirectReceiverAllowsManifestDigestAlreadyCached(t *testing.T) {
+	store := &fakeStore{}
+	cache := objectcache.New(10)
+	cache.Add("already-present")
+	d := NewDirectReceiver(store, nil)
+	d.Cache = cache
+
+	var buf bytes.Buffer
+	WritePack(&buf, []PackEntry{{Digest: "new-object", Data: []byte("payload bytes")}})
+
+	err := d.Commit("repo.example.org", "repo.example.org/a", receiver.Payload{
+		Data:            buf.Bytes(),
+		ManifestDigests: []string{"new-object", "already-present"},
+	})
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func TestDirectReceiverRejectsManifestDigestNotUploadedOrCached(t *testing.T) {
+	store := &fakeStore{}
+	d := NewDirectReceiver(store, nil)
+	d.Cache = objectcache.New(10)
+
+	var buf bytes.Buffer
+	WritePack(&buf, []PackEntry{{Digest: "new-object", Data: []byte("payload bytes")}})
+
+	err := d.Commit("repo.example.org", "repo.example.org/a", receiver.Payload{
+		Data:            buf.Bytes(),
+		ManifestDigests: []string{"new-object", "unknown-object"},
+	})
+	if err == nil {
+		t.Fatal("expected an error when the manifest references an object neither uploaded nor cached")
+	}
+}
+
+func TestDirectReceiverSucceedsWithoutFinalizer(t *testing.T) {
+	store := &fakeStore{}
+	d := NewDirectReceiver(store, nil)
+
+	var buf bytes.Buffer
+	WritePack(&buf, []PackEntry{{Digest: "abc123", Data: []byte("payload bytes")}})
+
+	if err := d.Commit("repo.example.org", "repo.example.org/a", receiver.Payload{Data: buf.Bytes()}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
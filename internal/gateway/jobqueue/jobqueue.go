@@ -0,0 +1,344 @@
+// Package jobqueue implements a persistent, retrying job subsystem shared
+// by the gateway's long-running background operations: asynchronous
+// commits, garbage collection runs, and lease cleanups. It replaces
+// ad-hoc goroutine dispatch with durable job records, a bounded worker
+// pool, and a status API that survives across individual requests.
+package jobqueue
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// State is the lifecycle state of a job record.
+type State string
+
+const (
+	Pending  State = "pending"
+	Running  State = "running"
+	Done     State = "done"
+	Failed   State = "failed"
+	Retrying State = "retrying"
+)
+
+// RetryPolicy controls how many times a failed job is retried and how
+// long to wait between attempts.
+type RetryPolicy struct {
+	MaxAttempts int
+	Backoff     time.Duration
+}
+
+// DefaultRetryPolicy retries a job twice with a five-second backoff.
+var DefaultRetryPolicy = RetryPolicy{MaxAttempts: 3, Backoff: 5 * time.Second}
+
+// Job is a durable record of a single unit of background work. Data
+// carries the type-specific arguments a handler needs to execute the
+// job; a SQL-backed Store would serialize it, the in-memory one keeps it
+// as-is.
+type Job struct {
+	ID        string
+	Type      string
+	State     State
+	Attempts  int
+	Error     string
+	Data      interface{}
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+// Handler performs the work for a job of a given type.
+type Handler func(job Job) error
+
+// Describer is implemented by a job's Data to surface a human-readable
+// summary -- typically the repository path it's operating on -- in
+// WorkerStatuses, without jobqueue needing to know about any job type's
+// argument struct. A Data value that doesn't implement it is reported
+// with no description.
+type Describer interface {
+	JobDescription() string
+}
+
+// Store persists job records. Implementations must be safe for
+// concurrent use. The in-memory implementation in this package is meant
+// for tests and single-instance deployments; a SQL-backed one can
+// implement the same interface for durability across restarts.
+type Store interface {
+	Put(job Job) error
+	Get(id string) (Job, bool, error)
+	All() ([]Job, error)
+}
+
+// MemStore is an in-memory Store.
+type MemStore struct {
+	mu   sync.Mutex
+	jobs map[string]Job
+}
+
+// NewMemStore returns an empty in-memory job store.
+func NewMemStore() *MemStore {
+	return &MemStore{jobs: make(map[string]Job)}
+}
+
+func (s *MemStore) Put(job Job) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.jobs[job.ID] = job
+	return nil
+}
+
+func (s *MemStore) Get(id string) (Job, bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	j, ok := s.jobs[id]
+	return j, ok, nil
+}
+
+func (s *MemStore) All() ([]Job, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	out := make([]Job, 0, len(s.jobs))
+	for _, j := range s.jobs {
+		out = append(out, j)
+	}
+	return out, nil
+}
+
+// workerIdleTimeout is how long an auto-scaled worker waits for a job
+// before exiting and shrinking the pool back down.
+const workerIdleTimeout = 10 * time.Second
+
+// scaleCheckInterval is how often the auto-scaler re-evaluates queue
+// depth against the current worker count.
+const scaleCheckInterval = time.Second
+
+// Queue dispatches jobs to an auto-scaling pool of worker goroutines,
+// retrying failed jobs per their retry policy and persisting state
+// transitions to Store. The pool grows toward maxWorkers when the queue
+// backs up and shrinks back to minWorkers as workers sit idle.
+type Queue struct {
+	Store    Store
+	Policy   RetryPolicy
+	handlers map[string]Handler
+
+	minWorkers int
+	maxWorkers int
+	active     int32
+	nextWorker int32
+
+	work chan Job
+	wg   sync.WaitGroup
+
+	statusMu sync.Mutex
+	statuses map[int]WorkerStatus
+}
+
+// WorkerStatus is a point-in-time snapshot of a single worker goroutine,
+// returned by WorkerStatuses for a live view of what the pool is doing --
+// indispensable when diagnosing why publishes are queueing.
+type WorkerStatus struct {
+	ID int `json:"id"`
+
+	// Busy is false while the worker is parked waiting for its next job.
+	Busy bool `json:"busy"`
+
+	// JobType and JobID identify the job currently running, and are
+	// empty while Busy is false.
+	JobType string `json:"job_type,omitempty"`
+	JobID   string `json:"job_id,omitempty"`
+
+	// Description is the job's Describer.JobDescription(), if its Data
+	// implements it -- typically a repository or lease path. Empty if
+	// the job's Data doesn't implement Describer, or the worker is idle.
+	Description string `json:"description,omitempty"`
+
+	// Since is when the worker entered its current Busy state, so
+	// WorkerStatuses can report how long a job has been running (or how
+	// long a worker has sat idle).
+	Since time.Time `json:"since"`
+}
+
+// NewQueue starts a Queue with minWorkers permanent worker goroutines,
+// scaling up to maxWorkers under load, backed by store.
+func NewQueue(store Store, minWorkers, maxWorkers int) *Queue {
+	if maxWorkers < minWorkers {
+		maxWorkers = minWorkers
+	}
+	q := &Queue{
+		Store:      store,
+		Policy:     DefaultRetryPolicy,
+		handlers:   make(map[string]Handler),
+		minWorkers: minWorkers,
+		maxWorkers: maxWorkers,
+		work:       make(chan Job, 64),
+		statuses:   make(map[int]WorkerStatus),
+	}
+	for i := 0; i < minWorkers; i++ {
+		q.spawnWorker(false)
+	}
+	go q.scale()
+	return q
+}
+
+// ActiveWorkers returns the current number of live worker goroutines.
+func (q *Queue) ActiveWorkers() int {
+	return int(atomic.LoadInt32(&q.active))
+}
+
+// QueueDepth returns the number of jobs currently buffered and waiting
+// for a free worker, not counting jobs already handed to one.
+func (q *Queue) QueueDepth() int {
+	return len(q.work)
+}
+
+// WorkerStatuses returns a snapshot of every live worker goroutine,
+// ordered by ID, for a telemetry endpoint to report worker count,
+// busy/idle state, current task, and task age.
+func (q *Queue) WorkerStatuses() []WorkerStatus {
+	q.statusMu.Lock()
+	defer q.statusMu.Unlock()
+	out := make([]WorkerStatus, 0, len(q.statuses))
+	for _, s := range q.statuses {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].ID < out[j].ID })
+	return out
+}
+
+func (q *Queue) setStatus(s WorkerStatus) {
+	q.statusMu.Lock()
+	q.statuses[s.ID] = s
+	q.statusMu.Unlock()
+}
+
+func (q *Queue) clearStatus(id int) {
+	q.statusMu.Lock()
+	delete(q.statuses, id)
+	q.statusMu.Unlock()
+}
+
+func (q *Queue) spawnWorker(canShrink bool) {
+	atomic.AddInt32(&q.active, 1)
+	id := int(atomic.AddInt32(&q.nextWorker, 1))
+	q.setStatus(WorkerStatus{ID: id, Busy: false, Since: time.Now()})
+	q.wg.Add(1)
+	go q.worker(id, canShrink)
+}
+
+// scale periodically grows the pool toward maxWorkers when the queue has
+// a backlog and there is room to add workers.
+func (q *Queue) scale() {
+	ticker := time.NewTicker(scaleCheckInterval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if len(q.work) > 0 && q.ActiveWorkers() < q.maxWorkers {
+			q.spawnWorker(true)
+		}
+	}
+}
+
+// RegisterHandler associates jobType with the function that executes
+// jobs of that type.
+func (q *Queue) RegisterHandler(jobType string, h Handler) {
+	q.handlers[jobType] = h
+}
+
+func newJobID() string {
+	buf := make([]byte, 8)
+	rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// Enqueue creates and persists a new job of jobType, carrying data as
+// the handler's arguments, and schedules it for execution, returning
+// immediately.
+func (q *Queue) Enqueue(jobType string, data interface{}) (Job, error) {
+	if _, ok := q.handlers[jobType]; !ok {
+		return Job{}, fmt.Errorf("jobqueue: no handler registered for type %q", jobType)
+	}
+	now := time.Now()
+	job := Job{ID: newJobID(), Type: jobType, State: Pending, Data: data, CreatedAt: now, UpdatedAt: now}
+	if err := q.Store.Put(job); err != nil {
+		return Job{}, err
+	}
+	q.work <- job
+	return job, nil
+}
+
+// Get returns the current state of a job.
+func (q *Queue) Get(id string) (Job, bool) {
+	job, ok, _ := q.Store.Get(id)
+	return job, ok
+}
+
+// worker processes jobs from the queue. A permanent worker (canShrink
+// false) runs until the queue is closed; an auto-scaled worker exits
+// after sitting idle for workerIdleTimeout, shrinking the pool back down.
+func (q *Queue) worker(id int, canShrink bool) {
+	defer q.wg.Done()
+	defer atomic.AddInt32(&q.active, -1)
+	defer q.clearStatus(id)
+
+	if !canShrink {
+		for job := range q.work {
+			q.run(id, job)
+			q.setStatus(WorkerStatus{ID: id, Busy: false, Since: time.Now()})
+		}
+		return
+	}
+
+	for {
+		select {
+		case job, ok := <-q.work:
+			if !ok {
+				return
+			}
+			q.run(id, job)
+			q.setStatus(WorkerStatus{ID: id, Busy: false, Since: time.Now()})
+		case <-time.After(workerIdleTimeout):
+			return
+		}
+	}
+}
+
+func jobDescription(job Job) string {
+	if d, ok := job.Data.(Describer); ok {
+		return d.JobDescription()
+	}
+	return ""
+}
+
+func (q *Queue) run(id int, job Job) {
+	q.setStatus(WorkerStatus{ID: id, Busy: true, JobType: job.Type, JobID: job.ID, Description: jobDescription(job), Since: time.Now()})
+
+	handler := q.handlers[job.Type]
+	job.Attempts++
+	job.State = Running
+	job.UpdatedAt = time.Now()
+	q.Store.Put(job)
+
+	err := handler(job)
+	job.UpdatedAt = time.Now()
+	if err == nil {
+		job.State = Done
+		job.Error = ""
+		q.Store.Put(job)
+		return
+	}
+
+	job.Error = err.Error()
+	if job.Attempts >= q.Policy.MaxAttempts {
+		job.State = Failed
+		q.Store.Put(job)
+		return
+	}
+	job.State = Retrying
+	q.Store.Put(job)
+	time.AfterFunc(q.Policy.Backoff, func() {
+		q.work <- job
+	})
+}
@@ -0,0 +1,134 @@
+package jobqueue
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func waitForState(t *testing.T, q *Queue, id string, want State) Job {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		job, ok := q.Get(id)
+		if ok && job.State == want {
+			return job
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Fatalf("job %s did not reach state %q in time", id, want)
+	return Job{}
+}
+
+func TestQueueRunsRegisteredHandler(t *testing.T) {
+	q := NewQueue(NewMemStore(), 2, 2)
+	q.RegisterHandler("noop", func(job Job) error { return nil })
+
+	job, err := q.Enqueue("noop", nil)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	got := waitForState(t, q, job.ID, Done)
+	if got.Attempts != 1 {
+		t.Fatalf("expected 1 attempt, got %d", got.Attempts)
+	}
+}
+
+func TestQueueRetriesUntilFailed(t *testing.T) {
+	q := NewQueue(NewMemStore(), 1, 1)
+	q.Policy = RetryPolicy{MaxAttempts: 2, Backoff: time.Millisecond}
+	q.RegisterHandler("always-fails", func(job Job) error { return errors.New("nope") })
+
+	job, err := q.Enqueue("always-fails", nil)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	got := waitForState(t, q, job.ID, Failed)
+	if got.Attempts != 2 {
+		t.Fatalf("expected 2 attempts, got %d", got.Attempts)
+	}
+}
+
+func TestQueueRejectsUnknownType(t *testing.T) {
+	q := NewQueue(NewMemStore(), 1, 1)
+	if _, err := q.Enqueue("unregistered", nil); err == nil {
+		t.Fatal("expected error for unregistered job type")
+	}
+}
+
+type describableArgs struct {
+	Path string
+}
+
+func (a describableArgs) JobDescription() string { return a.Path }
+
+func TestWorkerStatusesReportsBusyWorkerWithDescription(t *testing.T) {
+	q := NewQueue(NewMemStore(), 1, 1)
+	release := make(chan struct{})
+	q.RegisterHandler("slow", func(job Job) error {
+		<-release
+		return nil
+	})
+
+	if _, err := q.Enqueue("slow", describableArgs{Path: "repo.example.org/a"}); err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+
+	deadline := time.Now().Add(time.Second)
+	var statuses []WorkerStatus
+	for time.Now().Before(deadline) {
+		statuses = q.WorkerStatuses()
+		if len(statuses) == 1 && statuses[0].Busy {
+			break
+		}
+		time.Sleep(time.Millisecond)
+	}
+	close(release)
+
+	if len(statuses) != 1 || !statuses[0].Busy {
+		t.Fatalf("expected exactly one busy worker, got %+v", statuses)
+	}
+	if statuses[0].JobType != "slow" {
+		t.Fatalf("expected job type %q, got %q", "slow", statuses[0].JobType)
+	}
+	if statuses[0].Description != "repo.example.org/a" {
+		t.Fatalf("expected description from Describer, got %q", statuses[0].Description)
+	}
+}
+
+func TestWorkerStatusesReportsIdleWorkerWithoutDescription(t *testing.T) {
+	q := NewQueue(NewMemStore(), 1, 1)
+	q.RegisterHandler("noop", func(job Job) error { return nil })
+
+	statuses := q.WorkerStatuses()
+	if len(statuses) != 1 || statuses[0].Busy {
+		t.Fatalf("expected exactly one idle worker, got %+v", statuses)
+	}
+	if statuses[0].JobType != "" || statuses[0].Description != "" {
+		t.Fatalf("expected no job type or description while idle, got %+v", statuses[0])
+	}
+}
+
+func TestQueueScalesUpUnderLoad(t *testing.T) {
+	q := NewQueue(NewMemStore(), 1, 4)
+	release := make(chan struct{})
+	q.RegisterHandler("slow", func(job Job) error {
+		<-release
+		return nil
+	})
+
+	for i := 0; i < 4; i++ {
+		if _, err := q.Enqueue("slow", nil); err != nil {
+			t.Fatalf("Enqueue: %v", err)
+		}
+	}
+
+	deadline := time.Now().Add(2 * time.Second)
+	for q.ActiveWorkers() < 4 && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if got := q.ActiveWorkers(); got < 2 {
+		t.Fatalf("expected the pool to scale beyond its minimum, got %d active workers", got)
+	}
+	close(release)
+}
@@ -0,0 +1,83 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// GraphiteSink pushes observations to a Graphite carbon receiver over a
+// persistent TCP connection, using carbon's plaintext protocol
+// ("path value timestamp\n"). Since Graphite has no notion of tags,
+// tags are folded into the metric path as ".k.v" segments, sorted for a
+// stable path across calls with the same tag set.
+type GraphiteSink struct {
+	addr string
+	now  func() time.Time
+
+	mu   sync.Mutex
+	conn net.Conn
+}
+
+// NewGraphiteSink returns a sink that lazily dials addr (host:port) on
+// its first write, and redials automatically if the connection drops.
+func NewGraphiteSink(addr string) *GraphiteSink {
+	return &GraphiteSink{addr: addr, now: time.Now}
+}
+
+func (s *GraphiteSink) Count(name string, delta int64, tags map[string]string) {
+	s.send(name, fmt.Sprintf("%d", delta), tags)
+}
+
+func (s *GraphiteSink) Gauge(name string, value float64, tags map[string]string) {
+	s.send(name, fmt.Sprintf("%v", value), tags)
+}
+
+func (s *GraphiteSink) Observe(name string, value float64, tags map[string]string) {
+	s.send(name, fmt.Sprintf("%v", value), tags)
+}
+
+func (s *GraphiteSink) send(name, value string, tags map[string]string) {
+	path := graphitePath(name, tags)
+	line := fmt.Sprintf("%s %s %d\n", path, value, s.now().Unix())
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.conn == nil {
+		conn, err := net.Dial("tcp", s.addr)
+		if err != nil {
+			// A carbon receiver being briefly unreachable should never
+			// disrupt the publishing path that's being instrumented.
+			return
+		}
+		s.conn = conn
+	}
+	if _, err := s.conn.Write([]byte(line)); err != nil {
+		s.conn.Close()
+		s.conn = nil
+	}
+}
+
+func graphitePath(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+	names := make([]string, 0, len(tags))
+	for k := range tags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(name)
+	for _, k := range names {
+		b.WriteByte('.')
+		b.WriteString(k)
+		b.WriteByte('.')
+		b.WriteString(tags[k])
+	}
+	return b.String()
+}
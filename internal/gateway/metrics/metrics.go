@@ -0,0 +1,30 @@
+// Package metrics abstracts emission of gateway instrumentation behind a
+// small Sink interface, so a site can push counters and timings to
+// StatsD or Graphite, or expose them for a Prometheus scrape, without
+// changing any instrumentation call sites in backend or frontend code.
+package metrics
+
+// Sink receives counter, gauge, and timing observations from
+// instrumented gateway code paths. Tags are a set of key/value labels
+// describing the observation (e.g. "repository", "task"); a Sink that
+// doesn't support labels may fold them into the metric name instead.
+type Sink interface {
+	// Count adds delta to the named counter.
+	Count(name string, delta int64, tags map[string]string)
+
+	// Gauge sets the named gauge to value.
+	Gauge(name string, value float64, tags map[string]string)
+
+	// Observe records value as a sample for the named histogram or
+	// timing distribution.
+	Observe(name string, value float64, tags map[string]string)
+}
+
+// NopSink discards every observation. It is the zero value a Pool falls
+// back to when no metrics backend is configured, so instrumented code
+// never has to nil-check its Sink.
+type NopSink struct{}
+
+func (NopSink) Count(name string, delta int64, tags map[string]string)     {}
+func (NopSink) Gauge(name string, value float64, tags map[string]string)   {}
+func (NopSink) Observe(name string, value float64, tags map[string]string) {}
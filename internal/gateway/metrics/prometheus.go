@@ -0,0 +1,108 @@
+package metrics
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// PrometheusSink accumulates observations in memory and renders them in
+// the Prometheus text exposition format on demand, for a scrape endpoint
+// to serve. It requires no external client library.
+type PrometheusSink struct {
+	mu       sync.Mutex
+	counters map[string]float64
+	gauges   map[string]float64
+	obsCount map[string]uint64
+	obsSum   map[string]float64
+}
+
+// NewPrometheusSink returns an empty PrometheusSink.
+func NewPrometheusSink() *PrometheusSink {
+	return &PrometheusSink{
+		counters: make(map[string]float64),
+		gauges:   make(map[string]float64),
+		obsCount: make(map[string]uint64),
+		obsSum:   make(map[string]float64),
+	}
+}
+
+func (s *PrometheusSink) Count(name string, delta int64, tags map[string]string) {
+	key := seriesKey(name, tags)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.counters[key] += float64(delta)
+}
+
+func (s *PrometheusSink) Gauge(name string, value float64, tags map[string]string) {
+	key := seriesKey(name, tags)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.gauges[key] = value
+}
+
+func (s *PrometheusSink) Observe(name string, value float64, tags map[string]string) {
+	key := seriesKey(name, tags)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.obsCount[key]++
+	s.obsSum[key] += value
+}
+
+// WriteTo renders every accumulated series in the Prometheus text
+// exposition format.
+func (s *PrometheusSink) WriteTo(w io.Writer) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var b strings.Builder
+	for _, key := range sortedKeys(s.counters) {
+		fmt.Fprintf(&b, "%s %v\n", key, s.counters[key])
+	}
+	for _, key := range sortedKeys(s.gauges) {
+		fmt.Fprintf(&b, "%s %v\n", key, s.gauges[key])
+	}
+	for _, key := range sortedKeys(s.obsCount) {
+		fmt.Fprintf(&b, "%s_count %v\n", key, s.obsCount[key])
+		fmt.Fprintf(&b, "%s_sum %v\n", key, s.obsSum[key])
+	}
+
+	n, err := io.WriteString(w, b.String())
+	return int64(n), err
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// seriesKey renders name and its sorted tags as a single Prometheus
+// exposition line prefix, e.g. `cvmfs_gateway_commits_total{repository="x"}`.
+func seriesKey(name string, tags map[string]string) string {
+	if len(tags) == 0 {
+		return name
+	}
+	labelNames := make([]string, 0, len(tags))
+	for k := range tags {
+		labelNames = append(labelNames, k)
+	}
+	sort.Strings(labelNames)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, k := range labelNames {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		fmt.Fprintf(&b, "%s=%q", k, tags[k])
+	}
+	b.WriteByte('}')
+	return b.String()
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPrometheusSinkAccumulatesCounters(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Count("cvmfs_gateway_commits_total", 1, map[string]string{"repository": "repo.example.org"})
+	s.Count("cvmfs_gateway_commits_total", 2, map[string]string{"repository": "repo.example.org"})
+
+	var b strings.Builder
+	if _, err := s.WriteTo(&b); err != nil {
+		t.Fatalf("WriteTo: %v", err)
+	}
+	if !strings.Contains(b.String(), `cvmfs_gateway_commits_total{repository="repo.example.org"} 3`) {
+		t.Fatalf("expected accumulated counter in output, got %q", b.String())
+	}
+}
+
+func TestPrometheusSinkRendersObservationsAsCountAndSum(t *testing.T) {
+	s := NewPrometheusSink()
+	s.Observe("cvmfs_gateway_commit_seconds", 1.5, nil)
+	s.Observe("cvmfs_gateway_commit_seconds", 2.5, nil)
+
+	var b strings.Builder
+	s.WriteTo(&b)
+	out := b.String()
+	if !strings.Contains(out, "cvmfs_gateway_commit_seconds_count 2") {
+		t.Fatalf("expected an observation count line, got %q", out)
+	}
+	if !strings.Contains(out, "cvmfs_gateway_commit_seconds_sum 4") {
+		t.Fatalf("expected an observation sum line, got %q", out)
+	}
+}
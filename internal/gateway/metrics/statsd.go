@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+)
+
+// StatsDSink pushes observations to a StatsD-compatible daemon over UDP,
+// using the conventional "name:value|type" line protocol. Tags, if any,
+// are appended as a StatsD-D/Datadog-style "#k:v,k:v" suffix, which most
+// modern StatsD-compatible collectors (Datadog, Telegraf) understand.
+type StatsDSink struct {
+	conn   net.Conn
+	prefix string
+}
+
+// NewStatsDSink dials addr (host:port) and returns a sink that prepends
+// prefix to every metric name exactly as given; no separator is added,
+// so a dotted namespace needs its trailing dot in prefix itself.
+func NewStatsDSink(addr, prefix string) (*StatsDSink, error) {
+	conn, err := net.Dial("udp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("metrics: could not dial statsd at %s: %w", addr, err)
+	}
+	return &StatsDSink{conn: conn, prefix: prefix}, nil
+}
+
+func (s *StatsDSink) Count(name string, delta int64, tags map[string]string) {
+	s.send(name, fmt.Sprintf("%d|c", delta), tags)
+}
+
+func (s *StatsDSink) Gauge(name string, value float64, tags map[string]string) {
+	s.send(name, fmt.Sprintf("%v|g", value), tags)
+}
+
+func (s *StatsDSink) Observe(name string, value float64, tags map[string]string) {
+	s.send(name, fmt.Sprintf("%v|ms", value), tags)
+}
+
+func (s *StatsDSink) send(name, valueAndType string, tags map[string]string) {
+	line := fmt.Sprintf("%s%s:%s%s", s.prefix, name, valueAndType, tagSuffix(tags))
+	// UDP is fire-and-forget: a lost or failed metric write should never
+	// disrupt the publishing path that's being instrumented.
+	s.conn.Write([]byte(line))
+}
+
+// Close releases the underlying UDP socket.
+func (s *StatsDSink) Close() error {
+	return s.conn.Close()
+}
+
+func tagSuffix(tags map[string]string) string {
+	if len(tags) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(tags))
+	for k := range tags {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+
+	parts := make([]string, len(names))
+	for i, k := range names {
+		parts[i] = k + ":" + tags[k]
+	}
+	return "|#" + strings.Join(parts, ",")
+}
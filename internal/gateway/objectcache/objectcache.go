@@ -0,0 +1,84 @@
+// Package objectcache remembers which content-addressed object digests
+// are already known to exist in upstream storage, so a publisher can ask
+// the gateway which objects of a prospective pack it can skip
+// re-uploading. It's a bounded, exact LRU set rather than a probabilistic
+// bloom filter: exactness is cheap at this scale and avoids ever telling
+// a publisher an object exists when it doesn't, at the cost of losing
+// entries (safely: a lost entry only costs a redundant upload) once the
+// gateway restarts or the cache fills.
+package objectcache
+
+import (
+	"container/list"
+	"sync"
+)
+
+// Cache is a fixed-capacity LRU set of known-existing object digests. The
+// zero value is not usable; use New.
+type Cache struct {
+	mu       sync.Mutex
+	capacity int
+	entries  map[string]*list.Element
+	order    *list.List
+}
+
+// New returns a Cache holding at most capacity digests, evicting the
+// least recently used entry once full. A non-positive capacity means
+// unbounded.
+func New(capacity int) *Cache {
+	return &Cache{
+		capacity: capacity,
+		entries:  make(map[string]*list.Element),
+		order:    list.New(),
+	}
+}
+
+// Add records digest as known to exist, refreshing its recency if it's
+// already present.
+func (c *Cache) Add(digest string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[digest]; ok {
+		c.order.MoveToFront(el)
+		return
+	}
+	c.entries[digest] = c.order.PushFront(digest)
+	if c.capacity > 0 && c.order.Len() > c.capacity {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(string))
+	}
+}
+
+// Contains reports whether digest is recorded as known to exist,
+// refreshing its recency if so.
+func (c *Cache) Contains(digest string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[digest]
+	if ok {
+		c.order.MoveToFront(el)
+	}
+	return ok
+}
+
+// Missing filters digests down to those not recorded in the cache, i.e.
+// the objects a publisher still needs to upload.
+func (c *Cache) Missing(digests []string) []string {
+	var missing []string
+	for _, d := range digests {
+		if !c.Contains(d) {
+			missing = append(missing, d)
+		}
+	}
+	return missing
+}
+
+// Len reports how many digests the cache currently holds.
+func (c *Cache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
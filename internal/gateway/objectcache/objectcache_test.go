@@ -0,0 +1,52 @@
+package objectcache
+
+import "testing"
+
+func TestMissingFiltersKnownDigests(t *testing.T) {
+	c := New(10)
+	c.Add("aaaa")
+	c.Add("bbbb")
+
+	missing := c.Missing([]string{"aaaa", "cccc", "bbbb", "dddd"})
+	want := []string{"cccc", "dddd"}
+	if len(missing) != len(want) {
+		t.Fatalf("expected %v, got %v", want, missing)
+	}
+	for i := range want {
+		if missing[i] != want[i] {
+			t.Fatalf("expected %v, got %v", want, missing)
+		}
+	}
+}
+
+func TestAddEvictsLeastRecentlyUsedAtCapacity(t *testing.T) {
+	c := New(2)
+	c.Add("aaaa")
+	c.Add("bbbb")
+	c.Add("cccc")
+
+	if c.Contains("aaaa") {
+		t.Fatal("expected the least recently used entry to have been evicted")
+	}
+	if !c.Contains("bbbb") || !c.Contains("cccc") {
+		t.Fatal("expected the two most recently added entries to remain")
+	}
+	if c.Len() != 2 {
+		t.Fatalf("expected 2 entries, got %d", c.Len())
+	}
+}
+
+func TestContainsRefreshesRecency(t *testing.T) {
+	c := New(2)
+	c.Add("aaaa")
+	c.Add("bbbb")
+	c.Contains("aaaa")
+	c.Add("cccc")
+
+	if !c.Contains("aaaa") {
+		t.Fatal("expected aaaa to survive eviction after being refreshed")
+	}
+	if c.Contains("bbbb") {
+		t.Fatal("expected bbbb to have been evicted as the least recently used")
+	}
+}
@@ -0,0 +1,145 @@
+// Package objectstore writes payload objects directly to an
+// S3-compatible object store, signing requests with AWS Signature
+// Version 4 using only the standard library, so the gateway's direct
+// ingestion path (see the ingest package) needs no AWS SDK dependency.
+package objectstore
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+)
+
+// Store persists a repository's objects, keyed by content digest.
+type Store interface {
+	Put(digest string, data []byte) error
+}
+
+// S3Store puts objects to a single bucket in an S3-compatible object
+// store, addressed path-style (Endpoint/Bucket/key) so it also works
+// against non-AWS implementations that don't support virtual-hosted
+// bucket addressing.
+type S3Store struct {
+	// Endpoint is the object store's base URL, e.g.
+	// "https://s3.us-east-1.amazonaws.com" or a MinIO/Ceph RGW endpoint.
+	Endpoint string
+	Region   string
+	Bucket   string
+
+	AccessKeyID     string
+	SecretAccessKey string
+
+	// Prefix is prepended to every object's digest to form its key,
+	// e.g. "data/" to mirror the on-disk CVMFS object layout. Leave
+	// empty to key objects by digest alone.
+	Prefix string
+
+	client *http.Client
+}
+
+// NewS3Store returns an S3Store that signs every request as
+// accessKeyID/secretAccessKey.
+func NewS3Store(endpoint, region, bucket, accessKeyID, secretAccessKey string) *S3Store {
+	return &S3Store{
+		Endpoint:        strings.TrimSuffix(endpoint, "/"),
+		Region:          region,
+		Bucket:          bucket,
+		AccessKeyID:     accessKeyID,
+		SecretAccessKey: secretAccessKey,
+		client:          &http.Client{Timeout: 60 * time.Second},
+	}
+}
+
+// Put uploads data as the object named digest, signing the request with
+// AWS Signature Version 4.
+func (s *S3Store) Put(digest string, data []byte) error {
+	key := s.Prefix + digest
+	url := fmt.Sprintf("%s/%s/%s", s.Endpoint, s.Bucket, key)
+
+	req, err := http.NewRequest(http.MethodPut, url, bytes.NewReader(data))
+	if err != nil {
+		return fmt.Errorf("objectstore: could not build request: %w", err)
+	}
+	req.ContentLength = int64(len(data))
+
+	if err := s.sign(req, data); err != nil {
+		return fmt.Errorf("objectstore: could not sign request: %w", err)
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("objectstore: put %s failed: %w", key, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("objectstore: put %s: unexpected status %s", key, resp.Status)
+	}
+	return nil
+}
+
+// sign attaches the AWS Signature Version 4 headers required for an
+// S3-compatible object store to authenticate req.
+func (s *S3Store) sign(req *http.Request, body []byte) error {
+	now := time.Now().UTC()
+	amzDate := now.Format("20060102T150405Z")
+	dateStamp := now.Format("20060102")
+	payloadHash := hexSHA256(body)
+
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	req.Header.Set("Host", req.URL.Host)
+
+	signedHeaders := "host;x-amz-content-sha256;x-amz-date"
+	canonicalHeaders := fmt.Sprintf("host:%s\nx-amz-content-sha256:%s\nx-amz-date:%s\n",
+		req.URL.Host, payloadHash, amzDate)
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		req.URL.EscapedPath(),
+		"",
+		canonicalHeaders,
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/s3/aws4_request", dateStamp, s.Region)
+	stringToSign := strings.Join([]string{
+		"AWS4-HMAC-SHA256",
+		amzDate,
+		scope,
+		hexSHA256([]byte(canonicalRequest)),
+	}, "\n")
+
+	key := deriveSigningKey(s.SecretAccessKey, dateStamp, s.Region, "s3")
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf(
+		"AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		s.AccessKeyID, scope, signedHeaders, signature,
+	))
+	return nil
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte(data))
+	return mac.Sum(nil)
+}
+
+func hexSHA256(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// deriveSigningKey derives the AWS Signature Version 4 signing key for a
+// single request, per the chained-HMAC construction AWS specifies.
+func deriveSigningKey(secret, dateStamp, region, service string) []byte {
+	kDate := hmacSHA256([]byte("AWS4"+secret), dateStamp)
+	kRegion := hmacSHA256(kDate, region)
+	kService := hmacSHA256(kRegion, service)
+	return hmacSHA256(kService, "aws4_request")
+}
@@ -0,0 +1,48 @@
+package objectstore
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPutSignsAndUploadsObject(t *testing.T) {
+	var gotAuth, gotContentSha string
+	var gotBody []byte
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotContentSha = r.Header.Get("X-Amz-Content-Sha256")
+		gotBody, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	store := NewS3Store(server.URL, "us-east-1", "cvmfs-objects", "AKIAEXAMPLE", "secret")
+	if err := store.Put("deadbeef", []byte("object body")); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+
+	if gotAuth == "" {
+		t.Fatal("expected an Authorization header to be sent")
+	}
+	if gotContentSha != hexSHA256([]byte("object body")) {
+		t.Fatalf("unexpected content sha256: %s", gotContentSha)
+	}
+	if string(gotBody) != "object body" {
+		t.Fatalf("unexpected body: %s", gotBody)
+	}
+}
+
+func TestPutFailsOnNon2xxStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	store := NewS3Store(server.URL, "us-east-1", "cvmfs-objects", "AKIAEXAMPLE", "secret")
+	if err := store.Put("deadbeef", []byte("object body")); err == nil {
+		t.Fatal("expected an error on a non-2xx response")
+	}
+}
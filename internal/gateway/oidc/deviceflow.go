@@ -0,0 +1,132 @@
+// Package oidc implements the pieces of OpenID Connect the gateway needs
+// to let human operators authenticate to admin endpoints with their
+// institutional SSO, via the device authorization flow (RFC 8628)
+// suited to gateway-ctl, a CLI with no browser redirect target of its
+// own. Machine publishers keep authenticating with HMAC keys through the
+// access package; OIDC is additive, for humans only.
+package oidc
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// DeviceCodeResponse is the provider's response to a device authorization
+// request, per RFC 8628 section 3.2.
+type DeviceCodeResponse struct {
+	DeviceCode              string `json:"device_code"`
+	UserCode                string `json:"user_code"`
+	VerificationURI         string `json:"verification_uri"`
+	VerificationURIComplete string `json:"verification_uri_complete,omitempty"`
+	ExpiresIn               int    `json:"expires_in"`
+	Interval                int    `json:"interval"`
+}
+
+// Token is the provider's successful token response.
+type Token struct {
+	AccessToken string `json:"access_token"`
+	IDToken     string `json:"id_token"`
+	TokenType   string `json:"token_type"`
+	ExpiresIn   int    `json:"expires_in"`
+}
+
+// Client drives the device authorization flow against an OIDC provider.
+type Client struct {
+	ClientID           string
+	DeviceAuthEndpoint string
+	TokenEndpoint      string
+	HTTPClient         *http.Client
+}
+
+// NewClient returns a device-flow Client for the given provider
+// endpoints, with a default 10-second HTTP timeout.
+func NewClient(clientID, deviceAuthEndpoint, tokenEndpoint string) *Client {
+	return &Client{
+		ClientID:           clientID,
+		DeviceAuthEndpoint: deviceAuthEndpoint,
+		TokenEndpoint:      tokenEndpoint,
+		HTTPClient:         &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+// StartDeviceFlow requests a device and user code from the provider. The
+// caller should display VerificationURI(Complete) and UserCode to the
+// operator, then poll PollOnce until the operator completes login there.
+func (c *Client) StartDeviceFlow(scope string) (DeviceCodeResponse, error) {
+	form := url.Values{"client_id": {c.ClientID}, "scope": {scope}}
+	resp, err := c.HTTPClient.PostForm(c.DeviceAuthEndpoint, form)
+	if err != nil {
+		return DeviceCodeResponse{}, fmt.Errorf("oidc: device authorization request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return DeviceCodeResponse{}, fmt.Errorf("oidc: device authorization endpoint returned status %d", resp.StatusCode)
+	}
+	var out DeviceCodeResponse
+	if err := json.NewDecoder(resp.Body).Decode(&out); err != nil {
+		return DeviceCodeResponse{}, fmt.Errorf("oidc: could not decode device authorization response: %w", err)
+	}
+	return out, nil
+}
+
+// errAuthorizationPending is the RFC 8628 error code returned by the
+// token endpoint while the operator has not yet completed login.
+const errAuthorizationPending = "authorization_pending"
+
+type tokenErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// PollOnce makes a single token-endpoint poll attempt for deviceCode. It
+// returns (Token{}, false, nil) if authorization is still pending, so the
+// caller can sleep for Interval and call it again; a non-nil error means
+// the flow failed and should not be retried.
+func (c *Client) PollOnce(deviceCode string) (Token, bool, error) {
+	form := url.Values{
+		"client_id":   {c.ClientID},
+		"device_code": {deviceCode},
+		"grant_type":  {"urn:ietf:params:oauth:grant-type:device_code"},
+	}
+	resp, err := c.HTTPClient.PostForm(c.TokenEndpoint, form)
+	if err != nil {
+		return Token{}, false, fmt.Errorf("oidc: token request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		var tokenErr tokenErrorResponse
+		json.NewDecoder(resp.Body).Decode(&tokenErr)
+		if tokenErr.Error == errAuthorizationPending {
+			return Token{}, false, nil
+		}
+		return Token{}, false, fmt.Errorf("oidc: token endpoint denied the request: %s", tokenErr.Error)
+	}
+
+	var tok Token
+	if err := json.NewDecoder(resp.Body).Decode(&tok); err != nil {
+		return Token{}, false, fmt.Errorf("oidc: could not decode token response: %w", err)
+	}
+	return tok, true, nil
+}
+
+// PollForToken polls the token endpoint every interval until the operator
+// completes login, the device code expires, or an unrecoverable error
+// occurs.
+func (c *Client) PollForToken(deviceCode string, interval time.Duration, expiresIn time.Duration) (Token, error) {
+	deadline := time.Now().Add(expiresIn)
+	for time.Now().Before(deadline) {
+		tok, done, err := c.PollOnce(deviceCode)
+		if err != nil {
+			return Token{}, err
+		}
+		if done {
+			return tok, nil
+		}
+		time.Sleep(interval)
+	}
+	return Token{}, fmt.Errorf("oidc: device code expired before login completed")
+}
@@ -0,0 +1,74 @@
+package oidc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims holds the ID token fields the gateway cares about for admin
+// authentication.
+type Claims struct {
+	Subject string `json:"sub"`
+	Email   string `json:"email"`
+	Issuer  string `json:"iss"`
+	Expiry  int64  `json:"exp"`
+
+	// Groups lists the institutional groups this operator belongs to,
+	// used by access.Config.GroupRole to determine their admin API role.
+	Groups []string `json:"groups"`
+}
+
+// Verifier checks that an ID token was issued by Issuer and has not
+// expired. Audience is stored but not compared with the token's "aud"
+// claim, which is not decoded.
+//
+// It does not verify the token's signature: doing so correctly requires
+// fetching and caching the provider's JWKS and matching the token's "kid",
+// which is out of scope here. Deployments should terminate the admin API
+// behind a reverse proxy that already validates the token (e.g. an
+// OAuth2 proxy sidecar) until JWKS verification is added.
+type Verifier struct {
+	Issuer   string
+	Audience string
+}
+
+// NewVerifier returns a Verifier for tokens issued by issuer to audience.
+func NewVerifier(issuer, audience string) *Verifier {
+	return &Verifier{Issuer: issuer, Audience: audience}
+}
+
+// Verify decodes idToken's claims and checks issuer and expiry.
+func (v *Verifier) Verify(idToken string) (Claims, error) {
+	claims, err := decodeClaims(idToken)
+	if err != nil {
+		return Claims{}, err
+	}
+	if claims.Issuer != v.Issuer {
+		return Claims{}, fmt.Errorf("oidc: unexpected issuer %q", claims.Issuer)
+	}
+	if time.Now().Unix() >= claims.Expiry {
+		return Claims{}, fmt.Errorf("oidc: token expired")
+	}
+	return claims, nil
+}
+
+// decodeClaims extracts the payload segment of a JWT and unmarshals it,
+// without checking the signature.
+func decodeClaims(idToken string) (Claims, error) {
+	parts := strings.Split(idToken, ".")
+	if len(parts) != 3 {
+		return Claims{}, fmt.Errorf("oidc: malformed ID token")
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return Claims{}, fmt.Errorf("oidc: could not decode token payload: %w", err)
+	}
+	var claims Claims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return Claims{}, fmt.Errorf("oidc: could not parse token claims: %w", err)
+	}
+	return claims, nil
+}
@@ -0,0 +1,62 @@
+package oidc
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func fakeIDToken(t *testing.T, claims Claims) string {
+	t.Helper()
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	header := base64.RawURLEncoding.EncodeToString([]byte(`{"alg":"none"}`))
+	body := base64.RawURLEncoding.EncodeToString(payload)
+	return header + "." + body + ".sig"
+}
+
+func TestVerifierAcceptsValidToken(t *testing.T) {
+	v := NewVerifier("https://sso.example.org", "gateway")
+	tok := fakeIDToken(t, Claims{
+		Subject: "alice",
+		Issuer:  "https://sso.example.org",
+		Expiry:  time.Now().Add(time.Hour).Unix(),
+	})
+
+	claims, err := v.Verify(tok)
+	if err != nil {
+		t.Fatalf("Verify: %v", err)
+	}
+	if claims.Subject != "alice" {
+		t.Fatalf("expected subject alice, got %q", claims.Subject)
+	}
+}
+
+func TestVerifierRejectsExpiredToken(t *testing.T) {
+	v := NewVerifier("https://sso.example.org", "gateway")
+	tok := fakeIDToken(t, Claims{
+		Subject: "alice",
+		Issuer:  "https://sso.example.org",
+		Expiry:  time.Now().Add(-time.Hour).Unix(),
+	})
+
+	if _, err := v.Verify(tok); err == nil {
+		t.Fatal("expected expired token to be rejected")
+	}
+}
+
+func TestVerifierRejectsWrongIssuer(t *testing.T) {
+	v := NewVerifier("https://sso.example.org", "gateway")
+	tok := fakeIDToken(t, Claims{
+		Subject: "alice",
+		Issuer:  "https://evil.example.org",
+		Expiry:  time.Now().Add(time.Hour).Unix(),
+	})
+
+	if _, err := v.Verify(tok); err == nil {
+		t.Fatal("expected wrong-issuer token to be rejected")
+	}
+}
@@ -0,0 +1,122 @@
+// Package precheck implements the gateway's startup self-check: access
+// configuration consistency, receiver binary presence, upstream storage
+// reachability, and port availability, all verified before the gateway
+// takes any traffic. It backs the --check startup flag.
+package precheck
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/config"
+)
+
+// Check is the outcome of a single self-check step.
+type Check struct {
+	Name   string
+	OK     bool
+	Detail string
+}
+
+// Report is the full set of self-check results.
+type Report struct {
+	Checks []Check
+}
+
+func (r *Report) add(name string, err error) {
+	c := Check{Name: name, OK: err == nil}
+	if err != nil {
+		c.Detail = err.Error()
+	}
+	r.Checks = append(r.Checks, c)
+}
+
+// Fatal reports whether any check failed.
+func (r Report) Fatal() bool {
+	for _, c := range r.Checks {
+		if !c.OK {
+			return true
+		}
+	}
+	return false
+}
+
+// WriteTo prints a human-readable, line-per-check report.
+func (r Report) WriteTo(w io.Writer) (int64, error) {
+	var written int64
+	for _, c := range r.Checks {
+		status := "ok"
+		if !c.OK {
+			status = "FAIL"
+		}
+		n, err := fmt.Fprintf(w, "[%s] %s\n", status, c.Name)
+		written += int64(n)
+		if err != nil {
+			return written, err
+		}
+		if c.Detail != "" {
+			n, err := fmt.Fprintf(w, "      %s\n", c.Detail)
+			written += int64(n)
+			if err != nil {
+				return written, err
+			}
+		}
+	}
+	return written, nil
+}
+
+// Run verifies cfg and acc against the running host: that every key a
+// repository authorizes actually exists, that the receiver binary is
+// present and executable, that upstream storage is reachable, and that
+// the configured port isn't already in use.
+func Run(cfg config.Config, acc *access.Config) Report {
+	var r Report
+
+	if issues := acc.Validate(); len(issues) == 0 {
+		r.add("access config: every repository's authorized keys exist", nil)
+	} else {
+		for _, issue := range issues {
+			r.add("access config", errors.New(issue))
+		}
+	}
+
+	r.add("receiver binary is present and executable", checkExecutable(cfg.ReceiverBinary))
+	r.add("upstream storage is reachable", checkPath(cfg.StoragePath))
+
+	if cfg.ListenSocket == "" {
+		r.add(fmt.Sprintf("port %d is free", cfg.Port), checkPortFree(cfg.Port))
+	}
+
+	return r
+}
+
+func checkExecutable(path string) error {
+	if path == "" {
+		return errors.New("no receiver binary configured")
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return err
+	}
+	if info.Mode()&0111 == 0 {
+		return fmt.Errorf("%s is not executable", path)
+	}
+	return nil
+}
+
+func checkPath(path string) error {
+	_, err := os.Stat(path)
+	return err
+}
+
+func checkPortFree(port int) error {
+	l, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	return l.Close()
+}
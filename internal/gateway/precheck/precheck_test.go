@@ -0,0 +1,40 @@
+package precheck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/config"
+)
+
+func TestRunFlagsMissingReceiverBinary(t *testing.T) {
+	cfg := config.Default()
+	cfg.ReceiverBinary = "/does/not/exist"
+	cfg.StoragePath = "/"
+
+	report := Run(cfg, access.NewConfig())
+	if !report.Fatal() {
+		t.Fatal("expected a missing receiver binary to be reported as fatal")
+	}
+
+	var buf strings.Builder
+	report.WriteTo(&buf)
+	if !strings.Contains(buf.String(), "FAIL") {
+		t.Fatalf("expected the report to include a FAIL line, got %q", buf.String())
+	}
+}
+
+func TestRunPassesWithConsistentConfig(t *testing.T) {
+	cfg := config.Default()
+	cfg.ReceiverBinary = "/bin/sh"
+	cfg.StoragePath = "/"
+	cfg.Port = 0
+
+	report := Run(cfg, access.NewConfig())
+	for _, c := range report.Checks {
+		if !c.OK {
+			t.Errorf("expected check %q to pass, got: %s", c.Name, c.Detail)
+		}
+	}
+}
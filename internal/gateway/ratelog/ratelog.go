@@ -0,0 +1,75 @@
+// Package ratelog rate-limits repeated log lines. A condition that fails
+// continuously — a shadow gateway that's down, an access config fragment
+// that never parses — would otherwise flood the gateway's logs with one
+// identical line per occurrence; a Limiter collapses each burst down to
+// one line per interval, with a count of how many were suppressed.
+package ratelog
+
+import (
+	"log"
+	"sync"
+	"time"
+)
+
+// Limiter tracks, per key, the last time a message was logged, so bursts
+// of repeated messages under the same key can be collapsed to at most
+// one per interval.
+type Limiter struct {
+	interval time.Duration
+
+	mu    sync.Mutex
+	state map[string]*keyState
+}
+
+type keyState struct {
+	lastLogged time.Time
+	suppressed int
+}
+
+// NewLimiter returns a Limiter that allows at most one message per key
+// every interval.
+func NewLimiter(interval time.Duration) *Limiter {
+	return &Limiter{interval: interval, state: make(map[string]*keyState)}
+}
+
+// Allow reports whether a message under key should be logged now. If it
+// returns false, the caller should drop the message; suppressed is the
+// number of messages dropped for key since the last one that was
+// allowed, so it can be folded into the next allowed line.
+func (l *Limiter) Allow(key string) (ok bool, suppressed int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	now := time.Now()
+	s, exists := l.state[key]
+	if !exists {
+		l.state[key] = &keyState{lastLogged: now}
+		return true, 0
+	}
+
+	if now.Sub(s.lastLogged) < l.interval {
+		s.suppressed++
+		return false, s.suppressed
+	}
+
+	suppressed = s.suppressed
+	s.lastLogged = now
+	s.suppressed = 0
+	return true, suppressed
+}
+
+// Printf logs format/args under the standard log package, unless key has
+// already logged within the last interval, in which case the message is
+// dropped and counted. The next allowed message for key notes how many
+// were suppressed in between.
+func (l *Limiter) Printf(key, format string, args ...interface{}) {
+	ok, suppressed := l.Allow(key)
+	if !ok {
+		return
+	}
+	if suppressed > 0 {
+		log.Printf(format+" (%d similar messages suppressed)", append(args, suppressed)...)
+		return
+	}
+	log.Printf(format, args...)
+}
@@ -0,0 +1,61 @@
+package ratelog
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLimiterAllowsFirstMessage(t *testing.T) {
+	l := NewLimiter(time.Minute)
+	ok, suppressed := l.Allow("key")
+	if !ok {
+		t.Fatal("expected the first message for a key to be allowed")
+	}
+	if suppressed != 0 {
+		t.Fatalf("expected no suppressed count on the first message, got %d", suppressed)
+	}
+}
+
+func TestLimiterSuppressesWithinInterval(t *testing.T) {
+	l := NewLimiter(time.Hour)
+	l.Allow("key")
+
+	ok, _ := l.Allow("key")
+	if ok {
+		t.Fatal("expected a second message within the interval to be suppressed")
+	}
+
+	ok, suppressed := l.Allow("key")
+	if ok {
+		t.Fatal("expected a third message within the interval to be suppressed")
+	}
+	if suppressed != 2 {
+		t.Fatalf("expected 2 suppressed messages, got %d", suppressed)
+	}
+}
+
+func TestLimiterAllowsAgainAfterInterval(t *testing.T) {
+	l := NewLimiter(time.Millisecond)
+	l.Allow("key")
+	l.Allow("key")
+
+	time.Sleep(5 * time.Millisecond)
+
+	ok, suppressed := l.Allow("key")
+	if !ok {
+		t.Fatal("expected a message after the interval has elapsed to be allowed")
+	}
+	if suppressed != 1 {
+		t.Fatalf("expected the 1 suppressed message in between to be reported, got %d", suppressed)
+	}
+}
+
+func TestLimiterTracksKeysIndependently(t *testing.T) {
+	l := NewLimiter(time.Hour)
+	l.Allow("a")
+
+	ok, _ := l.Allow("b")
+	if !ok {
+		t.Fatal("expected a different key to be allowed independently of an unrelated key")
+	}
+}
@@ -0,0 +1,37 @@
+package receiver
+
+import "sync"
+
+// AffinityPool routes every commit for a given repository to the same
+// underlying Receiver instance, so a receiver that keeps warm per-repo
+// state (an open catalog, a cached scratch area) doesn't have to rebuild
+// it on every commit.
+type AffinityPool struct {
+	factory func(repository string) Receiver
+
+	mu        sync.Mutex
+	receivers map[string]Receiver
+}
+
+// NewAffinityPool returns a Receiver that lazily creates one underlying
+// receiver per repository via factory, on first use.
+func NewAffinityPool(factory func(repository string) Receiver) *AffinityPool {
+	return &AffinityPool{factory: factory, receivers: make(map[string]Receiver)}
+}
+
+// Commit dispatches to the receiver instance affine to repository,
+// creating it via factory on first use.
+func (p *AffinityPool) Commit(repository, path string, payload Payload) error {
+	return p.receiverFor(repository).Commit(repository, path, payload)
+}
+
+func (p *AffinityPool) receiverFor(repository string) Receiver {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	r, ok := p.receivers[repository]
+	if !ok {
+		r = p.factory(repository)
+		p.receivers[repository] = r
+	}
+	return r
+}
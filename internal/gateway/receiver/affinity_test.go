@@ -0,0 +1,41 @@
+package receiver
+
+import "testing"
+
+type countingReceiver struct {
+	commits int
+}
+
+func (c *countingReceiver) Commit(repository, path string, payload Payload) error {
+	c.commits++
+	return nil
+}
+
+func TestAffinityPoolReusesReceiverPerRepository(t *testing.T) {
+	created := map[string]*countingReceiver{}
+	pool := NewAffinityPool(func(repository string) Receiver {
+		r := &countingReceiver{}
+		created[repository] = r
+		return r
+	})
+
+	if err := pool.Commit("repo.a.org", "repo.a.org/x", Payload{}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := pool.Commit("repo.a.org", "repo.a.org/y", Payload{}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if err := pool.Commit("repo.b.org", "repo.b.org/x", Payload{}); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+
+	if len(created) != 2 {
+		t.Fatalf("expected one receiver per distinct repository, got %d", len(created))
+	}
+	if created["repo.a.org"].commits != 2 {
+		t.Fatalf("expected repo.a.org's receiver to see both its commits, got %d", created["repo.a.org"].commits)
+	}
+	if created["repo.b.org"].commits != 1 {
+		t.Fatalf("expected repo.b.org's receiver to see its own commit, got %d", created["repo.b.org"].commits)
+	}
+}
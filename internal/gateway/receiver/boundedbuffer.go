@@ -0,0 +1,57 @@
+package receiver
+
+import "sync"
+
+// maxCapturedOutputBytes bounds how much of a single stdout or stderr
+// stream boundedBuffer will retain from a receiver subprocess. It's
+// generous enough to hold any legitimate diagnostic output or publish
+// report, while keeping a misbehaving (or fuzzed) receiver binary that
+// floods its output from exhausting worker memory.
+const maxCapturedOutputBytes = 8 << 20 // 8 MiB
+
+// boundedBuffer is an io.Writer that captures up to maxCapturedOutputBytes
+// of a receiver subprocess's output. Writes beyond the cap are discarded
+// rather than erroring, since a chatty child process failing the whole
+// commit merely because it's chatty would be worse than losing the tail
+// of its output; Truncated reports when that happened. Safe for
+// concurrent use, since it's written by cmd.Run() and, when Streams is
+// set, raced against reads in the same call via io.MultiWriter.
+type boundedBuffer struct {
+	mu        sync.Mutex
+	buf       []byte
+	truncated bool
+}
+
+// Write implements io.Writer. It never returns an error.
+func (b *boundedBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	room := maxCapturedOutputBytes - len(b.buf)
+	if room <= 0 {
+		b.truncated = true
+		return len(p), nil
+	}
+	if len(p) > room {
+		b.buf = append(b.buf, p[:room]...)
+		b.truncated = true
+		return len(p), nil
+	}
+	b.buf = append(b.buf, p...)
+	return len(p), nil
+}
+
+// String returns the captured output collected so far.
+func (b *boundedBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return string(b.buf)
+}
+
+// Truncated reports whether some output was discarded because the
+// capture reached maxCapturedOutputBytes.
+func (b *boundedBuffer) Truncated() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.truncated
+}
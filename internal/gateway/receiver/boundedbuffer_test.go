@@ -0,0 +1,71 @@
+package receiver
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestBoundedBufferCapturesWithinLimit(t *testing.T) {
+	b := &boundedBuffer{}
+	if _, err := b.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if b.String() != "hello" {
+		t.Fatalf("expected %q, got %q", "hello", b.String())
+	}
+	if b.Truncated() {
+		t.Fatal("expected no truncation for a small write")
+	}
+}
+
+func TestBoundedBufferTruncatesOversizedInput(t *testing.T) {
+	b := &boundedBuffer{}
+	over := bytes.Repeat([]byte("x"), maxCapturedOutputBytes+1024)
+
+	n, err := b.Write(over)
+	if err != nil {
+		t.Fatalf("Write returned an error, want none: %v", err)
+	}
+	if n != len(over) {
+		t.Fatalf("expected Write to report all %d bytes accepted, got %d", len(over), n)
+	}
+	if len(b.String()) != maxCapturedOutputBytes {
+		t.Fatalf("expected capture capped at %d bytes, got %d", maxCapturedOutputBytes, len(b.String()))
+	}
+	if !b.Truncated() {
+		t.Fatal("expected Truncated to report true once the cap was exceeded")
+	}
+}
+
+func TestBoundedBufferDiscardsWritesAfterCap(t *testing.T) {
+	b := &boundedBuffer{}
+	b.Write(bytes.Repeat([]byte("x"), maxCapturedOutputBytes))
+	if b.Truncated() {
+		t.Fatal("expected no truncation while exactly at the cap")
+	}
+
+	if _, err := b.Write([]byte("overflow")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if len(b.String()) != maxCapturedOutputBytes {
+		t.Fatalf("expected capture to stay at %d bytes, got %d", maxCapturedOutputBytes, len(b.String()))
+	}
+	if !b.Truncated() {
+		t.Fatal("expected Truncated to report true once further writes were discarded")
+	}
+}
+
+func TestBoundedBufferManySmallWrites(t *testing.T) {
+	b := &boundedBuffer{}
+	line := strings.Repeat("y", 4096) + "\n"
+	for i := 0; i < 3000; i++ {
+		b.Write([]byte(line))
+	}
+	if !b.Truncated() {
+		t.Fatal("expected truncation after enough small writes to exceed the cap")
+	}
+	if len(b.String()) != maxCapturedOutputBytes {
+		t.Fatalf("expected capture capped at %d bytes, got %d", maxCapturedOutputBytes, len(b.String()))
+	}
+}
@@ -0,0 +1,30 @@
+package receiver
+
+import (
+	"fmt"
+	"os/exec"
+)
+
+// Cleaner discards a receiver's staged upload for a lease that expired
+// without ever being committed or cancelled by its client. Not every
+// Receiver implementation stages uploads somewhere that needs explicit
+// cleanup; callers should type-assert for it.
+type Cleaner interface {
+	Cleanup(repository, path string) error
+}
+
+// Cleanup shells out to the receiver binary's "cleanup" subcommand to
+// discard any staged upload data left behind for path.
+func (r *ProcessReceiver) Cleanup(repository, path string) error {
+	if r.BinaryPath == "" {
+		return fmt.Errorf("receiver: no binary configured")
+	}
+
+	cmd := exec.Command(r.BinaryPath, "cleanup", repository, path)
+	stderr := &boundedBuffer{}
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("receiver: cleanup failed: %w: %s", err, stderr.String())
+	}
+	return nil
+}
@@ -0,0 +1,63 @@
+package receiver
+
+import (
+	"sync"
+	"time"
+)
+
+// DiagnosticBundle captures a single receiver invocation's output so
+// publish failures can be triaged after the fact, without needing to
+// reproduce them against a live receiver process.
+type DiagnosticBundle struct {
+	Repository string `json:"repository"`
+	Path       string `json:"path"`
+	Stdout     string `json:"stdout"`
+	Stderr     string `json:"stderr"`
+
+	// Truncated reports whether Stdout or Stderr was cut short because
+	// the receiver process's output exceeded maxCapturedOutputBytes. A
+	// misbehaving or fuzzed receiver binary can flood its output; this
+	// bounds how much of it the gateway ever holds in memory.
+	Truncated bool `json:"truncated,omitempty"`
+
+	ExitCode int       `json:"exit_code"`
+	Err      string    `json:"error,omitempty"`
+	RanAt    time.Time `json:"ran_at"`
+
+	// RequestID is the originating HTTP request's correlation ID, if the
+	// commit was submitted with one, so a failure here can be matched
+	// back to the gateway's own access logs.
+	RequestID string `json:"request_id,omitempty"`
+
+	// Usage reports the receiver process's CPU time, peak RSS, and I/O
+	// block counts, if the platform's process accounting exposed them
+	// (see resourceUsageFromProcessState). Zero-valued if not.
+	Usage ResourceUsage `json:"usage"`
+}
+
+// DiagnosticStore keeps the most recent diagnostic bundle for each
+// repository subpath a receiver has run against.
+type DiagnosticStore struct {
+	mu      sync.Mutex
+	bundles map[string]DiagnosticBundle
+}
+
+// NewDiagnosticStore returns an empty diagnostic bundle store.
+func NewDiagnosticStore() *DiagnosticStore {
+	return &DiagnosticStore{bundles: make(map[string]DiagnosticBundle)}
+}
+
+// Save records the diagnostic bundle for path, replacing any earlier one.
+func (s *DiagnosticStore) Save(path string, bundle DiagnosticBundle) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.bundles[path] = bundle
+}
+
+// Get returns the most recent diagnostic bundle recorded for path.
+func (s *DiagnosticStore) Get(path string) (DiagnosticBundle, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	b, ok := s.bundles[path]
+	return b, ok
+}
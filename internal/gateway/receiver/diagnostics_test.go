@@ -0,0 +1,16 @@
+package receiver
+
+import "testing"
+
+func TestDiagnosticStoreSaveAndGet(t *testing.T) {
+	s := NewDiagnosticStore()
+	if _, ok := s.Get("repo.example.org/a"); ok {
+		t.Fatal("expected no bundle before any Save")
+	}
+
+	s.Save("repo.example.org/a", DiagnosticBundle{Stdout: "ok", ExitCode: 0})
+	b, ok := s.Get("repo.example.org/a")
+	if !ok || b.Stdout != "ok" {
+		t.Fatalf("unexpected bundle: %+v, ok=%v", b, ok)
+	}
+}
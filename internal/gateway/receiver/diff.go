@@ -0,0 +1,65 @@
+package receiver
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// DiffEntry describes a single catalog entry that changed between two
+// revisions.
+type DiffEntry struct {
+	Path   string `json:"path"`
+	Change string `json:"change"` // "added", "removed", or "modified"
+}
+
+// Differ produces a summary of the catalog entries that changed between
+// two root hashes. Not every Receiver implementation supports it; callers
+// should type-assert for it.
+type Differ interface {
+	Diff(repository, from, to string) ([]DiffEntry, error)
+}
+
+// Diff shells out to the receiver binary's "diff" subcommand and parses
+// its output, one changed entry per line in the form "<A|D|M> <path>".
+func (r *ProcessReceiver) Diff(repository, from, to string) ([]DiffEntry, error) {
+	if r.BinaryPath == "" {
+		return nil, fmt.Errorf("receiver: no binary configured")
+	}
+
+	cmd := exec.Command(r.BinaryPath, "diff", repository, from, to)
+	stdout := &boundedBuffer{}
+	stderr := &boundedBuffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("receiver: diff failed: %w: %s", err, stderr.String())
+	}
+
+	return parseDiffOutput(stdout.String()), nil
+}
+
+func parseDiffOutput(output string) []DiffEntry {
+	var entries []DiffEntry
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.SplitN(strings.TrimSpace(scanner.Text()), " ", 2)
+		if len(fields) != 2 {
+			continue
+		}
+		var change string
+		switch fields[0] {
+		case "A":
+			change = "added"
+		case "D":
+			change = "removed"
+		case "M":
+			change = "modified"
+		default:
+			continue
+		}
+		entries = append(entries, DiffEntry{Path: fields[1], Change: change})
+	}
+	return entries
+}
@@ -0,0 +1,66 @@
+package receiver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDiffOutput(t *testing.T) {
+	entries := parseDiffOutput("A /new-file\nD /old-file\nM /changed-file\n")
+	if len(entries) != 3 {
+		t.Fatalf("expected 3 entries, got %d", len(entries))
+	}
+	if entries[0] != (DiffEntry{Path: "/new-file", Change: "added"}) {
+		t.Fatalf("unexpected first entry: %+v", entries[0])
+	}
+	if entries[1] != (DiffEntry{Path: "/old-file", Change: "removed"}) {
+		t.Fatalf("unexpected second entry: %+v", entries[1])
+	}
+	if entries[2] != (DiffEntry{Path: "/changed-file", Change: "modified"}) {
+		t.Fatalf("unexpected third entry: %+v", entries[2])
+	}
+}
+
+func TestParseDiffOutputSkipsUnrecognizedLines(t *testing.T) {
+	entries := parseDiffOutput("A /ok\n???\n")
+	if len(entries) != 1 {
+		t.Fatalf("expected 1 entry, got %d", len(entries))
+	}
+}
+
+// TestParseDiffOutputMalformedInput is a negative-test suite: a
+// misbehaving receiver binary's diff output should never make this
+// parser panic or hang, whatever garbage it emits.
+func TestParseDiffOutputMalformedInput(t *testing.T) {
+	cases := []string{
+		"",
+		"\x00\x01\x02binary garbage\xff\xfe",
+		"A\n",
+		"A ",
+		strings.Repeat("A /very-long-path-segment", 100000),
+		strings.Repeat("A /line\n", 100000),
+		"A /leading-space-preserved  \n",
+		"M\x00/embedded-nul",
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("parseDiffOutput panicked on %q: %v", c, r)
+				}
+			}()
+			parseDiffOutput(c)
+		}()
+	}
+}
+
+func FuzzParseDiffOutput(f *testing.F) {
+	f.Add("A /new-file\nD /old-file\nM /changed-file\n")
+	f.Add("")
+	f.Add("???\n")
+	f.Add("A\n")
+	f.Add(strings.Repeat("A /x\n", 1000))
+	f.Fuzz(func(t *testing.T, output string) {
+		parseDiffOutput(output)
+	})
+}
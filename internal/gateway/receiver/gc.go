@@ -0,0 +1,86 @@
+package receiver
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// GCOptions configures a garbage collection run.
+type GCOptions struct {
+	// DryRun reports what GC would delete without deleting anything.
+	DryRun bool
+	// KeepRevisions is the number of most recent revisions to preserve
+	// regardless of age. 0 leaves the receiver's own default in effect.
+	KeepRevisions int
+}
+
+// GCReport summarizes the outcome (or, for a dry run, the projected
+// outcome) of a garbage collection run.
+type GCReport struct {
+	DryRun           bool     `json:"dry_run"`
+	DeletedRevisions []string `json:"deleted_revisions,omitempty"`
+	DeletedObjects   []string `json:"deleted_objects,omitempty"`
+	ReclaimedBytes   int64    `json:"reclaimed_bytes"`
+}
+
+// GCer runs garbage collection against a repository. Not every Receiver
+// implementation supports it; callers should type-assert for it.
+type GCer interface {
+	GC(repository string, opts GCOptions) (GCReport, error)
+}
+
+// GC shells out to the receiver binary's "gc" subcommand and parses its
+// report, one line per deleted revision or object in the form
+// "<R|O> <name> <bytes>".
+func (r *ProcessReceiver) GC(repository string, opts GCOptions) (GCReport, error) {
+	if r.BinaryPath == "" {
+		return GCReport{}, fmt.Errorf("receiver: no binary configured")
+	}
+
+	args := []string{"gc", repository}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	if opts.KeepRevisions > 0 {
+		args = append(args, "--keep-revisions", strconv.Itoa(opts.KeepRevisions))
+	}
+
+	cmd := exec.Command(r.BinaryPath, args...)
+	stdout := &boundedBuffer{}
+	stderr := &boundedBuffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return GCReport{}, fmt.Errorf("receiver: gc failed: %w: %s", err, stderr.String())
+	}
+
+	report := parseGCOutput(stdout.String())
+	report.DryRun = opts.DryRun
+	return report, nil
+}
+
+func parseGCOutput(output string) GCReport {
+	var report GCReport
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "R":
+			report.DeletedRevisions = append(report.DeletedRevisions, fields[1])
+		case "O":
+			report.DeletedObjects = append(report.DeletedObjects, fields[1])
+			if len(fields) >= 3 {
+				if n, err := strconv.ParseInt(fields[2], 10, 64); err == nil {
+					report.ReclaimedBytes += n
+				}
+			}
+		}
+	}
+	return report
+}
@@ -0,0 +1,54 @@
+package receiver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseGCOutput(t *testing.T) {
+	report := parseGCOutput("R rev-1\nO /obj/a 100\nO /obj/b 50\n")
+	if len(report.DeletedRevisions) != 1 || report.DeletedRevisions[0] != "rev-1" {
+		t.Fatalf("unexpected revisions: %v", report.DeletedRevisions)
+	}
+	if len(report.DeletedObjects) != 2 {
+		t.Fatalf("expected 2 deleted objects, got %d", len(report.DeletedObjects))
+	}
+	if report.ReclaimedBytes != 150 {
+		t.Fatalf("expected 150 reclaimed bytes, got %d", report.ReclaimedBytes)
+	}
+}
+
+// TestParseGCOutputMalformedInput is a negative-test suite: a
+// misbehaving receiver binary's gc output should never make this parser
+// panic or hang, whatever garbage it emits.
+func TestParseGCOutputMalformedInput(t *testing.T) {
+	cases := []string{
+		"",
+		"\x00\x01\x02binary garbage\xff\xfe",
+		"R\n",
+		"O /obj/a not-a-number\n",
+		"O /obj/a 99999999999999999999999999999999\n",
+		strings.Repeat("O /obj/a 1\n", 100000),
+		"R rev-1 with spaces in it\n",
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("parseGCOutput panicked on %q: %v", c, r)
+				}
+			}()
+			parseGCOutput(c)
+		}()
+	}
+}
+
+func FuzzParseGCOutput(f *testing.F) {
+	f.Add("R rev-1\nO /obj/a 100\nO /obj/b 50\n")
+	f.Add("")
+	f.Add("O /obj/a not-a-number\n")
+	f.Add(strings.Repeat("O /obj/a 1\n", 1000))
+	f.Fuzz(func(t *testing.T, output string) {
+		parseGCOutput(output)
+	})
+}
@@ -0,0 +1,48 @@
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os/exec"
+)
+
+// ObjectSubmitter applies a single small object directly to a
+// repository's storage, bypassing the pack-processing overhead a full
+// commit incurs. Not every Receiver implementation supports it; callers
+// should type-assert for it.
+type ObjectSubmitter interface {
+	SubmitObject(repository, digest string, data []byte) error
+}
+
+// SubmitObject shells out to the receiver binary's "put-object"
+// subcommand, streaming data over its stdin. If PayloadTimeout is set,
+// the process is killed and the call fails with ErrTimeout if it runs
+// longer than that.
+func (r *ProcessReceiver) SubmitObject(repository, digest string, data []byte) error {
+	if r.BinaryPath == "" {
+		return fmt.Errorf("receiver: no binary configured")
+	}
+
+	ctx := context.Background()
+	cancel := func() {}
+	if r.PayloadTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.PayloadTimeout)
+	}
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.BinaryPath, "put-object", repository, digest)
+	cmd.Stdin = bytes.NewReader(data)
+	stderr := &boundedBuffer{}
+	cmd.Stderr = stderr
+	runErr := cmd.Run()
+
+	if ctx.Err() == context.DeadlineExceeded {
+		r.Metrics.Count("cvmfs_gateway_receiver_timeouts_total", 1, map[string]string{"repository": repository, "op": "payload"})
+		return ErrTimeout{Op: "payload", Repository: repository, Path: digest, Timeout: r.PayloadTimeout}
+	}
+	if runErr != nil {
+		return fmt.Errorf("receiver: put-object failed: %w: %s", runErr, stderr.String())
+	}
+	return nil
+}
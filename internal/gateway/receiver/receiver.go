@@ -0,0 +1,195 @@
+// Package receiver drives the cvmfs_receiver worker process that applies
+// a submitted payload to a repository's storage.
+package receiver
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/metrics"
+)
+
+// Payload describes the data submitted by a publisher for a lease.
+type Payload struct {
+	Digest string
+	Data   []byte
+
+	// DigestAlgorithm names the hash algorithm Digest was computed with,
+	// e.g. "sha256" or "blake3". Left empty, it means the publisher
+	// didn't request a specific algorithm and the receiver should use
+	// its own default (historically the only option before algorithm
+	// negotiation existed). Callers should confirm the receiver
+	// advertises support for a non-empty value, via
+	// backend.Pool.SupportsDigestAlgorithm, before submitting it.
+	DigestAlgorithm string
+
+	// Tag names the catalog revision produced by this commit. If the
+	// publisher leaves it empty, the gateway fills one in from the
+	// repository's tag naming policy before the receiver is invoked.
+	Tag string
+
+	// RequestID correlates this commit with the HTTP request that
+	// submitted it, so it can be traced through the receiver process's
+	// own logs and the diagnostics bundle captured for it. Left empty if
+	// the caller didn't supply one.
+	RequestID string
+
+	// ManifestDigests lists every object digest this commit's catalog
+	// references, whether or not it's included in Data. A publisher
+	// that queried the gateway's objects/missing endpoint beforehand
+	// sends the full manifest here but only the objects that came back
+	// missing in Data, so a receiver that supports differential payloads
+	// (see ingest.DirectReceiver) can verify the objects it didn't
+	// receive are already known to exist before committing. Left empty,
+	// it means the publisher sent every referenced object in Data, as
+	// before this field existed.
+	ManifestDigests []string
+}
+
+// Receiver applies payloads to repository storage. The production
+// implementation shells out to the cvmfs_receiver binary; tests may
+// substitute a fake.
+type Receiver interface {
+	Commit(repository, path string, payload Payload) error
+}
+
+// ProcessReceiver runs commits through the external cvmfs_receiver
+// binary. Every invocation's stdout/stderr is captured into Diagnostics
+// so publish failures can be triaged without reproducing them live, and
+// the publish statistics it reports are captured into Stats.
+type ProcessReceiver struct {
+	BinaryPath  string
+	Diagnostics *DiagnosticStore
+	Stats       *StatsStore
+
+	// Streams, if set, is fed each commit's stdout/stderr live, line by
+	// line, so a StreamHub subscriber can watch a receiver process's
+	// output while it's still running instead of waiting for the
+	// diagnostic bundle captured after it exits.
+	Streams *StreamHub
+
+	// CommitTimeout bounds how long a single Commit invocation may run
+	// before its receiver process is killed and the call fails with
+	// ErrTimeout, so a hung receiver can't block its caller (or, invoked
+	// through backend.Pool's job queue, a worker) forever. Zero disables
+	// the limit, as before this field existed.
+	CommitTimeout time.Duration
+
+	// PayloadTimeout is CommitTimeout's counterpart for SubmitObject,
+	// set separately since a single small object is expected to apply
+	// far faster than a full commit and deserves a tighter limit. Zero
+	// disables it.
+	PayloadTimeout time.Duration
+
+	// Metrics receives a count of every receiver process killed for
+	// exceeding CommitTimeout or PayloadTimeout. It defaults to
+	// metrics.NopSink, so it's always safe to call.
+	Metrics metrics.Sink
+}
+
+// NewProcessReceiver returns a Receiver that shells out to the
+// cvmfs_receiver binary at binaryPath.
+func NewProcessReceiver(binaryPath string) *ProcessReceiver {
+	return &ProcessReceiver{
+		BinaryPath:  binaryPath,
+		Diagnostics: NewDiagnosticStore(),
+		Stats:       NewStatsStore(),
+		Streams:     NewStreamHub(),
+		Metrics:     metrics.NopSink{},
+	}
+}
+
+// Commit applies payload to repository at path via the receiver binary,
+// streaming the payload over the process's stdin and capturing its
+// stdout/stderr into a diagnostic bundle regardless of outcome, plus,
+// on success, the publish statistics it reported. If CommitTimeout is
+// set, the process is killed and the call fails with ErrTimeout if it
+// runs longer than that.
+func (r *ProcessReceiver) Commit(repository, path string, payload Payload) error {
+	if r.BinaryPath == "" {
+		return fmt.Errorf("receiver: no binary configured")
+	}
+
+	args := []string{"commit", repository, path}
+	if payload.Tag != "" {
+		args = append(args, "--tag", payload.Tag)
+	}
+	if payload.DigestAlgorithm != "" {
+		args = append(args, "--digest-algorithm", payload.DigestAlgorithm)
+	}
+
+	ctx := context.Background()
+	cancel := func() {}
+	if r.CommitTimeout > 0 {
+		ctx, cancel = context.WithTimeout(ctx, r.CommitTimeout)
+	}
+	defer cancel()
+
+	cmd := exec.CommandContext(ctx, r.BinaryPath, args...)
+	cmd.Stdin = bytes.NewReader(payload.Data)
+	if payload.RequestID != "" {
+		cmd.Env = append(os.Environ(), "CVMFS_GATEWAY_REQUEST_ID="+payload.RequestID)
+	}
+	stdout := &boundedBuffer{}
+	stderr := &boundedBuffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if r.Streams != nil {
+		cmd.Stdout = io.MultiWriter(stdout, &lineWriter{onLine: func(line string) {
+			r.Streams.publish(path, "stdout: "+line)
+		}})
+		cmd.Stderr = io.MultiWriter(stderr, &lineWriter{onLine: func(line string) {
+			r.Streams.publish(path, "stderr: "+line)
+		}})
+		defer r.Streams.closeStream(path)
+	}
+
+	start := time.Now()
+	runErr := cmd.Run()
+
+	exitCode := -1
+	if cmd.ProcessState != nil {
+		exitCode = cmd.ProcessState.ExitCode()
+	}
+	usage, _ := resourceUsageFromProcessState(cmd)
+
+	bundle := DiagnosticBundle{
+		Repository: repository,
+		Path:       path,
+		Stdout:     stdout.String(),
+		Stderr:     stderr.String(),
+		Truncated:  stdout.Truncated() || stderr.Truncated(),
+		ExitCode:   exitCode,
+		RanAt:      time.Now(),
+		RequestID:  payload.RequestID,
+		Usage:      usage,
+	}
+	if runErr != nil {
+		bundle.Err = runErr.Error()
+	}
+	if r.Diagnostics != nil {
+		r.Diagnostics.Save(path, bundle)
+	}
+
+	if ctx.Err() == context.DeadlineExceeded {
+		r.Metrics.Count("cvmfs_gateway_receiver_timeouts_total", 1, map[string]string{"repository": repository, "op": "commit"})
+		return ErrTimeout{Op: "commit", Repository: repository, Path: path, Timeout: r.CommitTimeout}
+	}
+
+	if runErr == nil && r.Stats != nil {
+		stats := parsePublishStats(stdout.String())
+		stats.Repository = repository
+		stats.Path = path
+		stats.Duration = time.Since(start)
+		stats.RanAt = bundle.RanAt
+		stats.Usage = usage
+		r.Stats.save(path, stats)
+	}
+
+	return runErr
+}
@@ -0,0 +1,54 @@
+package receiver
+
+import (
+	"os/exec"
+	"syscall"
+)
+
+// ResourceUsage summarizes a single receiver process invocation's
+// resource consumption, collected from the kernel's wait4/rusage
+// accounting once the process exits, so a repository whose publishes are
+// becoming pathologically expensive shows up in its stats and diagnostic
+// bundle instead of only in its wall-clock duration.
+type ResourceUsage struct {
+	// UserCPUSeconds and SystemCPUSeconds are the process's accumulated
+	// CPU time in user and kernel mode.
+	UserCPUSeconds   float64 `json:"user_cpu_seconds"`
+	SystemCPUSeconds float64 `json:"system_cpu_seconds"`
+
+	// MaxRSSBytes is the process's peak resident set size.
+	MaxRSSBytes int64 `json:"max_rss_bytes"`
+
+	// InputBytes and OutputBytes estimate block I/O from the rusage
+	// block counts (Inblock/Oublock), each scaled by the traditional
+	// 512-byte block size rusage reports in. They're an approximation,
+	// not an exact byte count, since the kernel accounts I/O in blocks.
+	InputBytes  int64 `json:"input_bytes"`
+	OutputBytes int64 `json:"output_bytes"`
+}
+
+// rusageBlockSize is the block size wait4/rusage's Inblock and Oublock
+// counters are traditionally reported in.
+const rusageBlockSize = 512
+
+// resourceUsageFromProcessState extracts a ResourceUsage from cmd's
+// ProcessState, if the platform's wait4 populated rusage data (true on
+// Linux, this gateway's only supported deployment target). It returns
+// false if cmd never ran to completion or the platform doesn't expose
+// rusage in the expected form.
+func resourceUsageFromProcessState(cmd *exec.Cmd) (ResourceUsage, bool) {
+	if cmd.ProcessState == nil {
+		return ResourceUsage{}, false
+	}
+	usage, ok := cmd.ProcessState.SysUsage().(*syscall.Rusage)
+	if !ok || usage == nil {
+		return ResourceUsage{}, false
+	}
+	return ResourceUsage{
+		UserCPUSeconds:   cmd.ProcessState.UserTime().Seconds(),
+		SystemCPUSeconds: cmd.ProcessState.SystemTime().Seconds(),
+		MaxRSSBytes:      int64(usage.Maxrss) * 1024,
+		InputBytes:       int64(usage.Inblock) * rusageBlockSize,
+		OutputBytes:      int64(usage.Oublock) * rusageBlockSize,
+	}, true
+}
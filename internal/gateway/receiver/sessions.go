@@ -0,0 +1,14 @@
+package receiver
+
+// SessionReporter is implemented by Receiver types that can report the
+// set of paths they currently consider to have an open upstream
+// transaction, independent of the gateway's own lease bookkeeping, so a
+// consistency check can catch drift between the two -- for example a
+// receiver process that crashed mid-upload without the gateway noticing,
+// or upstream state left behind after an admin force-cancelled a lease.
+// ProcessReceiver doesn't implement it: as a stateless wrapper around a
+// CLI invocation, it retains no memory of sessions between commands.
+// Callers should type-assert for it.
+type SessionReporter interface {
+	ActiveSessions() ([]string, error)
+}
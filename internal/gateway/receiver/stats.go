@@ -0,0 +1,110 @@
+package receiver
+
+import (
+	"bufio"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PublishStats summarizes a single commit's effect on a repository,
+// mirroring what "cvmfs_server stats" reports for a local publish.
+type PublishStats struct {
+	Repository      string        `json:"repository"`
+	Path            string        `json:"path"`
+	FilesAdded      int           `json:"files_added"`
+	FilesRemoved    int           `json:"files_removed"`
+	BytesAdded      int64         `json:"bytes_added"`
+	CatalogsTouched int           `json:"catalogs_touched"`
+	Duration        time.Duration `json:"duration"`
+	RanAt           time.Time     `json:"ran_at"`
+
+	// OldRootHash and NewRootHash are the repository's root catalog
+	// hashes immediately before and after this commit, when the
+	// receiver reports them. Left empty for receivers that don't print
+	// the "old_root_hash"/"new_root_hash" lines.
+	OldRootHash string `json:"old_root_hash,omitempty"`
+	NewRootHash string `json:"new_root_hash,omitempty"`
+
+	// Usage reports the receiver process's resource consumption for this
+	// commit, so a repository whose publishes are becoming
+	// pathologically expensive to run shows up here, not just in
+	// Duration. Zero-valued if the platform didn't expose rusage.
+	Usage ResourceUsage `json:"usage"`
+}
+
+// StatsStore keeps the most recent publish statistics for each
+// repository subpath a commit has run against.
+type StatsStore struct {
+	mu    sync.Mutex
+	stats map[string]PublishStats
+}
+
+// NewStatsStore returns an empty publish statistics store.
+func NewStatsStore() *StatsStore {
+	return &StatsStore{stats: make(map[string]PublishStats)}
+}
+
+// save records stats for path, replacing any earlier entry.
+func (s *StatsStore) save(path string, stats PublishStats) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stats[path] = stats
+}
+
+// Get returns the most recently recorded publish statistics for path.
+func (s *StatsStore) Get(path string) (PublishStats, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	stats, ok := s.stats[path]
+	return stats, ok
+}
+
+// parsePublishStats parses a commit invocation's stdout for the
+// key/value statistics lines the receiver prints alongside its normal
+// output, in the form "<key> <value>", e.g. "files_added 3". Lines it
+// doesn't recognize are ignored, so ordinary commit log output mixed in
+// with the stats lines doesn't cause a parse failure.
+func parsePublishStats(output string) PublishStats {
+	var stats PublishStats
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		switch fields[0] {
+		case "files_added":
+			stats.FilesAdded, _ = strconv.Atoi(fields[1])
+		case "files_removed":
+			stats.FilesRemoved, _ = strconv.Atoi(fields[1])
+		case "bytes_added":
+			stats.BytesAdded, _ = strconv.ParseInt(fields[1], 10, 64)
+		case "catalogs_touched":
+			stats.CatalogsTouched, _ = strconv.Atoi(fields[1])
+		case "old_root_hash":
+			stats.OldRootHash = fields[1]
+		case "new_root_hash":
+			stats.NewRootHash = fields[1]
+		}
+	}
+	return stats
+}
+
+// StatsReporter is implemented by Receiver types that record publish
+// statistics for each commit, retrievable afterward by path. Not every
+// Receiver implementation supports it; callers should type-assert for
+// it, mirroring Differ and GCer.
+type StatsReporter interface {
+	LastStats(path string) (PublishStats, bool)
+}
+
+// LastStats returns the most recently recorded publish statistics for
+// path, implementing StatsReporter.
+func (r *ProcessReceiver) LastStats(path string) (PublishStats, bool) {
+	if r.Stats == nil {
+		return PublishStats{}, false
+	}
+	return r.Stats.Get(path)
+}
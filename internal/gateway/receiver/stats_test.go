@@ -0,0 +1,70 @@
+package receiver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParsePublishStats(t *testing.T) {
+	stats := parsePublishStats("files_added 3\nfiles_removed 1\nbytes_added 2048\ncatalogs_touched 2\n")
+	want := PublishStats{FilesAdded: 3, FilesRemoved: 1, BytesAdded: 2048, CatalogsTouched: 2}
+	if stats.FilesAdded != want.FilesAdded || stats.FilesRemoved != want.FilesRemoved ||
+		stats.BytesAdded != want.BytesAdded || stats.CatalogsTouched != want.CatalogsTouched {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func TestParsePublishStatsSkipsUnrecognizedLines(t *testing.T) {
+	stats := parsePublishStats("publishing catalog...\nfiles_added 1\n")
+	if stats.FilesAdded != 1 {
+		t.Fatalf("expected files_added 1, got %+v", stats)
+	}
+}
+
+// TestParsePublishStatsMalformedInput is a negative-test suite: a
+// misbehaving receiver binary's stdout should never make this parser
+// panic or hang, whatever garbage it emits.
+func TestParsePublishStatsMalformedInput(t *testing.T) {
+	cases := []string{
+		"",
+		"\x00\x01\x02binary garbage\xff\xfe",
+		"files_added\n",
+		"files_added not-a-number\n",
+		"bytes_added 99999999999999999999999999999999\n",
+		strings.Repeat("files_added 1\n", 100000),
+		"files_added " + strings.Repeat("9", 10000) + "\n",
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("parsePublishStats panicked on %q: %v", c, r)
+				}
+			}()
+			parsePublishStats(c)
+		}()
+	}
+}
+
+func FuzzParsePublishStats(f *testing.F) {
+	f.Add("files_added 3\nfiles_removed 1\nbytes_added 2048\ncatalogs_touched 2\n")
+	f.Add("")
+	f.Add("publishing catalog...\nfiles_added 1\n")
+	f.Add("files_added not-a-number\n")
+	f.Fuzz(func(t *testing.T, output string) {
+		parsePublishStats(output)
+	})
+}
+
+func TestStatsStoreSaveAndGet(t *testing.T) {
+	s := NewStatsStore()
+	if _, ok := s.Get("repo.example.org/a"); ok {
+		t.Fatal("expected no stats before any save")
+	}
+
+	s.save("repo.example.org/a", PublishStats{FilesAdded: 5})
+	stats, ok := s.Get("repo.example.org/a")
+	if !ok || stats.FilesAdded != 5 {
+		t.Fatalf("unexpected stats: %+v, ok=%v", stats, ok)
+	}
+}
@@ -0,0 +1,97 @@
+package receiver
+
+import (
+	"bytes"
+	"sync"
+)
+
+// StreamHub multiplexes an in-flight commit's stdout/stderr, line by
+// line, to any number of subscribers attached while the commit is
+// running, so an operator can watch a receiver process's live output
+// for a lease under investigation instead of waiting for the
+// DiagnosticStore bundle captured after it exits.
+type StreamHub struct {
+	mu   sync.Mutex
+	subs map[string][]chan string
+}
+
+// NewStreamHub returns an empty StreamHub.
+func NewStreamHub() *StreamHub {
+	return &StreamHub{subs: make(map[string][]chan string)}
+}
+
+// Subscribe registers a new subscriber for path's live output, returning
+// a channel of output lines that closes once the commit finishes, and an
+// unsubscribe function the caller must call if it stops listening
+// before then.
+func (h *StreamHub) Subscribe(path string) (<-chan string, func()) {
+	ch := make(chan string, 64)
+	h.mu.Lock()
+	h.subs[path] = append(h.subs[path], ch)
+	h.mu.Unlock()
+
+	return ch, func() {
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		subs := h.subs[path]
+		for i, s := range subs {
+			if s == ch {
+				h.subs[path] = append(subs[:i], subs[i+1:]...)
+				break
+			}
+		}
+		if len(h.subs[path]) == 0 {
+			delete(h.subs, path)
+		}
+	}
+}
+
+// publish sends line to every subscriber currently attached to path,
+// dropping it for any subscriber whose buffer is full rather than
+// blocking the commit on a slow reader.
+func (h *StreamHub) publish(path, line string) {
+	h.mu.Lock()
+	subs := append([]chan string(nil), h.subs[path]...)
+	h.mu.Unlock()
+	for _, ch := range subs {
+		select {
+		case ch <- line:
+		default:
+		}
+	}
+}
+
+// closeStream closes and drops every subscriber channel for path. It's
+// called once a commit finishes so a stream handler watching it ends
+// instead of hanging forever.
+func (h *StreamHub) closeStream(path string) {
+	h.mu.Lock()
+	subs := h.subs[path]
+	delete(h.subs, path)
+	h.mu.Unlock()
+	for _, ch := range subs {
+		close(ch)
+	}
+}
+
+// lineWriter splits a written byte stream into lines and invokes onLine
+// for each one as it completes, buffering any trailing partial line
+// until the next Write.
+type lineWriter struct {
+	onLine func(line string)
+	buf    bytes.Buffer
+}
+
+func (w *lineWriter) Write(p []byte) (int, error) {
+	w.buf.Write(p)
+	for {
+		data := w.buf.Bytes()
+		idx := bytes.IndexByte(data, '\n')
+		if idx < 0 {
+			break
+		}
+		w.onLine(string(data[:idx]))
+		w.buf.Next(idx + 1)
+	}
+	return len(p), nil
+}
@@ -0,0 +1,45 @@
+package receiver
+
+import "testing"
+
+func TestStreamHubPublishesToSubscriber(t *testing.T) {
+	hub := NewStreamHub()
+	lines, unsubscribe := hub.Subscribe("repo.example.org/a")
+	defer unsubscribe()
+
+	hub.publish("repo.example.org/a", "stdout: hello")
+	select {
+	case line := <-lines:
+		if line != "stdout: hello" {
+			t.Fatalf("unexpected line: %q", line)
+		}
+	default:
+		t.Fatal("expected a buffered line to be immediately readable")
+	}
+}
+
+func TestStreamHubClosesSubscribersOnCloseStream(t *testing.T) {
+	hub := NewStreamHub()
+	lines, unsubscribe := hub.Subscribe("repo.example.org/a")
+	defer unsubscribe()
+
+	hub.closeStream("repo.example.org/a")
+	if _, open := <-lines; open {
+		t.Fatal("expected subscriber channel to be closed")
+	}
+}
+
+func TestLineWriterSplitsOnNewlines(t *testing.T) {
+	var got []string
+	w := &lineWriter{onLine: func(line string) { got = append(got, line) }}
+
+	w.Write([]byte("first\nsecond\npart"))
+	if len(got) != 2 || got[0] != "first" || got[1] != "second" {
+		t.Fatalf("unexpected lines after partial write: %+v", got)
+	}
+
+	w.Write([]byte("ial\n"))
+	if len(got) != 3 || got[2] != "partial" {
+		t.Fatalf("unexpected lines after completing partial write: %+v", got)
+	}
+}
@@ -0,0 +1,20 @@
+package receiver
+
+import (
+	"fmt"
+	"time"
+)
+
+// ErrTimeout is returned when a receiver process is killed for exceeding
+// its configured wall-clock limit, distinguishing a hung receiver from an
+// ordinary failure so callers can classify and alert on it separately.
+type ErrTimeout struct {
+	Op         string
+	Repository string
+	Path       string
+	Timeout    time.Duration
+}
+
+func (e ErrTimeout) Error() string {
+	return fmt.Sprintf("receiver: %s for %s/%s exceeded its %s timeout and was killed", e.Op, e.Repository, e.Path, e.Timeout)
+}
@@ -0,0 +1,86 @@
+package receiver
+
+import (
+	"fmt"
+	"os/exec"
+	"strconv"
+	"strings"
+)
+
+// MinSupportedVersion is the oldest cvmfs_receiver version this gateway
+// is known to work correctly with. Older receivers may accept commits
+// but silently misbehave on newer request formats, which otherwise only
+// surfaces as a mysterious failure at first commit.
+const MinSupportedVersion = "2.9.0"
+
+// Versioner is implemented by a Receiver that can report its own
+// version and capability set. It's an optional capability like Differ
+// and GCer; callers should type-assert for it, since not every Receiver
+// (e.g. a test fake) needs to support it.
+type Versioner interface {
+	Version() (VersionInfo, error)
+}
+
+// VersionInfo describes a receiver binary's reported version and the
+// capabilities it advertises.
+type VersionInfo struct {
+	Version      string
+	Capabilities []string
+}
+
+// Version invokes the receiver binary with --version and parses its
+// output, which is expected to be a version string optionally followed
+// by a comma-separated capability list, e.g. "2.10.1 diff,gc".
+func (r *ProcessReceiver) Version() (VersionInfo, error) {
+	if r.BinaryPath == "" {
+		return VersionInfo{}, fmt.Errorf("receiver: no binary configured")
+	}
+
+	cmd := exec.Command(r.BinaryPath, "--version")
+	stdout := &boundedBuffer{}
+	cmd.Stdout = stdout
+	if err := cmd.Run(); err != nil {
+		return VersionInfo{}, fmt.Errorf("receiver: could not query version: %w", err)
+	}
+
+	return parseVersionOutput(stdout.String()), nil
+}
+
+func parseVersionOutput(output string) VersionInfo {
+	fields := strings.Fields(strings.TrimSpace(output))
+	if len(fields) == 0 {
+		return VersionInfo{}
+	}
+	info := VersionInfo{Version: fields[0]}
+	if len(fields) > 1 {
+		info.Capabilities = strings.Split(fields[1], ",")
+	}
+	return info
+}
+
+// Compatible reports whether v is at least MinSupportedVersion, using
+// dotted-integer (major.minor.patch) comparison.
+func Compatible(v string) bool {
+	return compareVersions(v, MinSupportedVersion) >= 0
+}
+
+func compareVersions(a, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		var an, bn int
+		if i < len(as) {
+			an, _ = strconv.Atoi(as[i])
+		}
+		if i < len(bs) {
+			bn, _ = strconv.Atoi(bs[i])
+		}
+		if an != bn {
+			if an < bn {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
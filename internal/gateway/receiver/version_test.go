@@ -0,0 +1,82 @@
+package receiver
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseVersionOutput(t *testing.T) {
+	info := parseVersionOutput("2.10.1 diff,gc\n")
+	if info.Version != "2.10.1" {
+		t.Fatalf("expected version 2.10.1, got %q", info.Version)
+	}
+	if len(info.Capabilities) != 2 || info.Capabilities[0] != "diff" || info.Capabilities[1] != "gc" {
+		t.Fatalf("expected capabilities [diff gc], got %v", info.Capabilities)
+	}
+}
+
+func TestParseVersionOutputWithoutCapabilities(t *testing.T) {
+	info := parseVersionOutput("2.10.1\n")
+	if info.Version != "2.10.1" || len(info.Capabilities) != 0 {
+		t.Fatalf("expected bare version with no capabilities, got %+v", info)
+	}
+}
+
+// TestParseVersionOutputMalformedInput is a negative-test suite: a
+// misbehaving receiver binary's --version output should never make this
+// parser panic or hang, whatever garbage it emits.
+func TestParseVersionOutputMalformedInput(t *testing.T) {
+	cases := []string{
+		"",
+		"   ",
+		"\x00\x01\x02binary garbage\xff\xfe",
+		strings.Repeat("2.10.1,", 100000),
+		"2.10.1 " + strings.Repeat("cap,", 100000),
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("parseVersionOutput panicked on %q: %v", c, r)
+				}
+			}()
+			parseVersionOutput(c)
+		}()
+	}
+}
+
+func FuzzParseVersionOutput(f *testing.F) {
+	f.Add("2.10.1 diff,gc\n")
+	f.Add("2.10.1\n")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, output string) {
+		parseVersionOutput(output)
+	})
+}
+
+func FuzzCompatible(f *testing.F) {
+	f.Add("2.9.0")
+	f.Add("not-a-version")
+	f.Add("")
+	f.Fuzz(func(t *testing.T, version string) {
+		Compatible(version)
+	})
+}
+
+func TestCompatible(t *testing.T) {
+	cases := []struct {
+		version string
+		want    bool
+	}{
+		{"2.9.0", true},
+		{"2.10.0", true},
+		{"3.0.0", true},
+		{"2.8.9", false},
+		{"1.9.9", false},
+	}
+	for _, c := range cases {
+		if got := Compatible(c.version); got != c.want {
+			t.Errorf("Compatible(%q) = %v, want %v", c.version, got, c.want)
+		}
+	}
+}
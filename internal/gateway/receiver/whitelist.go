@@ -0,0 +1,64 @@
+package receiver
+
+import (
+	"bufio"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+)
+
+// WhitelistInfo describes a repository's current whitelist signature.
+type WhitelistInfo struct {
+	Repository string    `json:"repository"`
+	SignedAt   time.Time `json:"signed_at"`
+	Expiry     time.Time `json:"expiry"`
+}
+
+// WhitelistSigner re-signs a repository's whitelist and reports the
+// resulting signature's expiry. Not every Receiver implementation
+// supports it; callers should type-assert for it, mirroring Differ and
+// GCer. An external signing service can also implement it in place of a
+// receiver-backed implementation.
+type WhitelistSigner interface {
+	ResignWhitelist(repository string) (WhitelistInfo, error)
+}
+
+// ResignWhitelist shells out to the receiver binary's "resign-whitelist"
+// subcommand and parses its reported expiry, implementing
+// WhitelistSigner so the same receiver process used for commits can also
+// keep a repository's whitelist signature fresh.
+func (r *ProcessReceiver) ResignWhitelist(repository string) (WhitelistInfo, error) {
+	if r.BinaryPath == "" {
+		return WhitelistInfo{}, fmt.Errorf("receiver: no binary configured")
+	}
+
+	cmd := exec.Command(r.BinaryPath, "resign-whitelist", repository)
+	stdout := &boundedBuffer{}
+	stderr := &boundedBuffer{}
+	cmd.Stdout = stdout
+	cmd.Stderr = stderr
+	if err := cmd.Run(); err != nil {
+		return WhitelistInfo{}, fmt.Errorf("receiver: resign-whitelist failed: %w: %s", err, stderr.String())
+	}
+
+	expiry, err := parseWhitelistExpiry(stdout.String())
+	if err != nil {
+		return WhitelistInfo{}, fmt.Errorf("receiver: could not parse resign-whitelist output: %w", err)
+	}
+	return WhitelistInfo{Repository: repository, SignedAt: time.Now(), Expiry: expiry}, nil
+}
+
+// parseWhitelistExpiry scans output for a line of the form
+// "expiry <RFC3339 timestamp>", the format the receiver binary's
+// resign-whitelist subcommand reports its new signature's expiry in.
+func parseWhitelistExpiry(output string) (time.Time, error) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 && fields[0] == "expiry" {
+			return time.Parse(time.RFC3339, fields[1])
+		}
+	}
+	return time.Time{}, fmt.Errorf("no expiry line found in output")
+}
@@ -0,0 +1,57 @@
+package receiver
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestParseWhitelistExpiry(t *testing.T) {
+	expiry, err := parseWhitelistExpiry("resigning whitelist...\nexpiry 2026-09-01T00:00:00Z\n")
+	if err != nil {
+		t.Fatalf("parseWhitelistExpiry: %v", err)
+	}
+	want := time.Date(2026, time.September, 1, 0, 0, 0, 0, time.UTC)
+	if !expiry.Equal(want) {
+		t.Fatalf("got %v, want %v", expiry, want)
+	}
+}
+
+func TestParseWhitelistExpiryFailsWithoutExpiryLine(t *testing.T) {
+	if _, err := parseWhitelistExpiry("resigning whitelist...\n"); err == nil {
+		t.Fatal("expected an error when no expiry line is present")
+	}
+}
+
+// TestParseWhitelistExpiryMalformedInput is a negative-test suite: a
+// misbehaving receiver binary's resign-whitelist output should never
+// make this parser panic or hang, whatever garbage it emits.
+func TestParseWhitelistExpiryMalformedInput(t *testing.T) {
+	cases := []string{
+		"",
+		"\x00\x01\x02binary garbage\xff\xfe",
+		"expiry\n",
+		"expiry not-a-timestamp\n",
+		"expiry " + strings.Repeat("9", 10000) + "\n",
+		strings.Repeat("expiry not-a-timestamp\n", 100000),
+	}
+	for _, c := range cases {
+		func() {
+			defer func() {
+				if r := recover(); r != nil {
+					t.Fatalf("parseWhitelistExpiry panicked on %q: %v", c, r)
+				}
+			}()
+			parseWhitelistExpiry(c)
+		}()
+	}
+}
+
+func FuzzParseWhitelistExpiry(f *testing.F) {
+	f.Add("expiry 2026-09-01T00:00:00Z\n")
+	f.Add("")
+	f.Add("expiry not-a-timestamp\n")
+	f.Fuzz(func(t *testing.T, output string) {
+		parseWhitelistExpiry(output)
+	})
+}
@@ -0,0 +1,260 @@
+// Package recorder implements optional request/response capture for
+// protocol debugging: a bounded ring buffer of recent HTTP exchanges for
+// keys or repositories an operator has explicitly enrolled, so a
+// publisher's client protocol issue can be diagnosed from the admin API
+// without resorting to a packet capture.
+package recorder
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Exchange captures one HTTP request/response pair. Bodies are never
+// retained in full, only their size and digest, so a captured exchange
+// is safe to hand to an operator without leaking repository content.
+type Exchange struct {
+	At       time.Time     `json:"at"`
+	Duration time.Duration `json:"duration_ns"`
+	Method   string        `json:"method"`
+	Path     string        `json:"path"`
+	KeyID    string        `json:"key_id,omitempty"`
+	Repo     string        `json:"repo,omitempty"`
+
+	RequestBodySize   int64  `json:"request_body_size"`
+	RequestBodyDigest string `json:"request_body_digest,omitempty"`
+
+	ResponseStatus     int    `json:"response_status"`
+	ResponseBodySize   int64  `json:"response_body_size"`
+	ResponseBodyDigest string `json:"response_body_digest,omitempty"`
+}
+
+// Recorder wraps an http.Handler, capturing a bounded ring buffer of
+// request/response pairs for keys or repositories explicitly enrolled
+// via EnableKey or EnableRepo. A request whose key and repository are
+// both unenrolled passes straight through to next and is never
+// buffered, so enrolling nothing (the default) costs nothing.
+type Recorder struct {
+	next     http.Handler
+	capacity int
+	keyOf    func(*http.Request) string
+	repoOf   func(*http.Request) string
+
+	mu      sync.Mutex
+	keys    map[string]bool
+	repos   map[string]bool
+	entries []Exchange
+}
+
+// New returns a Recorder wrapping next that retains at most capacity
+// exchanges, discarding the oldest once full. keyOf and repoOf extract
+// the requesting key ID and target repository from a request, for
+// matching against the enrollment sets; KeyFromBearer and RepoFromPath
+// are reasonable defaults for the gateway's own API.
+func New(next http.Handler, capacity int, keyOf, repoOf func(*http.Request) string) *Recorder {
+	return &Recorder{
+		next:     next,
+		capacity: capacity,
+		keyOf:    keyOf,
+		repoOf:   repoOf,
+		keys:     make(map[string]bool),
+		repos:    make(map[string]bool),
+	}
+}
+
+// EnableKey enrolls keyID's requests in recording.
+func (rec *Recorder) EnableKey(keyID string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.keys[keyID] = true
+}
+
+// DisableKey removes keyID from recording.
+func (rec *Recorder) DisableKey(keyID string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	delete(rec.keys, keyID)
+}
+
+// EnableRepo enrolls repo's requests in recording.
+func (rec *Recorder) EnableRepo(repo string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.repos[repo] = true
+}
+
+// DisableRepo removes repo from recording.
+func (rec *Recorder) DisableRepo(repo string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	delete(rec.repos, repo)
+}
+
+// Enrolled returns the keys and repositories currently enrolled in
+// recording, for introspection via the admin API.
+func (rec *Recorder) Enrolled() (keys, repos []string) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	for k := range rec.keys {
+		keys = append(keys, k)
+	}
+	for r := range rec.repos {
+		repos = append(repos, r)
+	}
+	return keys, repos
+}
+
+// Snapshot returns every retained exchange, oldest first.
+func (rec *Recorder) Snapshot() []Exchange {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	out := make([]Exchange, len(rec.entries))
+	copy(out, rec.entries)
+	return out
+}
+
+// Clear discards every retained exchange without changing enrollment.
+func (rec *Recorder) Clear() {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.entries = nil
+}
+
+func (rec *Recorder) enabled(keyID, repo string) bool {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	return rec.keys[keyID] || rec.repos[repo]
+}
+
+func (rec *Recorder) record(e Exchange) {
+	rec.mu.Lock()
+	defer rec.mu.Unlock()
+	rec.entries = append(rec.entries, e)
+	if over := len(rec.entries) - rec.capacity; over > 0 {
+		rec.entries = rec.entries[over:]
+	}
+}
+
+// ServeHTTP serves r with the wrapped handler, unchanged from the
+// caller's point of view. If r's key or repository is enrolled, the
+// request body and buffered response are hashed and appended to the
+// ring buffer before the response is written out.
+func (rec *Recorder) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	keyID, repo := rec.keyOf(r), rec.repoOf(r)
+	if !rec.enabled(keyID, repo) {
+		rec.next.ServeHTTP(w, r)
+		return
+	}
+
+	var reqBody []byte
+	if r.Body != nil {
+		reqBody, _ = io.ReadAll(r.Body)
+		r.Body.Close()
+		r.Body = io.NopCloser(bytes.NewReader(reqBody))
+	}
+
+	start := time.Now()
+	rw := newResponseBuffer()
+	rec.next.ServeHTTP(rw, r)
+
+	for k, v := range rw.Header() {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rw.status)
+	w.Write(rw.body.Bytes())
+
+	reqDigest := sha256.Sum256(reqBody)
+	respBody := rw.body.Bytes()
+	respDigest := sha256.Sum256(respBody)
+
+	rec.record(Exchange{
+		At:                 start,
+		Duration:           time.Since(start),
+		Method:             r.Method,
+		Path:               r.URL.Path,
+		KeyID:              keyID,
+		Repo:               repo,
+		RequestBodySize:    int64(len(reqBody)),
+		RequestBodyDigest:  hex.EncodeToString(reqDigest[:]),
+		ResponseStatus:     rw.status,
+		ResponseBodySize:   int64(len(respBody)),
+		ResponseBodyDigest: hex.EncodeToString(respDigest[:]),
+	})
+}
+
+// responseBuffer is a minimal http.ResponseWriter that buffers the
+// response instead of writing it through immediately, so it can be
+// hashed before being relayed to the real client.
+type responseBuffer struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+	wrote  bool
+}
+
+func newResponseBuffer() *responseBuffer {
+	return &responseBuffer{header: make(http.Header), status: http.StatusOK}
+}
+
+func (rw *responseBuffer) Header() http.Header { return rw.header }
+
+func (rw *responseBuffer) WriteHeader(status int) {
+	if rw.wrote {
+		return
+	}
+	rw.wrote = true
+	rw.status = status
+}
+
+func (rw *responseBuffer) Write(p []byte) (int, error) {
+	if !rw.wrote {
+		rw.WriteHeader(http.StatusOK)
+	}
+	return rw.body.Write(p)
+}
+
+// KeyFromBearer extracts the raw bearer token from r's Authorization
+// header. It's a reasonable default keyOf for the gateway's own API,
+// whose HMAC keys and lease tokens are both carried as bearer tokens.
+func KeyFromBearer(r *http.Request) string {
+	const prefix = "Bearer "
+	h := r.Header.Get("Authorization")
+	if !strings.HasPrefix(h, prefix) {
+		return ""
+	}
+	return strings.TrimPrefix(h, prefix)
+}
+
+// repoPathPrefixes lists the gateway's own repository-scoped API path
+// prefixes, in the order RepoFromPath checks them.
+var repoPathPrefixes = []string{
+	"/api/v1/leases/",
+	"/api/v1/stats/",
+	"/api/v1/diagnostics/stream/",
+	"/api/v1/diagnostics/",
+	"/api/v1/receipts/",
+	"/api/v1/repos/",
+}
+
+// RepoFromPath returns the first path segment following one of the
+// gateway's known repository-scoped API prefixes, or "" if r's path
+// doesn't name a repository this way. It's a reasonable default repoOf
+// for the gateway's own API.
+func RepoFromPath(r *http.Request) string {
+	for _, prefix := range repoPathPrefixes {
+		rest := strings.TrimPrefix(r.URL.Path, prefix)
+		if rest == r.URL.Path {
+			continue
+		}
+		if repo := strings.SplitN(rest, "/", 2)[0]; repo != "" {
+			return repo
+		}
+	}
+	return ""
+}
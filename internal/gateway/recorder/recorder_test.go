@@ -0,0 +1,93 @@
+package recorder
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func echoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("response body"))
+	})
+}
+
+func TestUnenrolledRequestPassesThroughWithoutBuffering(t *testing.T) {
+	rec := New(echoHandler(), 10, KeyFromBearer, RepoFromPath)
+
+	w := httptest.NewRecorder()
+	rec.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/leases/myrepo/some/path", nil))
+
+	if w.Code != http.StatusTeapot || w.Body.String() != "response body" {
+		t.Fatalf("expected the caller to see the wrapped handler's response unchanged, got %d %q", w.Code, w.Body.String())
+	}
+	if snap := rec.Snapshot(); len(snap) != 0 {
+		t.Fatalf("expected nothing recorded for an unenrolled request, got %d entries", len(snap))
+	}
+}
+
+func TestEnrolledRepoRecordsExchange(t *testing.T) {
+	rec := New(echoHandler(), 10, KeyFromBearer, RepoFromPath)
+	rec.EnableRepo("myrepo")
+
+	req := httptest.NewRequest(http.MethodPost, "/api/v1/leases/myrepo/some/path", strings.NewReader("payload"))
+	w := httptest.NewRecorder()
+	rec.ServeHTTP(w, req)
+
+	if w.Code != http.StatusTeapot || w.Body.String() != "response body" {
+		t.Fatalf("expected the caller to see the wrapped handler's response unchanged, got %d %q", w.Code, w.Body.String())
+	}
+
+	snap := rec.Snapshot()
+	if len(snap) != 1 {
+		t.Fatalf("expected one recorded exchange, got %d", len(snap))
+	}
+	e := snap[0]
+	if e.Repo != "myrepo" || e.ResponseStatus != http.StatusTeapot || e.RequestBodySize != int64(len("payload")) {
+		t.Fatalf("unexpected exchange: %+v", e)
+	}
+}
+
+func TestRingBufferDiscardsOldestOnceFull(t *testing.T) {
+	rec := New(echoHandler(), 2, KeyFromBearer, RepoFromPath)
+	rec.EnableRepo("myrepo")
+
+	for i := 0; i < 3; i++ {
+		rec.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/api/v1/leases/myrepo/p", nil))
+	}
+
+	if snap := rec.Snapshot(); len(snap) != 2 {
+		t.Fatalf("expected ring buffer capped at 2 entries, got %d", len(snap))
+	}
+}
+
+func TestDisableKeyStopsFurtherRecording(t *testing.T) {
+	rec := New(echoHandler(), 10, KeyFromBearer, RepoFromPath)
+	rec.EnableKey("keyA")
+	rec.DisableKey("keyA")
+
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/leases/myrepo/p", nil)
+	req.Header.Set("Authorization", "Bearer keyA")
+	rec.ServeHTTP(httptest.NewRecorder(), req)
+
+	if snap := rec.Snapshot(); len(snap) != 0 {
+		t.Fatalf("expected no recording after DisableKey, got %d entries", len(snap))
+	}
+}
+
+func TestRepoFromPathMatchesKnownPrefixes(t *testing.T) {
+	cases := map[string]string{
+		"/api/v1/leases/myrepo/some/path": "myrepo",
+		"/api/v1/stats/myrepo":            "myrepo",
+		"/api/v1/repos/myrepo/objects":    "myrepo",
+		"/api/v1/health":                  "",
+	}
+	for path, want := range cases {
+		r := httptest.NewRequest(http.MethodGet, path, nil)
+		if got := RepoFromPath(r); got != want {
+			t.Errorf("RepoFromPath(%q) = %q, want %q", path, got, want)
+		}
+	}
+}
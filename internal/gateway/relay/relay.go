@@ -0,0 +1,142 @@
+// Package relay implements the gateway's reverse mode: forwarding
+// lease, payload, and cancel requests for repositories marked "remote"
+// to an upstream gateway, with credential translation, so an edge
+// gateway can sit closer to publishers in a distributed organization
+// without hosting the repository itself.
+package relay
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+)
+
+// Upstream describes the remote gateway a repository's requests should
+// be forwarded to, and the credential to present there in place of the
+// one the local publisher authenticated with.
+type Upstream struct {
+	// URL is the upstream gateway's base URL, e.g.
+	// "https://gateway.example.org".
+	URL string
+
+	// KeyID is the key this gateway authenticates to the upstream with.
+	// It need not match, and typically doesn't match, the key ID the
+	// local publisher used to reach this gateway.
+	KeyID string
+}
+
+// Relay forwards API calls for remote repositories to their configured
+// upstream gateways.
+type Relay struct {
+	client   *http.Client
+	upstream func(repo string) (Upstream, bool)
+}
+
+// New returns a Relay that looks up each repository's upstream gateway
+// via lookup. lookup returning ok == false means the repository is
+// hosted locally and should not be forwarded.
+func New(lookup func(repo string) (Upstream, bool)) *Relay {
+	return &Relay{client: &http.Client{Timeout: 30 * time.Second}, upstream: lookup}
+}
+
+// Remote reports whether repo is configured to be forwarded to an
+// upstream gateway, returning its Upstream if so.
+func (rl *Relay) Remote(repo string) (Upstream, bool) {
+	return rl.upstream(repo)
+}
+
+type upstreamLeaseRequest struct {
+	KeyID string `json:"key_id"`
+	Path  string `json:"path"`
+}
+
+type upstreamLeaseResponse struct {
+	Status  string `json:"status"`
+	Token   string `json:"session_token"`
+	Message string `json:"message,omitempty"`
+}
+
+// NewLease requests a lease for path from up, authenticating as up's own
+// key rather than the local publisher's, and returns the token the
+// upstream issued.
+func (rl *Relay) NewLease(up Upstream, path string) (string, error) {
+	body, err := json.Marshal(upstreamLeaseRequest{KeyID: up.KeyID, Path: path})
+	if err != nil {
+		return "", fmt.Errorf("relay: could not encode lease request: %w", err)
+	}
+
+	req, err := http.NewRequest(http.MethodPost, up.URL+"/api/v1/leases", bytes.NewReader(body))
+	if err != nil {
+		return "", fmt.Errorf("relay: could not build lease request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("relay: upstream lease request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	var lr upstreamLeaseResponse
+	if err := json.NewDecoder(resp.Body).Decode(&lr); err != nil {
+		return "", fmt.Errorf("relay: could not decode upstream lease response: %w", err)
+	}
+	if lr.Token == "" {
+		return "", fmt.Errorf("relay: upstream rejected lease: %s", lr.Message)
+	}
+	return lr.Token, nil
+}
+
+// CommitPayload forwards a payload submission to up, presenting token
+// (the one NewLease returned) and the same digest, tag, and body the
+// local publisher sent, and returns the upstream's raw JSON response
+// body so the local gateway can pass it through unchanged.
+func (rl *Relay) CommitPayload(up Upstream, path, token, digest, tag string, body []byte) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodPost, up.URL+"/api/v1/leases/"+path, bytes.NewReader(body))
+	if err != nil {
+		return nil, 0, fmt.Errorf("relay: could not build commit request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	if digest != "" {
+		req.Header.Set("X-Digest", digest)
+	}
+	if tag != "" {
+		req.Header.Set("X-Tag", tag)
+	}
+
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("relay: upstream commit request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("relay: could not read upstream commit response: %w", err)
+	}
+	return respBody, resp.StatusCode, nil
+}
+
+// CancelLease forwards a lease cancellation to up.
+func (rl *Relay) CancelLease(up Upstream, path, token string) ([]byte, int, error) {
+	req, err := http.NewRequest(http.MethodDelete, up.URL+"/api/v1/leases/"+path, nil)
+	if err != nil {
+		return nil, 0, fmt.Errorf("relay: could not build cancel request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := rl.client.Do(req)
+	if err != nil {
+		return nil, 0, fmt.Errorf("relay: upstream cancel request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, 0, fmt.Errorf("relay: could not read upstream cancel response: %w", err)
+	}
+	return respBody, resp.StatusCode, nil
+}
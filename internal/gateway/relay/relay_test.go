@@ -0,0 +1,75 @@
+package relay
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestNewLeaseReturnsUpstreamToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req upstreamLeaseRequest
+		json.NewDecoder(r.Body).Decode(&req)
+		if req.KeyID != "edge-key" || req.Path != "remote.example.org/a" {
+			t.Fatalf("unexpected upstream request: %+v", req)
+		}
+		json.NewEncoder(w).Encode(upstreamLeaseResponse{Status: "ok", Token: "upstream-token"})
+	}))
+	defer srv.Close()
+
+	rl := New(func(repo string) (Upstream, bool) { return Upstream{}, false })
+	token, err := rl.NewLease(Upstream{URL: srv.URL, KeyID: "edge-key"}, "remote.example.org/a")
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if token != "upstream-token" {
+		t.Fatalf("expected upstream-token, got %s", token)
+	}
+}
+
+func TestNewLeaseFailsOnUpstreamRejection(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		json.NewEncoder(w).Encode(upstreamLeaseResponse{Status: "error", Message: "path busy"})
+	}))
+	defer srv.Close()
+
+	rl := New(func(repo string) (Upstream, bool) { return Upstream{}, false })
+	if _, err := rl.NewLease(Upstream{URL: srv.URL, KeyID: "edge-key"}, "remote.example.org/a"); err == nil {
+		t.Fatal("expected upstream rejection to be surfaced as an error")
+	}
+}
+
+func TestCommitPayloadForwardsHeadersAndBody(t *testing.T) {
+	var gotAuth, gotDigest, gotTag string
+	var gotBody []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		gotAuth = r.Header.Get("Authorization")
+		gotDigest = r.Header.Get("X-Digest")
+		gotTag = r.Header.Get("X-Tag")
+		buf := make([]byte, r.ContentLength)
+		r.Body.Read(buf)
+		gotBody = buf
+		w.WriteHeader(http.StatusOK)
+		w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer srv.Close()
+
+	rl := New(func(repo string) (Upstream, bool) { return Upstream{}, false })
+	respBody, status, err := rl.CommitPayload(Upstream{URL: srv.URL}, "remote.example.org/a", "tok", "sha256:abc", "tagA", []byte("payload"))
+	if err != nil {
+		t.Fatalf("CommitPayload: %v", err)
+	}
+	if status != http.StatusOK {
+		t.Fatalf("expected 200, got %d", status)
+	}
+	if gotAuth != "Bearer tok" || gotDigest != "sha256:abc" || gotTag != "tagA" {
+		t.Fatalf("unexpected forwarded headers: auth=%q digest=%q tag=%q", gotAuth, gotDigest, gotTag)
+	}
+	if string(gotBody) != "payload" {
+		t.Fatalf("expected forwarded body %q, got %q", "payload", gotBody)
+	}
+	if string(respBody) != `{"status":"ok"}` {
+		t.Fatalf("unexpected response body: %s", respBody)
+	}
+}
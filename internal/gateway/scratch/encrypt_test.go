@@ -0,0 +1,110 @@
+package scratch
+
+import (
+	"bytes"
+	"io"
+	"path/filepath"
+	"testing"
+)
+
+func TestEncryptingStoreRoundTrip(t *testing.T) {
+	keys, err := OpenKeyStore(filepath.Join(t.TempDir(), "scratch.keys"))
+	if err != nil {
+		t.Fatalf("OpenKeyStore: %v", err)
+	}
+	store := NewEncryptingStore(NewMemStore(), RepoKeyLookup(keys))
+
+	w, err := store.Create("myrepo/blob")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := store.Open("myrepo/blob")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+}
+
+func TestEncryptingStoreWritesCiphertextToInnerStore(t *testing.T) {
+	keys, err := OpenKeyStore(filepath.Join(t.TempDir(), "scratch.keys"))
+	if err != nil {
+		t.Fatalf("OpenKeyStore: %v", err)
+	}
+	inner := NewMemStore()
+	store := NewEncryptingStore(inner, RepoKeyLookup(keys))
+
+	w, err := store.Create("myrepo/blob")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := inner.Open("myrepo/blob")
+	if err != nil {
+		t.Fatalf("Open on inner store: %v", err)
+	}
+	defer r.Close()
+	raw, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if bytes.Contains(raw, []byte("hello")) {
+		t.Fatal("expected the inner store to hold ciphertext, not the plaintext")
+	}
+}
+
+func TestEncryptingStoreRejectsBlobNameWithoutRepoPrefix(t *testing.T) {
+	keys, err := OpenKeyStore(filepath.Join(t.TempDir(), "scratch.keys"))
+	if err != nil {
+		t.Fatalf("OpenKeyStore: %v", err)
+	}
+	store := NewEncryptingStore(NewMemStore(), RepoKeyLookup(keys))
+
+	if _, err := store.Create("blob"); err == nil {
+		t.Fatal("expected Create to reject a blob name with no repository prefix")
+	}
+}
+
+func TestKeyStorePersistsKeysAcrossReopen(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "scratch.keys")
+
+	keys, err := OpenKeyStore(path)
+	if err != nil {
+		t.Fatalf("OpenKeyStore: %v", err)
+	}
+	key, err := keys.KeyFor("myrepo")
+	if err != nil {
+		t.Fatalf("KeyFor: %v", err)
+	}
+
+	reopened, err := OpenKeyStore(path)
+	if err != nil {
+		t.Fatalf("re-OpenKeyStore: %v", err)
+	}
+	again, err := reopened.KeyFor("myrepo")
+	if err != nil {
+		t.Fatalf("KeyFor after reopen: %v", err)
+	}
+	if !bytes.Equal(key, again) {
+		t.Fatal("expected the same repository to get the same key across a reopen")
+	}
+}
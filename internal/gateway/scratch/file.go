@@ -0,0 +1,53 @@
+package scratch
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FileStore is a Store backed by a directory on the local filesystem.
+// It's the gateway's default scratch backend.
+type FileStore struct {
+	root string
+}
+
+// NewFileStore returns a FileStore rooted at dir. dir must already exist;
+// Stat is what verifies that.
+func NewFileStore(dir string) *FileStore {
+	return &FileStore{root: dir}
+}
+
+// Stat reports whether the root directory exists and is reachable.
+func (f *FileStore) Stat() error {
+	_, err := os.Stat(f.root)
+	return err
+}
+
+// path resolves name to a file under root, discarding any directory
+// components so a caller can't be tricked into escaping the scratch
+// root.
+func (f *FileStore) path(name string) string {
+	return filepath.Join(f.root, filepath.Base(name))
+}
+
+// Create opens name under root for writing, truncating it if it already
+// exists.
+func (f *FileStore) Create(name string) (io.WriteCloser, error) {
+	return os.Create(f.path(name))
+}
+
+// Open opens name under root for reading.
+func (f *FileStore) Open(name string) (io.ReadCloser, error) {
+	return os.Open(f.path(name))
+}
+
+// Remove deletes name under root. It is not an error for name to not
+// exist.
+func (f *FileStore) Remove(name string) error {
+	err := os.Remove(f.path(name))
+	if os.IsNotExist(err) {
+		return nil
+	}
+	return err
+}
@@ -0,0 +1,77 @@
+package scratch
+
+import (
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/atomicfile"
+)
+
+// encryptionKeyBytes is the length of a generated per-repository
+// encryption key: 32 bytes for AES-256.
+const encryptionKeyBytes = 32
+
+// KeyStore persists per-repository scratch encryption keys, generating a
+// fresh key the first time a repository is seen and reusing it
+// thereafter, so blobs written under an old key stay decryptable across
+// a gateway restart.
+type KeyStore struct {
+	path string
+
+	mu   sync.Mutex
+	keys map[string][]byte
+}
+
+// OpenKeyStore loads the per-repository keys persisted at path,
+// starting with an empty set if the file doesn't exist yet.
+func OpenKeyStore(path string) (*KeyStore, error) {
+	k := &KeyStore{path: path, keys: make(map[string][]byte)}
+
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		return k, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("scratch: could not read key store: %w", err)
+	}
+	if err := json.Unmarshal(buf, &k.keys); err != nil {
+		return nil, fmt.Errorf("scratch: could not parse key store: %w", err)
+	}
+	return k, nil
+}
+
+// KeyFor returns repo's encryption key, generating and persisting one on
+// first use.
+func (k *KeyStore) KeyFor(repo string) ([]byte, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if key, ok := k.keys[repo]; ok {
+		return key, nil
+	}
+
+	key := make([]byte, encryptionKeyBytes)
+	if _, err := rand.Read(key); err != nil {
+		return nil, fmt.Errorf("scratch: could not generate key for %s: %w", repo, err)
+	}
+	k.keys[repo] = key
+	if err := k.persist(); err != nil {
+		return nil, err
+	}
+	return key, nil
+}
+
+// persist assumes the caller holds k.mu.
+func (k *KeyStore) persist() error {
+	buf, err := json.Marshal(k.keys)
+	if err != nil {
+		return fmt.Errorf("scratch: could not encode key store: %w", err)
+	}
+	if err := atomicfile.Write(k.path, buf, 0600); err != nil {
+		return fmt.Errorf("scratch: could not write key store: %w", err)
+	}
+	return nil
+}
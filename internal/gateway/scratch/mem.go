@@ -0,0 +1,65 @@
+package scratch
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sync"
+)
+
+// MemStore is an in-memory Store implementation for tests.
+type MemStore struct {
+	mu   sync.Mutex
+	data map[string][]byte
+}
+
+// NewMemStore returns an empty in-memory scratch store.
+func NewMemStore() *MemStore {
+	return &MemStore{data: make(map[string][]byte)}
+}
+
+// Stat always succeeds: an in-memory store has no underlying reachability
+// to lose.
+func (m *MemStore) Stat() error { return nil }
+
+// Create returns a writer that buffers name's contents in memory until
+// Close, at which point it becomes visible to Open.
+func (m *MemStore) Create(name string) (io.WriteCloser, error) {
+	return &memWriter{store: m, name: name}, nil
+}
+
+// Open returns a reader over name's most recently written contents.
+func (m *MemStore) Open(name string) (io.ReadCloser, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	data, ok := m.data[name]
+	if !ok {
+		return nil, fmt.Errorf("scratch: no such blob: %s", name)
+	}
+	return io.NopCloser(bytes.NewReader(data)), nil
+}
+
+// Remove deletes name. It is not an error for name to not exist.
+func (m *MemStore) Remove(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.data, name)
+	return nil
+}
+
+type memWriter struct {
+	store *MemStore
+	name  string
+	buf   bytes.Buffer
+}
+
+func (w *memWriter) Write(p []byte) (int, error) {
+	return w.buf.Write(p)
+}
+
+func (w *memWriter) Close() error {
+	w.store.mu.Lock()
+	defer w.store.mu.Unlock()
+	w.store.data[w.name] = w.buf.Bytes()
+	return nil
+}
@@ -0,0 +1,28 @@
+// Package scratch abstracts the gateway's local scratch space — the
+// staging area for payload data too large to hold comfortably in memory
+// while a commit is in flight — behind a small Store interface, so a
+// deployment can back it with a plain directory, a tmpfs mount, or (in
+// tests) an in-memory implementation without changing any of the call
+// sites that use it.
+package scratch
+
+import "io"
+
+// Store creates, reads, and removes named scratch blobs, and reports
+// whether the underlying storage is currently reachable. Implementations
+// must be safe for concurrent use.
+type Store interface {
+	// Stat reports whether the store is reachable.
+	Stat() error
+
+	// Create opens name for writing, truncating it if it already
+	// exists. The caller must Close the returned writer.
+	Create(name string) (io.WriteCloser, error)
+
+	// Open opens name for reading. The caller must Close the returned
+	// reader.
+	Open(name string) (io.ReadCloser, error)
+
+	// Remove deletes name. It is not an error for name to not exist.
+	Remove(name string) error
+}
@@ -0,0 +1,62 @@
+package scratch
+
+import (
+	"io"
+	"testing"
+)
+
+func testRoundTrip(t *testing.T, store Store) {
+	t.Helper()
+	if err := store.Stat(); err != nil {
+		t.Fatalf("Stat: %v", err)
+	}
+
+	w, err := store.Create("blob")
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := w.Write([]byte("hello")); err != nil {
+		t.Fatalf("Write: %v", err)
+	}
+	if err := w.Close(); err != nil {
+		t.Fatalf("Close: %v", err)
+	}
+
+	r, err := store.Open("blob")
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer r.Close()
+	got, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(got) != "hello" {
+		t.Fatalf("got %q, want %q", got, "hello")
+	}
+
+	if err := store.Remove("blob"); err != nil {
+		t.Fatalf("Remove: %v", err)
+	}
+	if _, err := store.Open("blob"); err == nil {
+		t.Fatal("expected Open to fail after Remove")
+	}
+	if err := store.Remove("blob"); err != nil {
+		t.Fatalf("Remove of an already-removed blob should be a no-op, got %v", err)
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	testRoundTrip(t, NewFileStore(t.TempDir()))
+}
+
+func TestFileStoreStatFailsForMissingDir(t *testing.T) {
+	store := NewFileStore("/does/not/exist")
+	if err := store.Stat(); err == nil {
+		t.Fatal("expected Stat to fail for a missing scratch directory")
+	}
+}
+
+func TestMemStoreRoundTrip(t *testing.T) {
+	testRoundTrip(t, NewMemStore())
+}
@@ -0,0 +1,108 @@
+// Package shadow implements request mirroring for shadow testing:
+// duplicating a configurable fraction of non-destructive API traffic to
+// a second gateway instance and logging any response mismatch, so a new
+// gateway version can be validated against production load before it
+// takes live traffic.
+package shadow
+
+import (
+	"bytes"
+	"io"
+	"math/rand"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/ratelog"
+)
+
+// logInterval bounds how often Mirror logs the same kind of failure for
+// the same shadow URL, so a shadow gateway that's persistently down
+// doesn't flood the logs with one line per mirrored request.
+const logInterval = time.Minute
+
+var logLimiter = ratelog.NewLimiter(logInterval)
+
+// Mirror wraps an http.Handler, asynchronously replaying a sample of its
+// GET and HEAD requests against a shadow gateway and comparing the two
+// responses. Mismatches are logged; they never affect what the caller
+// sees, which is always the primary handler's own response.
+type Mirror struct {
+	next      http.Handler
+	shadowURL string
+	fraction  float64
+	client    *http.Client
+
+	// rand returns a value in [0, 1); overridable so sampling is
+	// deterministic in tests.
+	rand func() float64
+}
+
+// NewMirror returns a Mirror that forwards a sample of next's GET and
+// HEAD requests to shadowURL. fraction is the sampling rate, from 0.0
+// (mirror nothing) to 1.0 (mirror everything).
+func NewMirror(next http.Handler, shadowURL string, fraction float64) *Mirror {
+	return &Mirror{
+		next:      next,
+		shadowURL: shadowURL,
+		fraction:  fraction,
+		client:    &http.Client{Timeout: 10 * time.Second},
+		rand:      rand.Float64,
+	}
+}
+
+// ServeHTTP serves r with the wrapped handler and, for a sampled
+// fraction of non-destructive requests, replays it against the shadow
+// gateway in the background.
+func (m *Mirror) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !mirrorable(r) || m.rand() >= m.fraction {
+		m.next.ServeHTTP(w, r)
+		return
+	}
+
+	rec := httptest.NewRecorder()
+	m.next.ServeHTTP(rec, r)
+
+	for k, v := range rec.Header() {
+		w.Header()[k] = v
+	}
+	w.WriteHeader(rec.Code)
+	w.Write(rec.Body.Bytes())
+
+	go m.replay(r, rec.Code, rec.Body.Bytes())
+}
+
+// mirrorable reports whether r is safe to duplicate: only read-only
+// requests are mirrored, since a shadow gateway must never be allowed to
+// mutate production state (leases, commits) as a side effect of testing.
+func mirrorable(r *http.Request) bool {
+	return r.Method == http.MethodGet || r.Method == http.MethodHead
+}
+
+// replay resends r to the shadow gateway and logs a mismatch if its
+// response disagrees with the primary handler's.
+func (m *Mirror) replay(r *http.Request, primaryStatus int, primaryBody []byte) {
+	req, err := http.NewRequest(r.Method, m.shadowURL+r.URL.RequestURI(), nil)
+	if err != nil {
+		logLimiter.Printf("build:"+r.URL.Path, "shadow: could not build mirrored request for %s: %v", r.URL.Path, err)
+		return
+	}
+	req.Header = r.Header.Clone()
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		logLimiter.Printf("request:"+m.shadowURL, "shadow: mirrored request to %s failed: %v", m.shadowURL, err)
+		return
+	}
+	defer resp.Body.Close()
+
+	shadowBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		logLimiter.Printf("read:"+r.URL.Path, "shadow: could not read shadow response for %s: %v", r.URL.Path, err)
+		return
+	}
+
+	if resp.StatusCode != primaryStatus || !bytes.Equal(shadowBody, primaryBody) {
+		logLimiter.Printf("mismatch:"+r.Method+":"+r.URL.Path, "shadow: mismatch for %s %s: primary status %d, shadow status %d", r.Method, r.URL.Path, primaryStatus, resp.StatusCode)
+	}
+}
@@ -0,0 +1,83 @@
+package shadow
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMirrorAlwaysReturnsThePrimaryResponse(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.Write([]byte("primary"))
+	})
+
+	var shadowHits int
+	var mu sync.Mutex
+	shadowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		shadowHits++
+		mu.Unlock()
+		w.Write([]byte("primary"))
+	}))
+	defer shadowSrv.Close()
+
+	m := NewMirror(primary, shadowSrv.URL, 1.0)
+	m.rand = func() float64 { return 0 }
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+
+	if w.Code != http.StatusTeapot || w.Body.String() != "primary" {
+		t.Fatalf("expected the caller to see the primary response unchanged, got %d %q", w.Code, w.Body.String())
+	}
+
+	deadline := time.Now().Add(time.Second)
+	for time.Now().Before(deadline) {
+		mu.Lock()
+		hits := shadowHits
+		mu.Unlock()
+		if hits > 0 {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	t.Fatal("expected the request to be mirrored to the shadow gateway")
+}
+
+func TestMirrorSkipsDestructiveMethods(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	var shadowHit bool
+	shadowSrv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		shadowHit = true
+	}))
+	defer shadowSrv.Close()
+
+	m := NewMirror(primary, shadowSrv.URL, 1.0)
+	m.rand = func() float64 { return 0 }
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/api/v1/leases", nil))
+
+	time.Sleep(50 * time.Millisecond)
+	if shadowHit {
+		t.Fatal("expected a POST request to never be mirrored")
+	}
+}
+
+func TestMirrorRespectsSamplingFraction(t *testing.T) {
+	primary := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusOK) })
+	m := NewMirror(primary, "http://unused.invalid", 0.5)
+	m.rand = func() float64 { return 0.9 }
+
+	w := httptest.NewRecorder()
+	m.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/v1/health", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected the primary response regardless of sampling, got %d", w.Code)
+	}
+}
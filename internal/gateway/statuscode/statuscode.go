@@ -0,0 +1,24 @@
+// Package statuscode defines the string status codes returned in the
+// "status" field of gateway JSON responses.
+package statuscode
+
+const (
+	Ok                  = "ok"
+	Error               = "error"
+	InvalidToken        = "invalid_token"
+	PathBusy            = "path_busy"
+	InvalidLease        = "invalid_lease"
+	InvalidPath         = "invalid_path"
+	InvalidKey          = "invalid_key"
+	NotFound            = "not_found"
+	TransactionAborted  = "transaction_aborted"
+	TransactionTooLarge = "transaction_too_large"
+	CircuitOpen         = "circuit_open"
+	Draining            = "draining"
+	Overloaded          = "overloaded"
+	ClockSkew           = "clock_skew"
+	UnsupportedDigest   = "unsupported_digest_algorithm"
+	Frozen              = "frozen"
+	PendingApproval     = "pending_approval"
+	MaintenanceLocked   = "maintenance_locked"
+)
@@ -0,0 +1,64 @@
+// Package sysd implements the small parts of the systemd service protocol
+// the gateway needs: socket activation (inheriting listening sockets
+// systemd already bound) and readiness/status notification via
+// sd_notify, without a dependency on the C libsystemd library.
+package sysd
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+)
+
+// listenFDsStart is the first file descriptor number systemd hands to an
+// activated process, per the sd_listen_fds(3) protocol.
+const listenFDsStart = 3
+
+// Listeners returns the listening sockets passed by systemd via socket
+// activation, in the order systemd was configured to pass them. It
+// returns an empty slice, with no error, if the process was not socket
+// activated.
+func Listeners() ([]net.Listener, error) {
+	pid, _ := strconv.Atoi(os.Getenv("LISTEN_PID"))
+	if pid != os.Getpid() {
+		return nil, nil
+	}
+
+	nfds, err := strconv.Atoi(os.Getenv("LISTEN_FDS"))
+	if err != nil || nfds <= 0 {
+		return nil, nil
+	}
+
+	listeners := make([]net.Listener, 0, nfds)
+	for i := 0; i < nfds; i++ {
+		fd := listenFDsStart + i
+		file := os.NewFile(uintptr(fd), fmt.Sprintf("systemd-fd-%d", fd))
+		l, err := net.FileListener(file)
+		if err != nil {
+			return nil, fmt.Errorf("sysd: could not wrap fd %d as a listener: %w", fd, err)
+		}
+		listeners = append(listeners, l)
+	}
+	return listeners, nil
+}
+
+// Notify sends a state update to systemd via the NOTIFY_SOCKET protocol,
+// e.g. Notify("READY=1") or Notify("STATUS=serving requests"). It is a
+// no-op, returning nil, when NOTIFY_SOCKET is not set (the process was
+// not started by systemd, or notification was not requested).
+func Notify(state string) error {
+	addr := os.Getenv("NOTIFY_SOCKET")
+	if addr == "" {
+		return nil
+	}
+
+	conn, err := net.Dial("unixgram", addr)
+	if err != nil {
+		return fmt.Errorf("sysd: could not dial notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	return err
+}
@@ -0,0 +1,23 @@
+package sysd
+
+import "testing"
+
+func TestListenersNoActivation(t *testing.T) {
+	t.Setenv("LISTEN_PID", "")
+	t.Setenv("LISTEN_FDS", "")
+
+	listeners, err := Listeners()
+	if err != nil {
+		t.Fatalf("Listeners: %v", err)
+	}
+	if len(listeners) != 0 {
+		t.Fatalf("expected no listeners without socket activation, got %d", len(listeners))
+	}
+}
+
+func TestNotifyWithoutSocketIsNoop(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	if err := Notify("READY=1"); err != nil {
+		t.Fatalf("expected Notify to be a no-op without NOTIFY_SOCKET, got %v", err)
+	}
+}
@@ -0,0 +1,80 @@
+// Package throttle implements token-bucket bandwidth limiting for
+// payload uploads, so a single publisher (or the gateway as a whole)
+// can't saturate the link to storage at the expense of everyone else
+// sharing it.
+package throttle
+
+import (
+	"io"
+	"sync"
+	"time"
+)
+
+// Limiter caps throughput at ratePerSecond bytes per second, with bursts
+// up to burst bytes absorbed without waiting. A Limiter with
+// ratePerSecond <= 0 is unlimited: WaitN always returns immediately.
+type Limiter struct {
+	ratePerSecond int64
+	burst         int64
+
+	mu      sync.Mutex
+	tokens  float64
+	updated time.Time
+}
+
+// NewLimiter returns a Limiter allowing ratePerSecond bytes per second,
+// on average, with bursts up to burst bytes.
+func NewLimiter(ratePerSecond, burst int64) *Limiter {
+	return &Limiter{
+		ratePerSecond: ratePerSecond,
+		burst:         burst,
+		tokens:        float64(burst),
+		updated:       time.Now(),
+	}
+}
+
+// WaitN blocks until n bytes' worth of tokens are available, consumes
+// them, and returns how long it waited.
+func (l *Limiter) WaitN(n int64) time.Duration {
+	if l == nil || l.ratePerSecond <= 0 {
+		return 0
+	}
+
+	l.mu.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.updated).Seconds() * float64(l.ratePerSecond)
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.updated = now
+	l.tokens -= float64(n)
+
+	var wait time.Duration
+	if l.tokens < 0 {
+		wait = time.Duration(-l.tokens / float64(l.ratePerSecond) * float64(time.Second))
+	}
+	l.mu.Unlock()
+
+	if wait > 0 {
+		time.Sleep(wait)
+	}
+	return wait
+}
+
+// Reader wraps an underlying io.Reader, throttling its aggregate
+// throughput to R's rate. A nil R makes Reader a passthrough.
+type Reader struct {
+	Source io.Reader
+	R      *Limiter
+}
+
+// Read reads from the underlying source and throttles based on how many
+// bytes were read, so the caller's effective throughput is capped
+// without needing to know how large its buffer is up front.
+func (t *Reader) Read(p []byte) (int, error) {
+	n, err := t.Source.Read(p)
+	if n > 0 {
+		t.R.WaitN(int64(n))
+	}
+	return n, err
+}
@@ -0,0 +1,62 @@
+package throttle
+
+import (
+	"bytes"
+	"io"
+	"testing"
+	"time"
+)
+
+func TestLimiterUnlimitedDoesNotWait(t *testing.T) {
+	l := NewLimiter(0, 0)
+	if wait := l.WaitN(1 << 30); wait != 0 {
+		t.Fatalf("expected an unlimited Limiter to never wait, got %v", wait)
+	}
+}
+
+func TestLimiterAllowsBurstWithoutWaiting(t *testing.T) {
+	l := NewLimiter(1024, 4096)
+	if wait := l.WaitN(4096); wait != 0 {
+		t.Fatalf("expected the initial burst to be consumed without waiting, got %v", wait)
+	}
+}
+
+func TestLimiterWaitsOnceBurstIsExhausted(t *testing.T) {
+	l := NewLimiter(1024, 1024)
+	l.WaitN(1024)
+	if wait := l.WaitN(512); wait <= 0 {
+		t.Fatal("expected a wait once the burst allowance is exhausted")
+	}
+}
+
+func TestReaderThrottlesThroughLimiter(t *testing.T) {
+	l := NewLimiter(0, 0)
+	r := &Reader{Source: bytes.NewReader([]byte("hello world")), R: l}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(body) != "hello world" {
+		t.Fatalf("expected the wrapped reader's content to pass through unchanged, got %q", body)
+	}
+}
+
+func TestReaderWithNilLimiterIsPassthrough(t *testing.T) {
+	r := &Reader{Source: bytes.NewReader([]byte("data")), R: nil}
+	body, err := io.ReadAll(r)
+	if err != nil {
+		t.Fatalf("ReadAll: %v", err)
+	}
+	if string(body) != "data" {
+		t.Fatalf("expected passthrough content, got %q", body)
+	}
+}
+
+func TestLimiterRefillsOverTime(t *testing.T) {
+	l := NewLimiter(1<<20, 1)
+	l.WaitN(1)
+	time.Sleep(2 * time.Millisecond)
+	if wait := l.WaitN(1); wait != 0 {
+		t.Fatalf("expected tokens to have refilled after a brief sleep, got wait %v", wait)
+	}
+}
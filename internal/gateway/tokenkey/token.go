@@ -0,0 +1,84 @@
+package tokenkey
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Claims describes the lease a token was issued for, carried inside the
+// token itself so obvious mismatches (wrong path, expired lease) can be
+// rejected without a LeaseDB lookup.
+type Claims struct {
+	Repository string    `json:"repo"`
+	Path       string    `json:"path"`
+	KeyID      string    `json:"key_id"`
+	Expiry     time.Time `json:"expiry"`
+
+	// Instance identifies the gateway instance that issued this token,
+	// so a load-balanced deployment can route a later payload submission
+	// back to the instance holding the lease's in-memory upload
+	// progress instead of one that knows nothing about it. Empty on a
+	// single-instance deployment, where it wouldn't mean anything.
+	Instance string `json:"instance,omitempty"`
+}
+
+// ErrMalformedToken is returned when a token string isn't in the
+// claims.signingKeyID.mac format IssueToken produces.
+type ErrMalformedToken struct{}
+
+func (ErrMalformedToken) Error() string { return "tokenkey: malformed lease token" }
+
+// ErrInvalidSignature is returned when a token's signature doesn't
+// verify against either the current or, within the overlap window, the
+// previous signing key.
+type ErrInvalidSignature struct{}
+
+func (ErrInvalidSignature) Error() string { return "tokenkey: invalid lease token signature" }
+
+// IssueToken mints a signed, self-describing lease token: the base64url
+// encoded claims, the ID of the signing key used, and the base64url
+// encoded HMAC, joined with ".".
+func (s *Store) IssueToken(claims Claims) (string, error) {
+	body, err := json.Marshal(claims)
+	if err != nil {
+		return "", fmt.Errorf("tokenkey: could not encode claims: %w", err)
+	}
+	keyID, mac := s.Sign(body)
+	return strings.Join([]string{
+		base64.RawURLEncoding.EncodeToString(body),
+		keyID,
+		base64.RawURLEncoding.EncodeToString(mac),
+	}, "."), nil
+}
+
+// ParseToken verifies token's signature and decodes its claims. It does
+// not check the claims' expiry; callers compare Claims.Expiry against
+// the current time themselves, since what counts as "expired" can differ
+// slightly by call site (e.g. clock-skew tolerance).
+func (s *Store) ParseToken(token string) (Claims, error) {
+	parts := strings.SplitN(token, ".", 3)
+	if len(parts) != 3 {
+		return Claims{}, ErrMalformedToken{}
+	}
+
+	body, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return Claims{}, ErrMalformedToken{}
+	}
+	mac, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return Claims{}, ErrMalformedToken{}
+	}
+	if !s.Verify(parts[1], body, mac) {
+		return Claims{}, ErrInvalidSignature{}
+	}
+
+	var claims Claims
+	if err := json.Unmarshal(body, &claims); err != nil {
+		return Claims{}, ErrMalformedToken{}
+	}
+	return claims, nil
+}
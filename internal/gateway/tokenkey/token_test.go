@@ -0,0 +1,64 @@
+package tokenkey
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestIssueTokenRoundTripsClaims(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "signing-key.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	want := Claims{Repository: "repo.example.org", Path: "repo.example.org/a", KeyID: "keyA", Expiry: time.Now().Add(time.Minute), Instance: "gw-1"}
+	token, err := s.IssueToken(want)
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	got, err := s.ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken: %v", err)
+	}
+	if got.Repository != want.Repository || got.Path != want.Path || got.KeyID != want.KeyID || got.Instance != want.Instance {
+		t.Fatalf("expected claims to round-trip, got %+v", got)
+	}
+}
+
+func TestParseTokenRejectsTamperedClaims(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "signing-key.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	token, err := s.IssueToken(Claims{Repository: "repo.example.org", Path: "repo.example.org/a"})
+	if err != nil {
+		t.Fatalf("IssueToken: %v", err)
+	}
+
+	// Swap in different claims while keeping the original key ID and MAC.
+	parts := strings.SplitN(token, ".", 3)
+	forged, err := json.Marshal(Claims{Repository: "repo.example.org", Path: "repo.example.org/b"})
+	if err != nil {
+		t.Fatalf("json.Marshal: %v", err)
+	}
+	tampered := base64.RawURLEncoding.EncodeToString(forged) + "." + parts[1] + "." + parts[2]
+	if _, err := s.ParseToken(tampered); err == nil {
+		t.Fatal("expected a tampered token to fail verification")
+	}
+}
+
+func TestParseTokenRejectsMalformedInput(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "signing-key.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if _, err := s.ParseToken("not-a-token"); err == nil {
+		t.Fatal("expected a malformed token to be rejected")
+	}
+}
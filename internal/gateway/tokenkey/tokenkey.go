@@ -0,0 +1,187 @@
+// Package tokenkey manages the symmetric secret the gateway uses to sign
+// lease tokens. It persists the secret to disk so a process restart
+// doesn't silently invalidate every outstanding lease, and supports
+// rotating to a new secret with an overlap window so tokens signed by
+// the previous secret keep validating until they expire naturally
+// instead of being invalidated out from under an in-flight publish.
+package tokenkey
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/atomicfile"
+)
+
+// secretBytes is the length of a generated signing secret.
+const secretBytes = 32
+
+// Key is a single generation of the token signing secret.
+type Key struct {
+	ID        string    `json:"id"`
+	Secret    []byte    `json:"secret"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// file is the on-disk persistence format.
+type file struct {
+	Current  Key  `json:"current"`
+	Previous *Key `json:"previous,omitempty"`
+}
+
+// Store manages the current signing key, persisting every change to
+// path, and retains the previous key for Overlap after a rotation so
+// tokens signed under it keep validating during the handoff.
+type Store struct {
+	path    string
+	overlap time.Duration
+
+	mu       sync.RWMutex
+	current  Key
+	previous *Key
+}
+
+func generateKey() (Key, error) {
+	secret := make([]byte, secretBytes)
+	if _, err := rand.Read(secret); err != nil {
+		return Key{}, fmt.Errorf("tokenkey: could not generate signing secret: %w", err)
+	}
+	id := make([]byte, 4)
+	if _, err := rand.Read(id); err != nil {
+		return Key{}, fmt.Errorf("tokenkey: could not generate key id: %w", err)
+	}
+	return Key{ID: hex.EncodeToString(id), Secret: secret, CreatedAt: time.Now()}, nil
+}
+
+// Open loads the signing key persisted at path, generating and
+// persisting a fresh one on first use if the file doesn't exist yet.
+// overlap controls how long a rotated-out key keeps validating tokens.
+func Open(path string, overlap time.Duration) (*Store, error) {
+	s := &Store{path: path, overlap: overlap}
+
+	buf, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		key, err := generateKey()
+		if err != nil {
+			return nil, err
+		}
+		s.current = key
+		if err := s.persist(); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("tokenkey: could not read signing key file: %w", err)
+	}
+
+	var f file
+	if err := json.Unmarshal(buf, &f); err != nil {
+		return nil, fmt.Errorf("tokenkey: could not parse signing key file: %w", err)
+	}
+	s.current = f.Current
+	s.previous = f.Previous
+	if s.previous != nil {
+		// The current key was created by the rotation that retired the
+		// previous one, so the overlap window runs from its CreatedAt.
+		remaining := overlap - time.Since(s.current.CreatedAt)
+		if remaining <= 0 {
+			s.previous = nil
+		} else {
+			time.AfterFunc(remaining, s.expirePrevious)
+		}
+	}
+	return s, nil
+}
+
+func (s *Store) persist() error {
+	f := file{Current: s.current, Previous: s.previous}
+	buf, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("tokenkey: could not encode signing key file: %w", err)
+	}
+	if err := atomicfile.Write(s.path, buf, 0600); err != nil {
+		return fmt.Errorf("tokenkey: could not write signing key file: %w", err)
+	}
+	return nil
+}
+
+// Current returns the key currently used to sign new tokens.
+func (s *Store) Current() Key {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current
+}
+
+// Rotate generates a fresh signing key and persists it as current,
+// retaining the outgoing key as previous for Overlap before it stops
+// validating tokens.
+func (s *Store) Rotate() error {
+	key, err := generateKey()
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	outgoing := s.current
+	s.previous = &outgoing
+	s.current = key
+	err = s.persist()
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+
+	time.AfterFunc(s.overlap, s.expirePrevious)
+	return nil
+}
+
+func (s *Store) expirePrevious() {
+	s.mu.Lock()
+	s.previous = nil
+	err := s.persist()
+	s.mu.Unlock()
+	if err != nil {
+		// Losing this write only delays clearing the previous key from
+		// disk; the next successful Rotate or restart still reflects the
+		// invalidation in memory and will retry on its own persist.
+		return
+	}
+}
+
+// Sign returns the HMAC-SHA256 of data under the current key, alongside
+// the key's ID so Verify can pick the right key without guessing.
+func (s *Store) Sign(data []byte) (keyID string, mac []byte) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	return s.current.ID, hmacSum(s.current.Secret, data)
+}
+
+// Verify reports whether mac is a valid HMAC-SHA256 of data under the key
+// identified by keyID, considering both the current key and, within the
+// rotation overlap window, the previous one.
+func (s *Store) Verify(keyID string, data, mac []byte) bool {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	if keyID == s.current.ID {
+		return hmac.Equal(mac, hmacSum(s.current.Secret, data))
+	}
+	if s.previous != nil && keyID == s.previous.ID {
+		return hmac.Equal(mac, hmacSum(s.previous.Secret, data))
+	}
+	return false
+}
+
+func hmacSum(secret, data []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,68 @@
+package tokenkey
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestOpenPersistsAGeneratedKeyAcrossReopens(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "signing-key.json")
+
+	first, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	second, err := Open(path, time.Minute)
+	if err != nil {
+		t.Fatalf("re-Open: %v", err)
+	}
+	if first.Current().ID != second.Current().ID {
+		t.Fatal("expected reopening the store to load the same persisted key")
+	}
+}
+
+func TestSignAndVerifyRoundTrip(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "signing-key.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	keyID, mac := s.Sign([]byte("repo.example.org/a"))
+	if !s.Verify(keyID, []byte("repo.example.org/a"), mac) {
+		t.Fatal("expected a freshly signed token to verify")
+	}
+	if s.Verify(keyID, []byte("tampered"), mac) {
+		t.Fatal("expected verification to fail for tampered data")
+	}
+}
+
+func TestRotateKeepsPreviousKeyValidDuringOverlap(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "signing-key.json"), time.Hour)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+
+	oldKeyID, mac := s.Sign([]byte("repo.example.org/a"))
+	if err := s.Rotate(); err != nil {
+		t.Fatalf("Rotate: %v", err)
+	}
+
+	if s.Current().ID == oldKeyID {
+		t.Fatal("expected Rotate to install a new current key")
+	}
+	if !s.Verify(oldKeyID, []byte("repo.example.org/a"), mac) {
+		t.Fatal("expected the previous key to still verify within the overlap window")
+	}
+}
+
+func TestVerifyRejectsUnknownKeyID(t *testing.T) {
+	s, err := Open(filepath.Join(t.TempDir(), "signing-key.json"), time.Minute)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	if s.Verify("does-not-exist", []byte("data"), []byte("mac")) {
+		t.Fatal("expected verification against an unknown key id to fail")
+	}
+}
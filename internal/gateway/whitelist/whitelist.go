@@ -0,0 +1,55 @@
+// Package whitelist implements an external whitelist signing service
+// client, for gateways that delegate repository whitelist re-signing to
+// a signing service instead of the receiver process, e.g. because the
+// signing key is held in an HSM the gateway host itself can't reach.
+package whitelist
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// HTTPSigner implements receiver.WhitelistSigner by delegating to an
+// external signing service over HTTP.
+type HTTPSigner struct {
+	// URL is the signing service's base URL, e.g.
+	// "https://signer.example.org". ResignWhitelist POSTs to
+	// URL+"/"+repository.
+	URL string
+
+	client *http.Client
+}
+
+// NewHTTPSigner returns an HTTPSigner for the signing service at url,
+// with a 30-second HTTP timeout.
+func NewHTTPSigner(url string) *HTTPSigner {
+	return &HTTPSigner{URL: url, client: &http.Client{Timeout: 30 * time.Second}}
+}
+
+type resignResponse struct {
+	Expiry time.Time `json:"expiry"`
+}
+
+// ResignWhitelist asks the external signing service to re-sign
+// repository's whitelist and reports the resulting expiry.
+func (s *HTTPSigner) ResignWhitelist(repository string) (receiver.WhitelistInfo, error) {
+	resp, err := s.client.Post(s.URL+"/"+repository, "application/json", nil)
+	if err != nil {
+		return receiver.WhitelistInfo{}, fmt.Errorf("whitelist: signing service request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return receiver.WhitelistInfo{}, fmt.Errorf("whitelist: signing service returned status %d", resp.StatusCode)
+	}
+
+	var body resignResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return receiver.WhitelistInfo{}, fmt.Errorf("whitelist: could not decode signing service response: %w", err)
+	}
+	return receiver.WhitelistInfo{Repository: repository, SignedAt: time.Now(), Expiry: body.Expiry}, nil
+}
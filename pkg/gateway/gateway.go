@@ -0,0 +1,62 @@
+// Package gateway is the embeddable library form of the CVMFS repository
+// gateway: the same lease and commit logic the HTTP API exposes, wired
+// for direct use by publisher tooling and tests that want gateway
+// semantics without running an HTTP server or a receiver-invoking round
+// trip through net/http.
+package gateway
+
+import (
+	"fmt"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+// Gateway wraps a backend.Pool with the small, stable surface publisher
+// tooling embeds directly: acquire a lease, validate a payload, commit
+// it. Admin operations, diagnostics, and HTTP-specific concerns are
+// intentionally left to the frontend package, which this type doesn't
+// depend on.
+type Gateway struct {
+	pool *backend.Pool
+}
+
+// New wraps pool for embedded use. The caller is responsible for
+// constructing and configuring pool exactly as cmd/gateway does: lease
+// database, receiver, and any optional stores or watchdogs it wants.
+func New(pool *backend.Pool) *Gateway {
+	return &Gateway{pool: pool}
+}
+
+// NewLease acquires a lease on path for repository, exactly as the
+// /api/v1/leases HTTP endpoint does, returning backend.ErrPathBusy if
+// another publisher already holds it.
+func (g *Gateway) NewLease(keyID, token, repository, path string, maxBytes int64) (backend.Lease, error) {
+	return g.pool.NewLease(keyID, token, repository, path, maxBytes)
+}
+
+// SubmitPayload validates payload before Commit is called with it, so a
+// caller assembling a payload in several steps can fail fast on an
+// unsupported digest algorithm instead of discovering it only once the
+// receiver process is invoked.
+func (g *Gateway) SubmitPayload(payload receiver.Payload) error {
+	if !g.pool.SupportsDigestAlgorithm(payload.DigestAlgorithm) {
+		return fmt.Errorf("gateway: receiver does not support digest algorithm %q", payload.DigestAlgorithm)
+	}
+	return nil
+}
+
+// Commit applies payload to the lease held on path via the configured
+// receiver, exactly as the commit step of the /api/v1/leases/{path} HTTP
+// endpoint does, returning a summary of the transaction for the caller
+// to log.
+func (g *Gateway) Commit(token, path string, payload receiver.Payload) (backend.LeaseSummary, error) {
+	return g.pool.CommitLease(token, path, payload)
+}
+
+// Cancel releases the lease held on path without committing it, exactly
+// as the DELETE /api/v1/leases/{path} HTTP endpoint does, returning a
+// summary of what the lease had accumulated before it was cancelled.
+func (g *Gateway) Cancel(path string) (backend.LeaseSummary, error) {
+	return g.pool.CancelLease(path)
+}
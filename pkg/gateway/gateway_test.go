@@ -0,0 +1,55 @@
+package gateway
+
+import (
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeReceiver struct{}
+
+func (fakeReceiver) Commit(repository, path string, payload receiver.Payload) error {
+	return nil
+}
+
+func TestGatewayLeaseSubmitCommitRoundTrip(t *testing.T) {
+	pool := backend.NewPool(backend.NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	g := New(pool)
+
+	lease, err := g.NewLease("key1", "token1", "myrepo", "/path", 0)
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	payload := receiver.Payload{Digest: "abc", Data: []byte("data")}
+	if err := g.SubmitPayload(payload); err != nil {
+		t.Fatalf("SubmitPayload: %v", err)
+	}
+
+	if _, err := g.Commit(lease.Token, "/path", payload); err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+}
+
+func TestGatewaySubmitPayloadRejectsUnsupportedDigestAlgorithm(t *testing.T) {
+	pool := backend.NewPool(backend.NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	g := New(pool)
+
+	payload := receiver.Payload{Digest: "abc", Data: []byte("data"), DigestAlgorithm: "sha256"}
+	if err := g.SubmitPayload(payload); err == nil {
+		t.Fatal("expected SubmitPayload to reject a digest algorithm the receiver hasn't advertised")
+	}
+}
+
+func TestGatewayCancel(t *testing.T) {
+	pool := backend.NewPool(backend.NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	g := New(pool)
+
+	if _, err := g.NewLease("key1", "token1", "myrepo", "/path", 0); err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if _, err := g.Cancel("/path"); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+}
@@ -0,0 +1,160 @@
+// Package gatewayclient is a Go client for the gateway's /api/v1 HTTP
+// API, for publisher tooling that talks to a gateway over the network
+// instead of embedding it in-process via pkg/gateway. It's kept in sync
+// by hand with the OpenAPI document the gateway serves at
+// /api/v1/openapi.json (see internal/gateway/frontend/openapi.go); there's
+// no code-generation step in this repo's build, so a handler change that
+// affects the wire format should update both.
+package gatewayclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+)
+
+// Client talks to a single gateway instance's /api/v1 API over HTTP.
+type Client struct {
+	baseURL string
+	http    *http.Client
+}
+
+// New wraps baseURL (e.g. "https://gateway.example.org") for use by the
+// methods below. httpClient is used as-is if non-nil, so a caller can
+// supply one with its own timeout and TLS configuration; a nil
+// httpClient gets a default with a 30 second timeout.
+func New(baseURL string, httpClient *http.Client) *Client {
+	if httpClient == nil {
+		httpClient = &http.Client{Timeout: 30 * time.Second}
+	}
+	return &Client{baseURL: baseURL, http: httpClient}
+}
+
+// statusResponse mirrors frontend.statusResponse, the envelope every v1
+// failure response shares.
+type statusResponse struct {
+	Status  string `json:"status"`
+	Message string `json:"message,omitempty"`
+}
+
+// newLeaseResponse mirrors frontend.newLeaseResponse.
+type newLeaseResponse struct {
+	Status     string `json:"status"`
+	Token      string `json:"session_token,omitempty"`
+	UploadURL  string `json:"upload_url,omitempty"`
+	Expiration string `json:"expiration,omitempty"`
+	MaxBytes   int64  `json:"max_bytes,omitempty"`
+	Message    string `json:"message,omitempty"`
+}
+
+// commitResponse mirrors frontend.commitResponse.
+type commitResponse struct {
+	Status    string                `json:"status"`
+	ReceiptID string                `json:"receipt_id,omitempty"`
+	Summary   *backend.LeaseSummary `json:"summary,omitempty"`
+	Message   string                `json:"message,omitempty"`
+}
+
+// cancelResponse mirrors frontend.cancelResponse.
+type cancelResponse struct {
+	Status  string                `json:"status"`
+	Summary *backend.LeaseSummary `json:"summary,omitempty"`
+	Message string                `json:"message,omitempty"`
+}
+
+// Session is the outcome of a successful NewLease call: a session token
+// to authenticate the eventual Commit or Cancel call, and the path to
+// submit the payload to.
+type Session struct {
+	Token string
+	Path  string
+}
+
+// NewLease acquires a lease on path for repository, identified by keyID,
+// returning a Session to commit or cancel it with. err wraps the gateway's
+// status and message on any non-ok response.
+func (c *Client) NewLease(keyID, repository, path string) (Session, error) {
+	body, err := json.Marshal(map[string]string{"key_id": keyID, "path": repository + path})
+	if err != nil {
+		return Session{}, err
+	}
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/api/v1/leases", bytes.NewReader(body))
+	if err != nil {
+		return Session{}, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	var resp newLeaseResponse
+	if err := c.do(req, &resp); err != nil {
+		return Session{}, err
+	}
+	if resp.Status != "ok" {
+		return Session{}, fmt.Errorf("gatewayclient: new lease: %s: %s", resp.Status, resp.Message)
+	}
+	return Session{Token: resp.Token, Path: repository + path}, nil
+}
+
+// Commit submits payload and commits the lease held by session, returning
+// the transaction's LeaseSummary.
+func (c *Client) Commit(session Session, digest, tag string, payload io.Reader) (backend.LeaseSummary, error) {
+	req, err := http.NewRequest(http.MethodPost, c.baseURL+"/api/v1/leases/"+session.Path, payload)
+	if err != nil {
+		return backend.LeaseSummary{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+	req.Header.Set("X-Digest", digest)
+	if tag != "" {
+		req.Header.Set("X-Tag", tag)
+	}
+
+	var resp commitResponse
+	if err := c.do(req, &resp); err != nil {
+		return backend.LeaseSummary{}, err
+	}
+	if resp.Status != "ok" {
+		return backend.LeaseSummary{}, fmt.Errorf("gatewayclient: commit: %s: %s", resp.Status, resp.Message)
+	}
+	if resp.Summary == nil {
+		return backend.LeaseSummary{}, nil
+	}
+	return *resp.Summary, nil
+}
+
+// Cancel releases the lease held by session without committing it,
+// returning the LeaseSummary of what it had accumulated.
+func (c *Client) Cancel(session Session) (backend.LeaseSummary, error) {
+	req, err := http.NewRequest(http.MethodDelete, c.baseURL+"/api/v1/leases/"+session.Path, nil)
+	if err != nil {
+		return backend.LeaseSummary{}, err
+	}
+	req.Header.Set("Authorization", "Bearer "+session.Token)
+
+	var resp cancelResponse
+	if err := c.do(req, &resp); err != nil {
+		return backend.LeaseSummary{}, err
+	}
+	if resp.Status != "ok" {
+		return backend.LeaseSummary{}, fmt.Errorf("gatewayclient: cancel: %s: %s", resp.Status, resp.Message)
+	}
+	if resp.Summary == nil {
+		return backend.LeaseSummary{}, nil
+	}
+	return *resp.Summary, nil
+}
+
+// do sends req and decodes the JSON response body into out. It never
+// interprets the HTTP status code, since v1 returns 200 for most
+// statuscode.* failures; callers check out's Status field instead.
+func (c *Client) do(req *http.Request, out interface{}) error {
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	return json.NewDecoder(resp.Body).Decode(out)
+}
@@ -0,0 +1,99 @@
+package gatewayclient
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/cvmfs/cvmfs_services/internal/gateway/access"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/backend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/frontend"
+	"github.com/cvmfs/cvmfs_services/internal/gateway/receiver"
+)
+
+type fakeReceiver struct{}
+
+func (fakeReceiver) Commit(repository, path string, payload receiver.Payload) error {
+	return nil
+}
+
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+
+	cfgFile := filepath.Join(t.TempDir(), "access.json")
+	raw, err := json.Marshal(map[string]interface{}{
+		"keys":  []map[string]interface{}{{"id": "key1"}},
+		"repos": []map[string]interface{}{{"domain": "myrepo", "keys": []string{"key1"}}},
+	})
+	if err != nil {
+		t.Fatalf("marshal access config: %v", err)
+	}
+	if err := os.WriteFile(cfgFile, raw, 0600); err != nil {
+		t.Fatalf("write access config: %v", err)
+	}
+	acc, err := access.ReadConfig(cfgFile)
+	if err != nil {
+		t.Fatalf("access.ReadConfig: %v", err)
+	}
+
+	pool := backend.NewPool(backend.NewMemLeaseDB(), fakeReceiver{}, func() error { return nil })
+	api := frontend.NewAPI(pool, acc)
+
+	mux := http.NewServeMux()
+	api.Register(mux)
+	return httptest.NewServer(mux)
+}
+
+func TestClientLeaseCommitRoundTrip(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	c := New(srv.URL, nil)
+
+	session, err := c.NewLease("key1", "myrepo", "/path")
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+	if session.Token == "" {
+		t.Fatal("expected a non-empty session token")
+	}
+
+	summary, err := c.Commit(session, "abc", "", bytes.NewReader([]byte("data")))
+	if err != nil {
+		t.Fatalf("Commit: %v", err)
+	}
+	if summary.Path != session.Path {
+		t.Fatalf("expected summary for %q, got %q", session.Path, summary.Path)
+	}
+}
+
+func TestClientCancel(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	c := New(srv.URL, nil)
+
+	session, err := c.NewLease("key1", "myrepo", "/path")
+	if err != nil {
+		t.Fatalf("NewLease: %v", err)
+	}
+
+	if _, err := c.Cancel(session); err != nil {
+		t.Fatalf("Cancel: %v", err)
+	}
+}
+
+func TestClientNewLeaseRejectsUnauthorizedKey(t *testing.T) {
+	srv := newTestServer(t)
+	defer srv.Close()
+
+	c := New(srv.URL, nil)
+
+	if _, err := c.NewLease("unknown-key", "myrepo", "/path"); err == nil {
+		t.Fatal("expected an error for an unauthorized key")
+	}
+}